@@ -10,6 +10,7 @@ export function createLambda(
 	bucket: IBucket,
 	environment: Record<string, string>,
 	description?: string,
+	memorySize?: number,
 ): lambda.Function {
 	return new lambda.Function(th, funcName, {
 		code: lambda.Code.fromBucket(
@@ -22,7 +23,7 @@ export function createLambda(
 		description: description ?? `Lambda function for ${funcName}`,
 		tracing: lambda.Tracing.ACTIVE,
 		timeout: Duration.seconds(120),
-		memorySize: 256,
+		memorySize: memorySize ?? 256,
 		environment
 	})
 }
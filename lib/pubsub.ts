@@ -3,9 +3,15 @@ import { Construct } from "constructs";
 import { Comments } from "../services/comments/lib/comments-stack";
 import { Posts } from "../services/posts/lib/posts-stack";
 import { Notifications } from "../services/notifications/lib/notification";
+import { Inbox } from "../services/notifications/lib/inbox";
 import { Likes } from "../services/likes/lib/likes";
 import * as events from 'aws-cdk-lib/aws-events';
 import { Social } from "../services/social/lib/social";
+import { Auth } from "../services/auth/lib/auth";
+import { Outbox } from "../services/outbox/lib/outbox";
+import { Feed } from "../services/feed/lib/feed";
+import { Users } from "../services/users/lib/users";
+import { Search } from "../services/search/lib/search";
 
 export class PubSub extends Stack {
 	constructor(scope: Construct, id: string, props?: StackProps) {
@@ -16,21 +22,53 @@ export class PubSub extends Stack {
 		const account = this.account
 		const db_url = process.env.DB_ADDRESS;
 		const isProd = process.env.IS_PROD === "true";
+		const session_secret = process.env.SESSION_SECRET;
 
 		const eventBus = new events.EventBus(this, "NotificationsEventBus", {
 			eventBusName: "notifications",
 		})
 
-		new Posts(this, "PostsStack", { db_url: db_url, eventBus });
-		new Comments(this, "CommentsStack", { db_url: db_url, eventBus });
+		new Auth(this, "AuthStack", {
+			db_url,
+			session_secret,
+			session_encryption_key: process.env.SESSION_ENCRYPTION_KEY,
+			google_client_id: process.env.PUBSUB_GOOGLE_CLIENT_ID,
+			google_client_secret: process.env.PUBSUB_GOOGLE_CLIENT_SECRET,
+			callback_url: process.env.AUTH_CALLBACK_URL,
+			client_origins: process.env.CLIENT_ORIGINS,
+			isProd,
+		});
+
+		const posts = new Posts(this, "PostsStack", {
+			db_url: db_url,
+			session_secret,
+			media_base_url: process.env.MEDIA_BASE_URL,
+			s3_endpoint: process.env.S3_ENDPOINT,
+		});
+		new Users(this, "UsersStack", {
+			db_url,
+			session_secret,
+			media_base_url: posts.mediaBaseUrl,
+		});
+		new Comments(this, "CommentsStack", { db_url: db_url, session_secret });
+		new Search(this, "SearchStack", { db_url, media_base_url: posts.mediaBaseUrl });
 		/**
 		new Notifications(
 			this,
 			"NotificationsStack",
-			{ regionsToReplicate, region, account, isProd, db_url, eventBus }
+			{ regionsToReplicate, region, account, isProd, db_url, session_secret, eventBus }
 		);
 		*/
-		new Likes(this, "LikesStack", { db_url: db_url, eventBus });
-		new Social(this, "SocialStack", { db_url: db_url, eventBus });
+		new Inbox(this, "InboxStack", { db_url, session_secret });
+		new Likes(this, "LikesStack", { db_url: db_url, session_secret });
+		new Social(this, "SocialStack", { db_url: db_url, session_secret, eventBus });
+		new Outbox(this, "OutboxStack", { db_url: db_url, eventBus });
+		new Feed(this, "FeedStack", {
+			db_url: db_url,
+			session_secret,
+			ranker: process.env.FEED_RANKER,
+			media_base_url: posts.mediaBaseUrl,
+			eventBus,
+		});
 	}
 }
@@ -0,0 +1,19 @@
+create table if not exists outbox_events (
+    id bigserial primary key,
+    event_id text not null unique,
+    aggregate_type text not null,
+    aggregate_id bigint not null,
+    event_type text not null,
+    payload jsonb not null,
+    attempts int not null default 0,
+    last_error text,
+    next_attempt_at timestamptz not null default now(),
+    created_at timestamptz not null default now(),
+    delivered_at timestamptz
+);
+
+create index if not exists outbox_events_pending_idx on outbox_events (next_attempt_at, id)
+    where delivered_at is null;
+
+create index if not exists outbox_events_aggregate_idx on outbox_events (aggregate_type, aggregate_id, id)
+    where delivered_at is null;
@@ -0,0 +1,5 @@
+alter table users drop column if exists following_count;
+alter table users drop column if exists followers_count;
+
+drop index if exists friend_edges_next_node_idx;
+drop index if exists friend_nodes_userid_idx;
@@ -0,0 +1,46 @@
+-- one node per user so follow can lazily create it with on conflict, edges on
+-- a duplicate node are remapped onto the user's oldest node before the
+-- duplicates go so no follow relationship is lost
+create temporary table friend_node_remap as
+select n.id as old_id, k.keep_id
+from friend_nodes n
+join (select userId, min(id) as keep_id from friend_nodes group by userId) k
+    on k.userId = n.userId
+where n.id <> k.keep_id;
+
+-- insert the remapped copies rather than updating in place, two duplicates
+-- can map onto the same edge which would trip the primary key
+insert into friend_edges (previous_node, next_node)
+select distinct coalesce(p.keep_id, e.previous_node), coalesce(x.keep_id, e.next_node)
+from friend_edges e
+left join friend_node_remap p on p.old_id = e.previous_node
+left join friend_node_remap x on x.old_id = e.next_node
+where (p.old_id is not null or x.old_id is not null)
+    and coalesce(p.keep_id, e.previous_node) <> coalesce(x.keep_id, e.next_node)
+on conflict do nothing;
+
+delete from friend_edges
+where previous_node in (select old_id from friend_node_remap)
+    or next_node in (select old_id from friend_node_remap);
+
+delete from friend_nodes where id in (select old_id from friend_node_remap);
+
+drop table friend_node_remap;
+
+create unique index if not exists friend_nodes_userid_idx on friend_nodes (userId);
+create index if not exists friend_edges_next_node_idx on friend_edges (next_node);
+
+alter table users add column if not exists followers_count bigint not null default 0;
+alter table users add column if not exists following_count bigint not null default 0;
+
+update users u set
+    followers_count = (
+        select count(*) from friend_edges e
+        join friend_nodes n on n.id = e.next_node
+        where n.userId = u.id
+    ),
+    following_count = (
+        select count(*) from friend_edges e
+        join friend_nodes n on n.id = e.previous_node
+        where n.userId = u.id
+    );
@@ -0,0 +1,11 @@
+-- materialized home feeds, one row per post per follower. rows go away with
+-- the post or the user through the cascades
+create table if not exists timelines (
+    user_id bigint not null references users on delete cascade,
+    post_id bigint not null references posts on delete cascade,
+    created_at timestamptz not null,
+    primary key (user_id, post_id)
+);
+
+create index if not exists timelines_user_id_created_at_idx on timelines (user_id, created_at desc, post_id desc);
+create index if not exists timelines_post_id_idx on timelines (post_id);
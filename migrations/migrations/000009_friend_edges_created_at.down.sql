@@ -0,0 +1 @@
+alter table friend_edges drop column if exists created_at;
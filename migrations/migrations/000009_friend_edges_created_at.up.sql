@@ -0,0 +1,2 @@
+-- follow time, follower listings are paged newest first by it
+alter table friend_edges add column if not exists created_at timestamptz not null default now();
@@ -0,0 +1,2 @@
+-- back to storing just the parent id
+update comments set path = subpath(path, -1) where path <> '0' and nlevel(path) > 1;
@@ -0,0 +1,15 @@
+-- a comments path is now its full ancestry: '0' for a comment on the post,
+-- 'root' for a reply to root, 'root.child' for a reply to child and so on.
+-- replies used to store only their parent id, rebuild the chain from that
+with recursive tree as (
+    select id, id::text::ltree as child_path
+    from comments
+    where path = '0'
+    union all
+    select c.id, tree.child_path || c.id::text
+    from comments c
+    join tree on c.path = subpath(tree.child_path, -1)
+)
+update comments c set path = subpath(tree.child_path, 0, nlevel(tree.child_path) - 1)
+from tree
+where c.id = tree.id and c.path <> '0';
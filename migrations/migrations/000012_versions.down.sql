@@ -0,0 +1,2 @@
+alter table comments drop column if exists version;
+alter table posts drop column if exists version;
@@ -0,0 +1,2 @@
+alter table posts add column if not exists version integer not null default 1;
+alter table comments add column if not exists version integer not null default 1;
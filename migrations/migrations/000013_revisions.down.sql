@@ -0,0 +1,2 @@
+drop table if exists comment_revisions;
+drop table if exists post_revisions;
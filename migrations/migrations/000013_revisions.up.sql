@@ -0,0 +1,24 @@
+-- the body a post or comment had at version, written just before the edit
+-- that replaced it
+create table if not exists post_revisions (
+    id bigserial primary key,
+    post_id bigint not null references posts on delete cascade,
+    version integer not null,
+    body text not null,
+    edited_by bigint references users on delete set null,
+    created_at timestamptz not null default now(),
+    unique (post_id, version)
+);
+
+create table if not exists comment_revisions (
+    id bigserial primary key,
+    comment_id bigint not null references comments on delete cascade,
+    version integer not null,
+    body text not null,
+    edited_by bigint references users on delete set null,
+    created_at timestamptz not null default now(),
+    unique (comment_id, version)
+);
+
+create index if not exists post_revisions_post_id_created_at_idx on post_revisions (post_id, created_at desc, id desc);
+create index if not exists comment_revisions_comment_id_created_at_idx on comment_revisions (comment_id, created_at desc, id desc);
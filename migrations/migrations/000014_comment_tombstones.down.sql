@@ -0,0 +1,11 @@
+-- without tombstones a delete takes the whole thread below the comment
+delete from comments c
+using comments t
+where t.deleted_at is not null
+and (c.id = t.id or c.path <@ (case when t.path = '0' then t.id::text::ltree
+    else t.path || t.id::text end));
+
+drop index if exists comments_deleted_at_idx;
+
+alter table if exists comments
+    drop column if exists deleted_at;
@@ -0,0 +1,7 @@
+-- deleting a comment only stamps deleted_at, the row stays as a tombstone so
+-- its replies keep their place in the thread until the purge job removes it
+alter table if exists comments
+    add column if not exists deleted_at timestamptz;
+
+create index if not exists comments_deleted_at_idx on comments (deleted_at)
+    where deleted_at is not null;
@@ -0,0 +1,14 @@
+-- a row is written when an upload url is handed out and post_id is set once
+-- the upload is attached to a post, rows without a post are unused uploads
+create table if not exists post_media (
+    id bigserial primary key,
+    key text not null unique,
+    user_id bigint not null references users on delete cascade,
+    post_id bigint references posts on delete cascade,
+    content_type text not null,
+    size bigint not null,
+    position integer not null default 0,
+    created_at timestamptz not null default now()
+);
+
+create index if not exists post_media_post_id_idx on post_media (post_id, position);
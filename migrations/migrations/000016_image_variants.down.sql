@@ -0,0 +1,2 @@
+drop table if exists image_variants;
+drop table if exists images;
@@ -0,0 +1,21 @@
+-- written by the image processing lambda for every uploaded image, keyed by
+-- the uploads object key so post media and avatars can both look them up
+create table if not exists images (
+    source_key text primary key,
+    width integer not null default 0,
+    height integer not null default 0,
+    blurhash text not null default '',
+    -- set instead of the variants when the upload could not be processed
+    error text,
+    processed_at timestamptz not null default now()
+);
+
+create table if not exists image_variants (
+    source_key text not null references images on delete cascade,
+    name text not null,
+    key text not null,
+    content_type text not null,
+    width integer not null,
+    height integer not null,
+    primary key (source_key, name)
+);
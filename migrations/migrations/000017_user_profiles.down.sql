@@ -0,0 +1,3 @@
+drop index if exists users_username_lower_key;
+alter table users drop column if exists avatar_key;
+alter table users drop column if exists bio;
@@ -0,0 +1,41 @@
+alter table users add column if not exists bio text not null default '';
+-- the upload the profile picture was made from, null while it is still the
+-- one the oauth provider handed us
+alter table users add column if not exists avatar_key text;
+
+-- generated usernames were never unique, the later duplicates get a numeric
+-- suffix so the index below can be built. the name is cut first so the result
+-- stays within the 30 characters profile edits allow, and the suffix counts up
+-- until nobody else has the name
+do $$
+declare
+    duplicate record;
+    suffix text;
+    candidate text;
+    n int;
+begin
+    for duplicate in
+        select users.id, users.username from users
+        where exists (
+            select 1 from users as earlier
+            where lower(earlier.username) = lower(users.username)
+            and earlier.id < users.id
+        )
+        order by users.id
+    loop
+        n := 1;
+        loop
+            suffix := '-' || n;
+            candidate := left(duplicate.username, 30 - length(suffix)) || suffix;
+            exit when not exists (
+                select 1 from users where lower(username) = lower(candidate)
+            );
+            n := n + 1;
+        end loop;
+
+        update users set username = candidate where id = duplicate.id;
+    end loop;
+end
+$$;
+
+create unique index if not exists users_username_lower_key on users (lower(username));
@@ -0,0 +1,4 @@
+drop index if exists comments_search_idx;
+drop index if exists posts_search_idx;
+alter table comments drop column if exists search;
+alter table posts drop column if exists search;
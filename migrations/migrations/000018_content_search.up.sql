@@ -0,0 +1,8 @@
+-- kept up to date by postgres on every insert and update of the body
+alter table posts add column if not exists search tsvector
+    generated always as (to_tsvector('english', body)) stored;
+alter table comments add column if not exists search tsvector
+    generated always as (to_tsvector('english', body)) stored;
+
+create index if not exists posts_search_idx on posts using gin (search);
+create index if not exists comments_search_idx on comments using gin (search);
@@ -0,0 +1,20 @@
+-- the inbox, one row per event a user was notified about. the event id makes
+-- a redelivered event land on the row it already wrote, and the rows go away
+-- with the post or comment they point at
+create table if not exists notifications (
+    id bigserial primary key,
+    user_id bigint not null references users on delete cascade,
+    actor_id bigint not null references users on delete cascade,
+    event_id text not null,
+    type text not null,
+    post_id bigint not null references posts on delete cascade,
+    comment_id bigint references comments on delete cascade,
+    payload jsonb not null,
+    created_at timestamptz not null,
+    read_at timestamptz
+);
+
+create unique index if not exists notifications_event_id_user_id_idx on notifications (event_id, user_id);
+create index if not exists notifications_user_id_created_at_idx on notifications (user_id, created_at desc, id desc);
+create index if not exists notifications_unread_idx on notifications (user_id)
+    where read_at is null;
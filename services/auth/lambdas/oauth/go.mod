@@ -0,0 +1,29 @@
+module oauth
+
+go 1.21.5
+
+require (
+	events/common v0.0.0
+	events/session v0.0.0
+	github.com/go-chi/chi/v5 v5.0.11
+	github.com/gorilla/sessions v1.2.2
+	github.com/markbates/goth v1.78.0
+)
+
+require (
+	cloud.google.com/go v0.67.0 // indirect
+	github.com/aws/aws-lambda-go v1.43.0 // indirect
+	github.com/awslabs/aws-lambda-go-api-proxy v0.16.0 // indirect
+	github.com/golang/protobuf v1.5.0 // indirect
+	github.com/gorilla/mux v1.8.0 // indirect
+	github.com/gorilla/securecookie v1.1.2 // indirect
+	github.com/lib/pq v1.10.9 // indirect
+	golang.org/x/net v0.10.0 // indirect
+	golang.org/x/oauth2 v0.0.0-20200902213428-5d25da1a8d43 // indirect
+	google.golang.org/appengine v1.6.6 // indirect
+	google.golang.org/protobuf v1.30.0 // indirect
+)
+
+replace events/common => ../../../common
+
+replace events/session => ../../session
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"events/common/api"
+	"events/session"
+	"github.com/markbates/goth/gothic"
+)
+
+const (
+	CLIENT_REDIRECT_COOKIE = "__events_client_redirect"
+	MAX_USERNAME_ATTEMPTS  = 3
+	// the redirect only has to outlive the round trip to the provider
+	CLIENT_REDIRECT_MAX_AGE = 10 * 60
+)
+
+func (app *app) signInHandler(w http.ResponseWriter, r *http.Request) {
+	redirectUrl := r.URL.Query().Get("redirect")
+	if redirectUrl == "" {
+		noProvidedAuthRedirectUrl(w, r)
+		return
+	}
+
+	if !app.clientOrigins.Allows(redirectUrl) {
+		redirectUrlNotAllowed(w, r)
+		return
+	}
+
+	user := session.ContextGetUser(r)
+	if !user.IsAnonymous() {
+		w.Header().Set("Location", redirectUrl)
+		w.WriteHeader(http.StatusTemporaryRedirect)
+		return
+	}
+
+	session.SetSecureCookie(
+		w,
+		CLIENT_REDIRECT_COOKIE,
+		redirectUrl,
+		time.Now().Add(CLIENT_REDIRECT_MAX_AGE*time.Second),
+		CLIENT_REDIRECT_MAX_AGE,
+	)
+
+	gothic.BeginAuthHandler(w, r)
+}
+
+func (app *app) callbackHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := gothic.CompleteUserAuth(w, r)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	redirectCookie := session.FindCookie(r, CLIENT_REDIRECT_COOKIE)
+	if redirectCookie == nil {
+		noProvidedAuthRedirectUrl(w, r)
+		return
+	}
+
+	if !app.clientOrigins.Allows(redirectCookie.Value) {
+		redirectUrlNotAllowed(w, r)
+		return
+	}
+
+	userInDb, err := app.models.Users.GetByEmail(user.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUserNotFound):
+			userInDb = &User{
+				Email:          user.Email,
+				Name:           user.Name,
+				ProfilePicture: user.AvatarURL,
+				Username:       RandomUsername(),
+			}
+
+			err = app.models.Users.Insert(userInDb)
+			for attempt := 1; errors.Is(err, ErrDuplicateUsername) && attempt < MAX_USERNAME_ATTEMPTS; attempt++ {
+				userInDb.Username = RandomUsername()
+				err = app.models.Users.Insert(userInDb)
+			}
+
+			if err != nil {
+				api.ServerErrorResponse(w, r, err)
+				return
+			}
+		default:
+			api.ServerErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	_, err = app.models.Providers.GetByUser(userInDb.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrProviderNotFound):
+			providerUser := Provider{
+				UserId:            userInDb.Id,
+				Provider:          user.Provider,
+				AccessToken:       user.AccessToken,
+				AccessTokenSecret: user.AccessTokenSecret,
+				ExpiresAt:         user.ExpiresAt,
+				IdToken:           user.IDToken,
+				RefreshToken:      user.RefreshToken,
+			}
+
+			err = app.models.Providers.Insert(&providerUser)
+			if err != nil {
+				api.ServerErrorResponse(w, r, err)
+				return
+			}
+		default:
+			api.ServerErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	sessionToken, err := app.models.Sessions.GetByUserId(userInDb.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, session.ErrSessionNotFound):
+			sessionToken, err = app.models.Sessions.Insert(userInDb.Id)
+			if err != nil {
+				api.ServerErrorResponse(w, r, err)
+				return
+			}
+
+		default:
+			api.ServerErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	csrf := session.MakeCsrfToken(sessionToken)
+	expiry := time.Now().Add(session.SessionExpiry)
+
+	session.SetSecureCookie(w, session.SESSION_COOKIE, sessionToken, expiry, session.MaxAge)
+	session.SetCsrfCookie(w, csrf, expiry, session.MaxAge)
+	session.DeleteSecureCookie(w, CLIENT_REDIRECT_COOKIE)
+
+	w.Header().Set("Location", redirectCookie.Value)
+	w.WriteHeader(http.StatusTemporaryRedirect)
+}
+
+func (app *app) signOutHandler(w http.ResponseWriter, r *http.Request) {
+	err := gothic.Logout(w, r)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	sessionCookie := session.FindCookie(r, session.SESSION_COOKIE)
+	if sessionCookie != nil {
+		err := app.models.Sessions.DeleteByToken(sessionCookie.Value)
+		if err != nil {
+			api.ServerErrorResponse(w, r, err)
+			return
+		}
+
+		session.DeleteSecureCookie(w, session.SESSION_COOKIE)
+		session.DeleteSecureCookie(w, session.CSRF_COOKIE)
+	}
+
+	err = api.WriteJSON(w, http.StatusOK, api.Envelope{"status": "signed out"}, nil)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (app *app) sessionHandler(w http.ResponseWriter, r *http.Request) {
+	user := session.ContextGetUser(r)
+
+	err := api.WriteJSON(w, http.StatusOK, api.Envelope{"user": user}, nil)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+	}
+}
+
+func noProvidedAuthRedirectUrl(w http.ResponseWriter, r *http.Request) {
+	message := "no redirect param provided on auth initialization"
+	api.ErrorResponse(w, r, http.StatusBadRequest, message)
+}
+
+func redirectUrlNotAllowed(w http.ResponseWriter, r *http.Request) {
+	message := "redirect param is not an allowed client origin"
+	api.ErrorResponse(w, r, http.StatusBadRequest, message)
+}
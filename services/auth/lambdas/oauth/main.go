@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+
+	"events/common/api"
+	"events/common/data"
+	"events/session"
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/sessions"
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothic"
+	"github.com/markbates/goth/providers/google"
+)
+
+var router *chi.Mux
+
+type app struct {
+	models        Models
+	clientOrigins ClientOrigins
+}
+
+// gothic keeps the oauth state in a cookie between the sign in redirect and
+// the callback, both keys have to be stable across lambda instances
+func initAuth() {
+	store := sessions.NewCookieStore(
+		[]byte(os.Getenv("SESSION_SECRET")),
+		[]byte(os.Getenv("SESSION_ENCRYPTION_KEY")),
+	)
+	store.MaxAge(session.MaxAge)
+	store.Options.Path = "/"
+	store.Options.MaxAge = session.MaxAge
+	store.Options.HttpOnly = true
+	store.Options.Secure = os.Getenv("IS_PROD") == "true"
+
+	gothic.Store = store
+
+	goth.UseProviders(
+		google.New(
+			os.Getenv("PUBSUB_GOOGLE_CLIENT_ID"),
+			os.Getenv("PUBSUB_GOOGLE_CLIENT_SECRET"),
+			os.Getenv("AUTH_CALLBACK_URL"),
+		),
+	)
+}
+
+func init() {
+	db, err := data.OpenDB(os.Getenv("DB_ADDRESS"))
+	if err != nil {
+		panic(err)
+	}
+
+	initAuth()
+
+	app := app{
+		models:        NewModels(db),
+		clientOrigins: ParseClientOrigins(os.Getenv("CLIENT_ORIGINS")),
+	}
+	r := api.NewRouter()
+	r.Use(app.models.Sessions.Authenticate)
+	r.Route("/auth", func(r chi.Router) {
+		r.Get("/signin", app.signInHandler)
+		r.Get("/callback", app.callbackHandler)
+		r.Get("/signout", app.signOutHandler)
+		r.With(session.RequireAuthenticatedUser).Get("/session", app.sessionHandler)
+		r.Get("/healthcheck", api.HealthcheckHandler)
+	})
+
+	router = r
+}
+
+func main() {
+	api.Start(router)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+
+	"events/session"
+)
+
+var (
+	ErrDuplicateEmail    = errors.New("duplicate email")
+	ErrDuplicateUsername = errors.New("duplicate username")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrProviderNotFound  = errors.New("provider not found")
+)
+
+type Models struct {
+	Users     UserModel
+	Providers ProviderModel
+	Sessions  session.SessionModel
+}
+
+func NewModels(db *sql.DB) Models {
+	return Models{
+		Users:     UserModel{DB: db},
+		Providers: ProviderModel{DB: db},
+		Sessions:  session.SessionModel{DB: db},
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ClientOrigins is the allow-list of client origins (scheme://host[:port])
+// the auth flow may send a user back to once it completes
+type ClientOrigins map[string]bool
+
+func ParseClientOrigins(raw string) ClientOrigins {
+	origins := ClientOrigins{}
+	for _, origin := range strings.Split(raw, ",") {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin != "" {
+			origins[strings.ToLower(origin)] = true
+		}
+	}
+
+	return origins
+}
+
+// Allows reports whether redirectUrl is an absolute http(s) url on one of
+// the allow-listed origins, anything else would make signin an open redirect
+func (co ClientOrigins) Allows(redirectUrl string) bool {
+	u, err := url.Parse(redirectUrl)
+	if err != nil || u.User != nil || u.Host == "" {
+		return false
+	}
+
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return false
+	}
+
+	return co[strings.ToLower(u.Scheme+"://"+u.Host)]
+}
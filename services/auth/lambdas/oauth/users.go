@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+type UserModel struct {
+	DB *sql.DB
+}
+
+type User struct {
+	Id             int64  `json:"id"`
+	Email          string `json:"email"`
+	Name           string `json:"name"`
+	ProfilePicture string `json:"profile_picture"`
+	Username       string `json:"username"`
+}
+
+type sqlUser struct {
+	Id             sql.NullInt64
+	Email          sql.NullString
+	Name           sql.NullString
+	ProfilePicture sql.NullString
+	Username       sql.NullString
+}
+
+func (um *UserModel) Insert(user *User) error {
+	query := `
+	INSERT INTO users (email, name, profile_picture, username)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	args := []any{user.Email, user.Name, user.ProfilePicture, user.Username}
+	err := um.DB.QueryRowContext(ctx, query, args...).Scan(&user.Id)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"`:
+			return ErrDuplicateEmail
+		case err.Error() == `pq: duplicate key value violates unique constraint "users_username_lower_key"`:
+			return ErrDuplicateUsername
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (um *UserModel) GetByEmail(email string) (*User, error) {
+	query := `
+	SELECT id, email, name, profile_picture, username
+	FROM users
+	WHERE email = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var tempUser sqlUser
+
+	err := um.DB.QueryRowContext(ctx, query, email).
+		Scan(
+			&tempUser.Id,
+			&tempUser.Email,
+			&tempUser.Name,
+			&tempUser.ProfilePicture,
+			&tempUser.Username,
+		)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrUserNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return parseValidUser(&tempUser), nil
+}
+
+func parseValidUser(user *sqlUser) *User {
+	return &User{
+		Id:             user.Id.Int64,
+		Email:          user.Email.String,
+		Name:           user.Name.String,
+		ProfilePicture: user.ProfilePicture.String,
+		Username:       user.Username.String,
+	}
+}
+
+// a generated username for a new account, the number keeps collisions rare
+// and Insert reports the ones that still happen
+func RandomUsername() string {
+	return fmt.Sprintf("%s-user-%d", RandomUserAdjectiveThing(), rand.Intn(1_000_000))
+}
+
+func RandomUserAdjectiveThing() string {
+	possibleOnes := []string{
+		"beloved",
+		"adored",
+		"cherished",
+		"treasured",
+		"prized",
+		"favorite",
+		"precious",
+		"favorite",
+		"coolest",
+		"best",
+	}
+	return possibleOnes[rand.Intn(len(possibleOnes))]
+}
@@ -0,0 +1,100 @@
+import { CfnOutput, Tags } from 'aws-cdk-lib';
+import { Construct } from "constructs";
+import { RestApi, LambdaIntegration } from "aws-cdk-lib/aws-apigateway";
+import { Bucket } from 'aws-cdk-lib/aws-s3';
+import { createLambda } from '../../../lib/lambda';
+import * as path from "path"
+
+
+enum BaseUrlPaths {
+	AUTH = "auth",
+	SIGN_IN = "signin",
+	CALLBACK = "callback",
+	SIGN_OUT = "signout",
+	SESSION = "session",
+	HEALTH = "healthcheck",
+}
+
+interface AuthProps {
+	db_url?: string
+	session_secret?: string
+	session_encryption_key?: string
+	google_client_id?: string
+	google_client_secret?: string
+	callback_url?: string
+	client_origins?: string
+	isProd: boolean
+}
+
+export class Auth extends Construct {
+	constructor(scope: Construct, id: string, props: AuthProps) {
+		super(scope, id);
+
+		if (!props.db_url) {
+			throw new Error("DB env var is not set")
+		}
+
+		if (!props.session_secret || !props.session_encryption_key) {
+			throw new Error("SESSION_SECRET or SESSION_ENCRYPTION_KEY env var is not set")
+		}
+
+		if (!props.google_client_id || !props.google_client_secret || !props.callback_url) {
+			throw new Error("google oauth env vars are not set")
+		}
+
+		if (!props.client_origins) {
+			throw new Error("CLIENT_ORIGINS env var is not set")
+		}
+
+		const hotReloadBucket = Bucket.fromBucketName(
+			this,
+			"HotReloadingBucket",
+			"hot-reload"
+		)
+
+		const oauth = createLambda(
+			this,
+			"oauth",
+			path.join(__dirname, "../lambdas/oauth"),
+			hotReloadBucket,
+			{
+				DB_ADDRESS: props.db_url,
+				SESSION_SECRET: props.session_secret,
+				SESSION_ENCRYPTION_KEY: props.session_encryption_key,
+				PUBSUB_GOOGLE_CLIENT_ID: props.google_client_id,
+				PUBSUB_GOOGLE_CLIENT_SECRET: props.google_client_secret,
+				AUTH_CALLBACK_URL: props.callback_url,
+				CLIENT_ORIGINS: props.client_origins,
+				IS_PROD: props.isProd ? "true" : "false",
+			},
+		)
+
+		const api = new RestApi(this, "authapi", {
+			restApiName: "authapi",
+			description: "API for auth",
+		})
+		Tags.of(api).add("_custom_id_", "authapi")
+
+		const integration = new LambdaIntegration(oauth)
+		const auth = api.root.addResource(BaseUrlPaths.AUTH)
+
+		const signIn = auth.addResource(BaseUrlPaths.SIGN_IN)
+		signIn.addMethod("GET", integration)
+
+		const callback = auth.addResource(BaseUrlPaths.CALLBACK)
+		callback.addMethod("GET", integration)
+
+		const signOut = auth.addResource(BaseUrlPaths.SIGN_OUT)
+		signOut.addMethod("GET", integration)
+
+		const session = auth.addResource(BaseUrlPaths.SESSION)
+		session.addMethod("GET", integration)
+
+		const health = auth.addResource(BaseUrlPaths.HEALTH)
+		health.addMethod("GET", integration)
+
+		new CfnOutput(this, "GatewayId", { value: api.restApiId })
+		new CfnOutput(this, "GatewayUrl", { value: api.url })
+		new CfnOutput(this, "GatewayEndPoints", { value: "\n" + api.methods.join("\n") })
+	}
+}
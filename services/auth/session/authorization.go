@@ -0,0 +1,22 @@
+package session
+
+const (
+	ROLE_USER      = "user"
+	ROLE_MODERATOR = "moderator"
+	ROLE_ADMIN     = "admin"
+)
+
+// moderators and admins may edit or remove anything, everyone else only what
+// they created themselves
+func (u *User) CanModify(ownerId int64) bool {
+	if u.IsAnonymous() {
+		return false
+	}
+
+	switch u.Role {
+	case ROLE_ADMIN, ROLE_MODERATOR:
+		return true
+	default:
+		return u.Id == ownerId
+	}
+}
@@ -0,0 +1,24 @@
+package session
+
+import (
+	"context"
+	"net/http"
+)
+
+type contextKey string
+
+const userContextKey = contextKey("user")
+
+func ContextSetUser(r *http.Request, user *User) *http.Request {
+	ctx := context.WithValue(r.Context(), userContextKey, user)
+	return r.WithContext(ctx)
+}
+
+func ContextGetUser(r *http.Request) *User {
+	user, ok := r.Context().Value(userContextKey).(*User)
+	if !ok {
+		panic("missing user value in req context!!")
+	}
+
+	return user
+}
@@ -0,0 +1,65 @@
+package session
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	CSRF_COOKIE    = "__Secure-events_csrf_token"
+	SESSION_COOKIE = "__Secure-events_session_token"
+	CSRF_HEADER    = "X-CSRF-Token"
+	MaxAge         = 60 * 60 * 24 * 30
+)
+
+func SetSecureCookie(
+	w http.ResponseWriter, name, value string, expiry time.Time, maxAge int) {
+
+	cookie := http.Cookie{
+		Name:       name,
+		Value:      value,
+		Path:       "/",
+		Expires:    expiry,
+		RawExpires: expiry.Format(time.UnixDate),
+		MaxAge:     maxAge,
+		Secure:     true,
+		HttpOnly:   true,
+		SameSite:   http.SameSiteNoneMode,
+		Raw:        fmt.Sprintf("%s=%s", name, value),
+		Unparsed:   []string{fmt.Sprintf("%s=%s", name, value)},
+	}
+
+	http.SetCookie(w, &cookie)
+}
+
+// the csrf cookie has to be readable by the client so it can be sent back
+// in the CSRF_HEADER, everything else stays http only
+func SetCsrfCookie(w http.ResponseWriter, value string, expiry time.Time, maxAge int) {
+	cookie := http.Cookie{
+		Name:     CSRF_COOKIE,
+		Value:    value,
+		Path:     "/",
+		Expires:  expiry,
+		MaxAge:   maxAge,
+		Secure:   true,
+		HttpOnly: false,
+		SameSite: http.SameSiteNoneMode,
+	}
+
+	http.SetCookie(w, &cookie)
+}
+
+func DeleteSecureCookie(w http.ResponseWriter, name string) {
+	SetSecureCookie(w, name, "", time.Now(), -1)
+}
+
+func FindCookie(r *http.Request, cookieName string) *http.Cookie {
+	for _, cookie := range r.Cookies() {
+		if cookie.Name == cookieName {
+			return cookie
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+module events/session
+
+go 1.21.5
+
+require events/common v0.0.0
+
+require (
+	github.com/aws/aws-lambda-go v1.43.0 // indirect
+	github.com/awslabs/aws-lambda-go-api-proxy v0.16.0 // indirect
+	github.com/go-chi/chi/v5 v5.0.11 // indirect
+)
+
+replace events/common => ../../common
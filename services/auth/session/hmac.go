@@ -0,0 +1,34 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"os"
+)
+
+func makeMac(token string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(token))
+	return fmt.Sprintf("%x", mac.Sum(nil))
+}
+
+func CheckMac(token, mac string) bool {
+	key := []byte(os.Getenv("SESSION_SECRET"))
+	return hmac.Equal([]byte(mac), []byte(makeMac(token, key)))
+}
+
+func MakeToken(tokenToHash string) string {
+	secret := os.Getenv("SESSION_SECRET")
+	return makeMac(tokenToHash, []byte(secret))
+}
+
+// csrf token handed out next to a session token, the client has to echo it
+// back on any request that changes state
+func MakeCsrfToken(sessionToken string) string {
+	return MakeToken(fmt.Sprintf("%s%s", sessionToken, os.Getenv("SESSION_SECRET")))
+}
+
+func CheckCsrfToken(sessionToken, csrf string) bool {
+	return CheckMac(fmt.Sprintf("%s%s", sessionToken, os.Getenv("SESSION_SECRET")), csrf)
+}
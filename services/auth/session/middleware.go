@@ -0,0 +1,88 @@
+package session
+
+import (
+	"net/http"
+	"strings"
+
+	"events/common/api"
+)
+
+// resolves the caller from either an "Authorization: Bearer <token>" header
+// or the session cookie set by the auth service. Requests without any
+// credentials continue as AnonymousUser, handlers that need a signed in user
+// should be wrapped with RequireAuthenticatedUser
+func (sm *SessionModel) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Authorization")
+		w.Header().Add("Vary", "Cookie")
+
+		token, fromCookie := tokenFromRequest(r)
+		if token == "" {
+			r = ContextSetUser(r, AnonymousUser)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// cookies are sent by the browser automatically so state changing
+		// requests have to prove they can read the csrf cookie
+		if fromCookie && !isSafeMethod(r.Method) {
+			csrf := r.Header.Get(CSRF_HEADER)
+			if csrf == "" || !CheckCsrfToken(token, csrf) {
+				api.InvalidCredentialsResponse(w, r)
+				return
+			}
+		}
+
+		user, err := sm.GetUserForToken(token)
+		if err != nil {
+			switch err {
+			case ErrSessionNotFound:
+				api.InvalidAuthenticationTokenResponse(w, r)
+			default:
+				api.ServerErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		r = ContextSetUser(r, user)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func RequireAuthenticatedUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := ContextGetUser(r)
+		if user.IsAnonymous() {
+			api.AuthenticationRequiredResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func tokenFromRequest(r *http.Request) (string, bool) {
+	authorization := r.Header.Get("Authorization")
+	if authorization != "" {
+		headerParts := strings.Split(authorization, " ")
+		if len(headerParts) == 2 && headerParts[0] == "Bearer" {
+			return headerParts[1], false
+		}
+	}
+
+	sessionCookie := FindCookie(r, SESSION_COOKIE)
+	if sessionCookie != nil {
+		return sessionCookie.Value, true
+	}
+
+	return "", false
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,166 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	AnonymousUser = &User{}
+	SessionExpiry = 30 * 24 * time.Hour
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// fetch session via session token, token should be 128 bits long
+type Session struct {
+	Id        int64     `json:"id"`
+	Token     string    `json:"token"`
+	UserId    int64     `json:"user_id"`
+	ExpiresAt time.Time `json:"expires"`
+}
+
+// the signed in user that handlers read from the request context
+type User struct {
+	Id             int64  `json:"id"`
+	Username       string `json:"username"`
+	ProfilePicture string `json:"profile_picture"`
+	Role           string `json:"role"`
+}
+
+func (u *User) IsAnonymous() bool {
+	return u == AnonymousUser
+}
+
+type SessionModel struct {
+	DB *sql.DB
+}
+
+func (sm *SessionModel) Insert(userId int64) (string, error) {
+	query := `
+	INSERT INTO sessions (token, user_id, expires_at)
+	VALUES ($1, $2, $3)
+	RETURNING token
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	token, err := GenerateToken(128)
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(SessionExpiry)
+	args := []any{token, userId, expiresAt}
+
+	var sesh Session
+
+	err = sm.DB.QueryRowContext(ctx, query, args...).Scan(&sesh.Token)
+	if err != nil {
+		return "", err
+	}
+
+	return sesh.Token, nil
+}
+
+func (sm *SessionModel) GetByUserId(userId int64) (string, error) {
+	query := `
+	SELECT id, token, user_id, expires_at
+	FROM sessions
+	WHERE user_id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var s Session
+	err := sm.DB.QueryRowContext(ctx, query, userId).
+		Scan(&s.Id, &s.Token, &s.UserId, &s.ExpiresAt)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return "", ErrSessionNotFound
+		default:
+			return "", err
+		}
+	}
+
+	if s.ExpiresAt.Before(time.Now()) {
+		err = sm.Delete(userId)
+		if err != nil {
+			return "", err
+		}
+
+		return "", ErrSessionNotFound
+	}
+
+	return s.Token, nil
+}
+
+func (sm *SessionModel) Delete(userId int64) error {
+	query := `
+	DELETE FROM sessions
+	WHERE user_id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := sm.DB.ExecContext(ctx, query, userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sm *SessionModel) DeleteByToken(token string) error {
+	query := `
+	DELETE FROM sessions
+	WHERE token = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := sm.DB.ExecContext(ctx, query, token)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// looks up the user a non expired session token belongs to
+func (sm *SessionModel) GetUserForToken(token string) (*User, error) {
+	query := `
+	SELECT users.id, users.username, users.profile_picture, users.role
+	FROM sessions
+	JOIN users ON users.id = sessions.user_id
+	WHERE sessions.token = $1 AND sessions.expires_at > now()
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var user User
+	err := sm.DB.QueryRowContext(ctx, query, token).
+		Scan(&user.Id, &user.Username, &user.ProfilePicture, &user.Role)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrSessionNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,94 @@
+package session
+
+import (
+	"os"
+	"testing"
+)
+
+func TestTokenCreation(t *testing.T) {
+	token, err := GenerateToken(128)
+	if err != nil {
+		t.Errorf("error generating token: %s", err)
+	}
+
+	if len(token) != 256 {
+		t.Errorf("token length is not 256, got %d", len(token))
+	}
+
+	token, err = GenerateToken(64)
+	if err != nil {
+		t.Errorf("error generating token: %s", err)
+	}
+
+	if len(token) != 128 {
+		t.Errorf("token length is not 128, got %d", len(token))
+	}
+
+	token, err = GenerateToken(32)
+	if err != nil {
+		t.Errorf("error generating token: %s", err)
+	}
+
+	if len(token) != 64 {
+		t.Errorf("token length is not 64, got %d", len(token))
+	}
+}
+
+func TestHmacComparison(t *testing.T) {
+	token1, err := GenerateToken(128)
+	if err != nil {
+		t.Errorf("error generating token: %s", err)
+	}
+
+	secret := os.Getenv("SESSION_SECRET")
+
+	mac1 := makeMac(token1, []byte(secret))
+	valid := CheckMac(token1, mac1)
+	if !valid {
+		t.Errorf("mac1 is not valid")
+	}
+}
+
+func TestCsrfToken(t *testing.T) {
+	t.Setenv("SESSION_SECRET", "super-secret")
+
+	sessionToken, err := GenerateToken(128)
+	if err != nil {
+		t.Errorf("error generating token: %s", err)
+	}
+
+	csrf := MakeCsrfToken(sessionToken)
+	if !CheckCsrfToken(sessionToken, csrf) {
+		t.Errorf("csrf token is not valid for its own session")
+	}
+
+	otherToken, err := GenerateToken(128)
+	if err != nil {
+		t.Errorf("error generating token: %s", err)
+	}
+
+	if CheckCsrfToken(otherToken, csrf) {
+		t.Errorf("csrf token should not be valid for another session")
+	}
+}
+
+func TestCanModify(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    *User
+		ownerId int64
+		want    bool
+	}{
+		{"owner", &User{Id: 1, Role: ROLE_USER}, 1, true},
+		{"someone else", &User{Id: 2, Role: ROLE_USER}, 1, false},
+		{"moderator", &User{Id: 2, Role: ROLE_MODERATOR}, 1, true},
+		{"admin", &User{Id: 2, Role: ROLE_ADMIN}, 1, true},
+		{"anonymous", AnonymousUser, 0, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.user.CanModify(tt.ownerId); got != tt.want {
+			t.Errorf("%s: CanModify(%d) = %v, want %v", tt.name, tt.ownerId, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"events/common/data"
+)
+
+// hard deletes comment tombstones older than the retention window once
+// nothing below them is live, whole branches go together so a reply is never
+// left behind without its parent
+func main() {
+	var dsn string
+	var retention time.Duration
+	var dryRun bool
+	flag.StringVar(&dsn, "db-dsn", os.Getenv("DB_ADDRESS"), "postgres dsn")
+	flag.DurationVar(&retention, "retention", 30*24*time.Hour, "how long a deleted comment is kept")
+	flag.BoolVar(&dryRun, "dry-run", false, "report purgeable comments without deleting them")
+	flag.Parse()
+
+	db, err := data.OpenDB(dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to db: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	purged, err := purge(db, time.Now().Add(-retention), dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to purge comments: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("purged comments: %d\n", purged)
+}
+
+// a tombstone is purgeable when it and everything below it was deleted
+// before the cutoff, a live reply or a recent delete keeps the branch
+func purge(db *sql.DB, cutoff time.Time, dryRun bool) (int64, error) {
+	purgeable := `
+		with purgeable as (
+			select t.id from comments t
+			where t.deleted_at < $1
+			and not exists (
+				select 1 from comments d
+				where d.path <@ (case when t.path = '0' then t.id::text::ltree
+					else t.path || t.id::text end)
+				and (d.deleted_at is null or d.deleted_at >= $1)
+			)
+		)
+	`
+
+	var query string
+	if dryRun {
+		query = purgeable + `select count(*) from purgeable`
+	} else {
+		query = purgeable + `
+			, deleted as (
+				delete from comments c
+				using purgeable p
+				where c.id = p.id
+				returning c.id
+			)
+			select count(*) from deleted
+		`
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	var purged int64
+	err := db.QueryRowContext(ctx, query, cutoff).Scan(&purged)
+	return purged, err
+}
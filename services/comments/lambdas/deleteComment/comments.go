@@ -5,37 +5,78 @@ import (
 	"database/sql"
 	"errors"
 	"time"
-)
 
-var (
-	ErrRecordNotFound = errors.New("record not found")
+	"events/common/data"
+	"events/common/domain"
+	"events/common/outbox"
 )
 
 type CommentModel struct {
 	DB *sql.DB
 }
 
-func (c *CommentModel) delete(id int64) error {
+// deletedBy is the user who asked for the delete, the owner or a moderator
+func (c *CommentModel) delete(id, deletedBy int64) error {
+	// the row stays behind as a tombstone so replies keep their place in the
+	// thread, the purge job removes it once nothing below it is live
 	query := `
-		delete from comments where id = $1
+		update comments set deleted_at = now()
+		from posts
+		where comments.id = $1 and comments.deleted_at is null
+		and posts.id = comments.post_id
+		returning comments.post_id, comments.user_id, posts.user_id, comments.deleted_at
 	`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	result, err := c.DB.ExecContext(ctx, query, id)
+	tx, err := c.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	event := domain.CommentDeleted{CommentId: id, DeletedByUserId: deletedBy}
+	err = tx.QueryRowContext(ctx, query, id).Scan(
+		&event.PostId,
+		&event.CommentUserId,
+		&event.PostUserId,
+		&event.DeletedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return data.ErrRecordNotFound
+		default:
+			return err
+		}
+	}
 
-	rows, err := result.RowsAffected()
+	err = outbox.Enqueue(ctx, tx, outbox.AGGREGATE_POST, event.PostId, event, event.DeletedAt)
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return ErrRecordNotFound
+	return tx.Commit()
+}
+
+// user id of whoever created the comment
+func (c *CommentModel) owner(id int64) (int64, error) {
+	query := `select user_id from comments where id = $1 and deleted_at is null`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var userId int64
+	err := c.DB.QueryRowContext(ctx, query, id).Scan(&userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, data.ErrRecordNotFound
+		default:
+			return 0, err
+		}
 	}
 
-	return nil
+	return userId, nil
 }
@@ -5,41 +5,49 @@ import (
 	"net/http"
 	"strconv"
 
+	"events/common/api"
+	"events/common/data"
+	"events/session"
 	"github.com/go-chi/chi/v5"
 )
 
 func (app *app) handleDelete(w http.ResponseWriter, r *http.Request) {
 	commentId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil || commentId < 1 {
-		app.notFoundHandler(w, r)
+		api.NotFoundResponse(w, r)
 		return
 	}
 
-	err = app.models.Comments.delete(commentId)
+	ownerId, err := app.models.Comments.owner(commentId)
 	if err != nil {
 		switch {
-		case errors.Is(err, ErrRecordNotFound):
-			app.notFoundHandler(w, r)
+		case errors.Is(err, data.ErrRecordNotFound):
+			api.NotFoundResponse(w, r)
 		default:
-			app.serverErrorResponse(w, r, err)
+			api.ServerErrorResponse(w, r, err)
 		}
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"message": "comment successfully deleted"}, nil)
-	if err != nil {
-		app.serverErrorResponse(w, r, err)
+	user := session.ContextGetUser(r)
+	if !user.CanModify(ownerId) {
+		api.NotPermittedResponse(w, r)
+		return
 	}
-}
 
-func (app *app) healthcheckHandler(w http.ResponseWriter, r *http.Request) {
-	err := app.writeJSON(w, http.StatusOK, envelope{"status": "available"}, nil)
+	err = app.models.Comments.delete(commentId, user.Id)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			api.NotFoundResponse(w, r)
+		default:
+			api.ServerErrorResponse(w, r, err)
+		}
 		return
 	}
-}
 
-func (app *app) notFoundHandler(w http.ResponseWriter, r *http.Request) {
-	app.errorResponse(w, r, http.StatusNotFound, "resource not found")
+	err = api.WriteJSON(w, http.StatusOK, api.Envelope{"message": "comment successfully deleted"}, nil)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+	}
 }
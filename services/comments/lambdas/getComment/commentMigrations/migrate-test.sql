@@ -45,3 +45,32 @@ create table if not exists friend_edges (
     next_node bigint references friend_nodes(id),
     primary key (previous_node, next_node)
 );
+
+alter table if exists posts
+    add column if not exists version integer not null default 1;
+alter table if exists comments
+    add column if not exists version integer not null default 1;
+
+create table if not exists post_revisions (
+    id bigserial primary key,
+    post_id bigint not null references posts on delete cascade,
+    version integer not null,
+    body text not null,
+    edited_by bigint references users on delete set null,
+    created_at timestamptz not null default now(),
+    unique (post_id, version)
+);
+
+create table if not exists comment_revisions (
+    id bigserial primary key,
+    comment_id bigint not null references comments on delete cascade,
+    version integer not null,
+    body text not null,
+    edited_by bigint references users on delete set null,
+    created_at timestamptz not null default now(),
+    unique (comment_id, version)
+);
+
+
+alter table if exists comments
+    add column if not exists deleted_at timestamptz;
@@ -3,8 +3,8 @@ module getComment
 go 1.21.5
 
 require (
-	github.com/aws/aws-lambda-go v1.43.0
-	github.com/awslabs/aws-lambda-go-api-proxy v0.16.0
+	events/common v0.0.0
+	events/session v0.0.0
 	github.com/go-chi/chi/v5 v5.0.11
 	github.com/lib/pq v1.10.9
 	github.com/onsi/ginkgo/v2 v2.9.5
@@ -18,6 +18,8 @@ require (
 	github.com/Azure/go-ansiterm v0.0.0-20210617225240-d185dfc1b5a1 // indirect
 	github.com/Microsoft/go-winio v0.6.1 // indirect
 	github.com/Microsoft/hcsshim v0.11.4 // indirect
+	github.com/aws/aws-lambda-go v1.43.0 // indirect
+	github.com/awslabs/aws-lambda-go-api-proxy v0.16.0 // indirect
 	github.com/cenkalti/backoff/v4 v4.2.1 // indirect
 	github.com/containerd/containerd v1.7.11 // indirect
 	github.com/containerd/log v0.1.0 // indirect
@@ -63,3 +65,7 @@ require (
 	google.golang.org/protobuf v1.31.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace events/common => ../../../common
+
+replace events/session => ../../../auth/session
@@ -5,6 +5,9 @@ import (
 	"net/http"
 	"strconv"
 
+	"events/common/api"
+	"events/common/cursor"
+	"events/common/data"
 	"getComment/models"
 	"github.com/go-chi/chi/v5"
 )
@@ -12,47 +15,78 @@ import (
 func (app *app) getCommentHandler(w http.ResponseWriter, r *http.Request) {
 	commentId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid comment id parameter"))
+		api.BadRequestResponse(w, r, errors.New("invalid comment id parameter"))
 		return
 	}
-	qs := r.URL.Query()
-	take, err := app.readInt(qs, "take", 10)
+	filters, err := cursor.ReadFilters(r.URL.Query())
 	if err != nil {
-		app.badRequestResponse(w, r, err)
+		api.BadRequestResponse(w, r, err)
 		return
 	}
 
-	offset, err := app.readInt(qs, "offset", 0)
-	if err != nil {
-		app.badRequestResponse(w, r, err)
+	depth, err := api.ReadInt(r.URL.Query(), "depth", models.DEFAULT_DEPTH)
+	if err != nil || depth < 1 || depth > models.MAX_DEPTH {
+		api.BadRequestResponse(w, r, models.ErrInvalidDepth)
 		return
 	}
 
-	comment, err := app.models.Comments.GetComment(commentId, take, offset)
+	comment, metadata, err := app.models.Comments.GetComment(commentId, depth, filters)
 	if err != nil {
 		switch {
-		case errors.Is(err, models.ErrRecordNotFound):
-			app.notFoundHandler(w, r)
+		case errors.Is(err, data.ErrRecordNotFound):
+			api.NotFoundResponse(w, r)
 		default:
-			app.serverErrorResponse(w, r, err)
+			api.ServerErrorResponse(w, r, err)
 		}
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"comment": comment}, nil)
+	// the replies change without the comment's version moving, so the etag
+	// has to cover the whole thread
+	env := api.Envelope{"comment": comment, "metadata": metadata}
+	etag, err := api.ContentETag(env)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	if api.NotModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	err = api.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
 	}
 }
 
-func (app *app) healthcheckHandler(w http.ResponseWriter, r *http.Request) {
-	err := app.writeJSON(w, http.StatusOK, envelope{"status": "available"}, nil)
+func (app *app) listRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	commentId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.BadRequestResponse(w, r, errors.New("invalid comment id parameter"))
+		return
+	}
+	filters, err := cursor.ReadFilters(r.URL.Query())
+	if err != nil {
+		api.BadRequestResponse(w, r, err)
 		return
 	}
-}
 
-func (app *app) notFoundHandler(w http.ResponseWriter, r *http.Request) {
-	app.errorResponse(w, r, http.StatusNotFound, "resource not found")
+	revisions, metadata, err := app.models.Revisions.List(commentId, filters)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			api.NotFoundResponse(w, r)
+		default:
+			api.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = api.WriteJSON(w, http.StatusOK, api.Envelope{"revisions": revisions, "metadata": metadata}, nil)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+	}
 }
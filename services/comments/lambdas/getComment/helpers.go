@@ -1,64 +0,0 @@
-package main
-
-import (
-	"encoding/json"
-	"errors"
-	"net/http"
-	"net/url"
-	"strconv"
-)
-
-func (app *app) errorResponse(w http.ResponseWriter, r *http.Request, status int, message any) {
-	env := envelope{"error": message}
-	err := app.writeJSON(w, status, env, nil)
-	if err != nil {
-		w.WriteHeader(500)
-	}
-}
-
-func (app *app) serverErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
-	message := "the server encountared a problem and could not process this request :("
-	app.errorResponse(w, r, http.StatusInternalServerError, message)
-}
-
-func (app *app) badRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
-	app.errorResponse(w, r, http.StatusBadRequest, err.Error())
-}
-
-func (app *app) readInt(qs url.Values, key string, defaultValue int) (int, error) {
-	s := qs.Get(key)
-	if s == "" {
-		return defaultValue, nil
-	}
-
-	i, err := strconv.Atoi(s)
-	if err != nil {
-		return defaultValue, errors.New("key must be a valid int")
-	}
-	return i, nil
-}
-
-type envelope map[string]any
-
-func (app *app) writeJSON(
-	w http.ResponseWriter,
-	status int,
-	data envelope,
-	headers http.Header,
-) error {
-	js, err := json.MarshalIndent(data, "", "\t")
-	if err != nil {
-		return err
-	}
-
-	js = append(js, '\n')
-
-	for key, value := range headers {
-		w.Header()[key] = value
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	w.Write(js)
-	return nil
-}
@@ -1,18 +1,16 @@
 package main
 
 import (
-	"context"
 	"os"
 
+	"events/common/api"
+	"events/common/data"
+	"events/session"
 	"getComment/models"
-	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/awslabs/aws-lambda-go-api-proxy/chi"
 	"github.com/go-chi/chi/v5"
-	_ "github.com/lib/pq"
 )
 
-var chiLambda *chiadapter.ChiLambda
+var router *chi.Mux
 
 type app struct {
 	models models.Models
@@ -20,29 +18,24 @@ type app struct {
 
 func init() {
 	addr := os.Getenv("DB_ADDRESS")
-	db, err := models.OpenDB(addr)
+	db, err := data.OpenDB(addr)
 	if err != nil {
 		panic(err)
 	}
 
 	app := app{models: models.NewModels(db)}
-	r := chi.NewRouter()
+	r := api.NewRouter()
+	sessionModel := session.SessionModel{DB: db}
+	r.Use(sessionModel.Authenticate)
 	r.Route("/comments", func(r chi.Router) {
-		r.Get("/healthcheck", app.healthcheckHandler)
+		r.Get("/healthcheck", api.HealthcheckHandler)
 		r.Get("/{id}", app.getCommentHandler)
+		r.Get("/{id}/revisions", app.listRevisionsHandler)
 	})
-	r.NotFound(app.notFoundHandler)
 
-	chiLambda = chiadapter.New(r)
-}
-
-func Handler(
-	ctx context.Context,
-	event events.APIGatewayProxyRequest,
-) (events.APIGatewayProxyResponse, error) {
-	return chiLambda.ProxyWithContext(ctx, event)
+	router = r
 }
 
 func main() {
-	lambda.StartWithOptions(Handler, lambda.WithContext(context.Background()))
+	api.Start(router)
 }
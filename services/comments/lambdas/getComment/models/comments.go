@@ -4,24 +4,27 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"strconv"
+	"fmt"
+	"strings"
 	"time"
+
+	"events/common/cursor"
+	"events/common/data"
+	"github.com/lib/pq"
 )
 
-var (
-	ErrRecordNotFound = errors.New("record not found")
+const (
+	DEFAULT_DEPTH = 1
+	MAX_DEPTH     = 5
+	DELETED_BODY  = "[deleted]"
 )
 
+var ErrInvalidDepth = fmt.Errorf("depth must be between 1 and %d", MAX_DEPTH)
+
 type CommentModel struct {
 	DB *sql.DB
 }
 
-type User struct {
-	Id             int64  `json:"id"`
-	ProfilePicture string `json:"profile_picture"`
-	Username       string `json:"username"`
-}
-
 type Comment struct {
 	Id               int64     `json:"id"`
 	PostId           int64     `json:"post_id"`
@@ -31,55 +34,128 @@ type Comment struct {
 	UpdatedAt        time.Time `json:"updated_at"`
 	NumOfSubComments int       `json:"num_of_sub_comments"`
 	ParentId         int64     `json:"parent_id"`
-	User             User      `json:"user"`
+	Version          int32     `json:"version"`
+	User             data.User `json:"user"`
+	Deleted          bool      `json:"deleted"`
+	data.EditInfo
+	childPath string
 }
 
-func (c *CommentModel) GetComment(commentId int64, take, offset int) (Comment, error) {
-	query := `
+// a comments path is its ancestry, its replies hang off the path with the
+// comments own id appended. top level comments have path '0' which is not
+// carried into the replies
+func childPathOf(table string) string {
+	return fmt.Sprintf(`CASE WHEN %[1]s.path = '0' THEN %[1]s.id::text::ltree
+	ELSE %[1]s.path || %[1]s.id::text END`, table)
+}
+
+var childPathSQL = childPathOf("comments")
+
+// a deleted comment stays in the thread as a tombstone while anything below it
+// is still live, once its whole branch is deleted it is left out entirely
+func visibleSQL(table string) string {
+	return fmt.Sprintf(`(%[1]s.deleted_at IS NULL OR EXISTS (
+		SELECT 1 FROM comments AS live
+		WHERE live.path <@ (%[2]s) AND live.deleted_at IS NULL
+	))`, table, childPathOf(table))
+}
+
+// replies of the comment in table that are still shown in the thread
+var numOfSubCommentsSQL = fmt.Sprintf(`(select count(*) from comments as c
+	where c.path = %s and %s)`, childPathSQL, visibleSQL("c"))
+
+func (c *Comment) scan(rows *sql.Rows) error {
+	var deletedAt sql.NullTime
+	err := rows.Scan(
+		&c.Id,
+		&c.PostId,
+		&c.Body,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+		&c.Version,
+		&deletedAt,
+		&c.ParentId,
+		&c.childPath,
+		&c.NumOfSubComments,
+		&c.User.Id,
+		&c.User.Username,
+		&c.User.ProfilePicture,
+	)
+	if err != nil {
+		return err
+	}
+
+	c.EditInfo = data.NewEditInfo(c.Version)
+	if deletedAt.Valid {
+		c.tombstone()
+	}
+
+	return nil
+}
+
+// keeps the comments place in the thread without anything it said or who
+// said it
+func (c *Comment) tombstone() {
+	c.Deleted = true
+	c.Body = DELETED_BODY
+	c.User = data.User{}
+	c.EditInfo = data.EditInfo{}
+}
+
+// GetComment returns the comment with a page of its direct replies, each reply
+// carries its own replies up to depth levels below the comment. filters page
+// the direct replies, deeper levels hold at most filters.Take replies per
+// comment, num_of_sub_comments tells whether there are more to fetch
+func (c *CommentModel) GetComment(commentId int64, depth int, filters cursor.Filters) (Comment, cursor.Metadata, error) {
+	scan := filters.ScanOrder(cursor.ASC)
+	query := fmt.Sprintf(`
 	WITH main_comment as (
 		SELECT comments.id, comments.post_id, comments.body, comments.created_at, 
-		comments.updated_at, comments.path,
-		(select count(*) from comments as c
-		where path = comments.id::text::ltree) as num_of_sub_comments, 
+		comments.updated_at, comments.version, comments.deleted_at,
+		subpath(comments.path, -1)::text::bigint, (%[1]s)::text as child_path,
+		%[4]s as num_of_sub_comments, 
 		users.id as comment_user_id, users.username as comment_user_name,
 		users.profile_picture as comment_user_profile_picture
 		FROM comments
 		LEFT JOIN users ON users.id = comments.user_id
-		WHERE comments.id = $1
+		WHERE comments.id = $1 AND %[5]s
 		GROUP BY comments.id, users.id
 	),
 	sub_comments as (
 		SELECT comments.id, comments.post_id, comments.body, comments.created_at, 
-		comments.updated_at, comments.path,
-		(select count(*) from comments as c
-		where path = comments.id::text::ltree) as num_of_sub_comments, 
+		comments.updated_at, comments.version, comments.deleted_at,
+		subpath(comments.path, -1)::text::bigint, (%[1]s)::text as child_path,
+		%[4]s as num_of_sub_comments, 
 		users.id as sub_user_id, users.username as sub_username,
 		users.profile_picture as sub_profile_picture
 		FROM comments
 		LEFT JOIN users ON users.id = comments.user_id
-		WHERE comments.path <@ $1::text::ltree
+		WHERE comments.path = (SELECT child_path::ltree FROM main_comment)
+		AND %[5]s
+		AND ($4 OR (comments.created_at, comments.id) %[2]s ($2, $3))
 		GROUP BY comments.id, users.id
-		ORDER BY comments.created_at ASC
-		LIMIT $2
-		OFFSET $3
+		ORDER BY comments.created_at %[3]s, comments.id %[3]s
+		LIMIT $5
 	)
 	SELECT * from main_comment
 	UNION ALL
 	SELECT * FROM sub_comments
-	`
+	`, childPathSQL, filters.Compare(cursor.ASC), scan, numOfSubCommentsSQL, visibleSQL("comments"))
 	comment := Comment{}
 	comments := []Comment{}
+	metadata := cursor.Metadata{}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := c.DB.QueryContext(ctx, query, commentId, take, offset)
+	createdAt, id, first := filters.Args()
+	rows, err := c.DB.QueryContext(ctx, query, commentId, createdAt, id, first, filters.Limit())
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
-			return comment, ErrRecordNotFound
+			return comment, metadata, data.ErrRecordNotFound
 		default:
-			return comment, err
+			return comment, metadata, err
 		}
 	}
 
@@ -87,37 +163,11 @@ func (c *CommentModel) GetComment(commentId int64, take, offset int) (Comment, e
 
 	for rows.Next() {
 		tempComment := Comment{}
-		user := User{}
-
-		tempParentId := ""
-		numSubComments := 0
-
-		err = rows.Scan(
-			&tempComment.Id,
-			&tempComment.PostId,
-			&tempComment.Body,
-			&tempComment.CreatedAt,
-			&tempComment.UpdatedAt,
-			&tempParentId,
-			&numSubComments,
-			&user.Id,
-			&user.Username,
-			&user.ProfilePicture,
-		)
-
-		if err != nil {
-			return comment, err
-		}
-
-		parentIdInt, err := strconv.ParseInt(tempParentId, 10, 64)
+		err = tempComment.scan(rows)
 		if err != nil {
-			return comment, err
+			return comment, metadata, err
 		}
 
-		tempComment.ParentId = parentIdInt
-		tempComment.NumOfSubComments = numSubComments
-		tempComment.User = user
-
 		if tempComment.Id != commentId {
 			tempComment.SubComments = []Comment{}
 			comments = append(comments, tempComment)
@@ -127,13 +177,97 @@ func (c *CommentModel) GetComment(commentId int64, take, offset int) (Comment, e
 	}
 
 	if err = rows.Err(); err != nil {
-		return comment, err
+		return comment, metadata, err
 	}
 
 	if comment.Id == 0 {
-		return comment, ErrRecordNotFound
+		return comment, metadata, data.ErrRecordNotFound
+	}
+
+	comment.SubComments, metadata = cursor.Paginate(filters, comments, func(c Comment) (time.Time, int64) {
+		return c.CreatedAt, c.Id
+	})
+
+	if depth > 1 && len(comment.SubComments) > 0 {
+		err = c.loadReplies(ctx, comment.SubComments, depth-1, filters.Take)
+		if err != nil {
+			return comment, metadata, err
+		}
+	}
+
+	return comment, metadata, nil
+}
+
+// fills in replies below parents, levels deep, keeping the oldest perParent
+// replies of every comment
+func (c *CommentModel) loadReplies(ctx context.Context, parents []Comment, levels, perParent int) error {
+	childPaths := make([]string, len(parents))
+	for i, p := range parents {
+		childPaths[i] = p.childPath
+	}
+
+	query := fmt.Sprintf(`
+	SELECT comments.id, comments.post_id, comments.body, comments.created_at,
+	comments.updated_at, comments.version, comments.deleted_at,
+	subpath(comments.path, -1)::text::bigint, (%[1]s)::text as child_path,
+	%[2]s as num_of_sub_comments,
+	users.id, users.username, users.profile_picture
+	FROM (
+		SELECT comments.*, row_number() OVER (
+			PARTITION BY comments.path ORDER BY comments.created_at, comments.id
+		) AS position
+		FROM comments
+		WHERE comments.path <@ ANY($1::text[]::ltree[])
+		AND nlevel(comments.path) < $2
+		AND %[3]s
+	) AS comments
+	LEFT JOIN users ON users.id = comments.user_id
+	WHERE comments.position <= $3
+	ORDER BY nlevel(comments.path), comments.created_at, comments.id
+	`, childPathSQL, numOfSubCommentsSQL, visibleSQL("comments"))
+
+	// parents are siblings so their replies all start at the same level
+	maxLevel := strings.Count(parents[0].childPath, ".") + 1 + levels
+
+	rows, err := c.DB.QueryContext(ctx, query, pq.Array(childPaths), maxLevel, perParent)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	nodes := map[int64]*Comment{}
+	children := map[int64][]int64{}
+	for rows.Next() {
+		reply := &Comment{SubComments: []Comment{}}
+		err = reply.scan(rows)
+		if err != nil {
+			return err
+		}
+
+		nodes[reply.Id] = reply
+		children[reply.ParentId] = append(children[reply.ParentId], reply.Id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	var build func(id int64) []Comment
+	build = func(id int64) []Comment {
+		replies := []Comment{}
+		for _, childId := range children[id] {
+			reply := *nodes[childId]
+			reply.SubComments = build(childId)
+			replies = append(replies, reply)
+		}
+
+		return replies
+	}
+
+	for i := range parents {
+		parents[i].SubComments = build(parents[i].Id)
 	}
 
-	comment.SubComments = comments
-	return comment, nil
+	return nil
 }
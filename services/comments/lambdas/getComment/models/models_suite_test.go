@@ -3,9 +3,13 @@ package models
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"testing"
 	"time"
 
+	"events/common/cursor"
+	"events/common/data"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -35,7 +39,7 @@ var _ = BeforeSuite(func() {
 	}
 	container = postgres
 
-	dbConn, err := OpenDB(container.ConnectionString)
+	dbConn, err := data.OpenDB(container.ConnectionString)
 	if err != nil {
 		panic(err)
 	}
@@ -83,8 +87,8 @@ var _ = AfterSuite(func() {
 var _ = Describe("Get comment", Label("unit"), func() {
 	When("there are no comments in the db", func() {
 		It("should return not found", func() {
-			_, err := models.Comments.GetComment(99999, 10, 0)
-			Expect(err).To(MatchError(ErrRecordNotFound))
+			_, _, err := models.Comments.GetComment(99999, 1, cursor.Filters{Take: 10})
+			Expect(err).To(MatchError(data.ErrRecordNotFound))
 		})
 	})
 
@@ -129,12 +133,12 @@ var _ = Describe("Get comment", Label("unit"), func() {
 		})
 
 		It("should include the comment body", func() {
-			comment, err := models.Comments.GetComment(commentIds[0], 10, 0)
+			comment, _, err := models.Comments.GetComment(commentIds[0], 1, cursor.Filters{Take: 10})
 			Expect(err).ToNot(HaveOccurred())
 			Expect(comment.Body).To(Equal(commentBody))
 		})
 		It("should include a user with username, profile pic and id", func() {
-			comment, err := models.Comments.GetComment(commentIds[0], 10, 0)
+			comment, _, err := models.Comments.GetComment(commentIds[0], 1, cursor.Filters{Take: 10})
 			Expect(err).ToNot(HaveOccurred())
 			Expect(comment.User.Username).To(Equal(username))
 			Expect(comment.User.ProfilePicture).To(Equal(profilePicture))
@@ -143,12 +147,12 @@ var _ = Describe("Get comment", Label("unit"), func() {
 
 		When("a comment has no sub comments", func() {
 			It("should have sub comments as an empty slice", func() {
-				comment, err := models.Comments.GetComment(commentIds[0], 10, 0)
+				comment, _, err := models.Comments.GetComment(commentIds[0], 1, cursor.Filters{Take: 10})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(comment.SubComments).To(BeEmpty())
 			})
 			It("should have num of sub comments as 0", func() {
-				comment, err := models.Comments.GetComment(commentIds[0], 10, 0)
+				comment, _, err := models.Comments.GetComment(commentIds[0], 1, cursor.Filters{Take: 10})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(comment.NumOfSubComments).To(Equal(0))
 			})
@@ -195,52 +199,289 @@ var _ = Describe("Get comment", Label("unit"), func() {
 			})
 
 			It("should have sub comments", func() {
-				comment, err := models.Comments.GetComment(commentIds[0], 10, 0)
+				comment, _, err := models.Comments.GetComment(commentIds[0], 1, cursor.Filters{Take: 10})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(comment.SubComments).ToNot(BeEmpty())
 			})
 			It("should have num of sub comments as the number of sub comments", func() {
-				comment, err := models.Comments.GetComment(commentIds[0], 10, 0)
+				comment, _, err := models.Comments.GetComment(commentIds[0], 1, cursor.Filters{Take: 10})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(comment.NumOfSubComments).To(Equal(subCommentCount))
 			})
 			It("should have sub comments with the correct body", func() {
-				comment, err := models.Comments.GetComment(commentIds[0], 10, 0)
+				comment, _, err := models.Comments.GetComment(commentIds[0], 1, cursor.Filters{Take: 10})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(comment.SubComments[0].Body).To(Equal(subCommentBody))
 			})
 			It("should have sub comments with the correct user", func() {
-				comment, err := models.Comments.GetComment(commentIds[0], 10, 0)
+				comment, _, err := models.Comments.GetComment(commentIds[0], 1, cursor.Filters{Take: 10})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(comment.SubComments[0].User.Username).To(Equal(username))
 				Expect(comment.SubComments[0].User.ProfilePicture).To(Equal(profilePicture))
 				Expect(comment.SubComments[0].User.Id).To(Equal(userId))
 			})
 			It("should return sub comments always in the same order if state does not change in the db", func() {
-				comment, err := models.Comments.GetComment(commentIds[0], 10, 0)
+				comment, _, err := models.Comments.GetComment(commentIds[0], 1, cursor.Filters{Take: 10})
 				Expect(err).ToNot(HaveOccurred())
 
-				comment2, err := models.Comments.GetComment(commentIds[0], 10, 0)
+				comment2, _, err := models.Comments.GetComment(commentIds[0], 1, cursor.Filters{Take: 10})
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(comment.SubComments).To(Equal(comment2.SubComments))
 			})
 			It("should be possible to use pagination on the sub comments", func() {
-				comment, err := models.Comments.GetComment(commentIds[0], 5, 0)
+				comment, metadata, err := models.Comments.GetComment(commentIds[0], 1, cursor.Filters{Take: 5})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(comment.SubComments).To(HaveLen(5))
 
 				lastId := comment.SubComments[len(comment.SubComments)-1].Id
-				comment, err = models.Comments.GetComment(commentIds[0], 5, 5)
+				next, err := cursor.Decode(metadata.NextCursor)
+				Expect(err).ToNot(HaveOccurred())
+
+				comment, _, err = models.Comments.GetComment(commentIds[0], 1, cursor.Filters{Take: 5, Cursor: next})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(comment.SubComments).To(HaveLen(5))
 				Expect(comment.SubComments[len(comment.SubComments)-1]).ToNot(Equal(lastId))
 			})
 			It("should be possible to just return the parent comment with pagination set to 0", func() {
-				comment, err := models.Comments.GetComment(commentIds[0], 0, 0)
+				comment, _, err := models.Comments.GetComment(commentIds[0], 1, cursor.Filters{Take: 0})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(comment.SubComments).To(BeEmpty())
 			})
+
+			When("sub comments have replies of their own", func() {
+				var replyId, nestedReplyId int64
+				BeforeEach(func() {
+					query := `
+						insert into comments (body, user_id, post_id, path) values (
+						$1, $2, $3, $4::text::ltree
+						)
+						returning id
+					`
+
+					insertCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					defer cancel()
+
+					replyPath := fmt.Sprintf("%d.%d", commentIds[0], subCommentIds[0])
+					err := conn.QueryRowContext(insertCtx, query, "a reply", userId, postId, replyPath).Scan(&replyId)
+					if err != nil {
+						panic(err)
+					}
+
+					nestedPath := fmt.Sprintf("%s.%d", replyPath, replyId)
+					err = conn.QueryRowContext(insertCtx, query, "a nested reply", userId, postId, nestedPath).Scan(&nestedReplyId)
+					if err != nil {
+						panic(err)
+					}
+				})
+
+				It("should only count direct replies as sub comments", func() {
+					comment, _, err := models.Comments.GetComment(commentIds[0], 1, cursor.Filters{Take: 20})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(comment.NumOfSubComments).To(Equal(subCommentCount))
+					Expect(comment.SubComments).To(HaveLen(subCommentCount))
+					Expect(comment.SubComments[0].NumOfSubComments).To(Equal(1))
+					Expect(comment.SubComments[0].SubComments).To(BeEmpty())
+				})
+				It("should return replies down to the requested depth", func() {
+					comment, _, err := models.Comments.GetComment(commentIds[0], 3, cursor.Filters{Take: 20})
+					Expect(err).ToNot(HaveOccurred())
+
+					reply := comment.SubComments[0].SubComments
+					Expect(reply).To(HaveLen(1))
+					Expect(reply[0].Id).To(Equal(replyId))
+					Expect(reply[0].ParentId).To(Equal(subCommentIds[0]))
+					Expect(reply[0].SubComments).To(HaveLen(1))
+					Expect(reply[0].SubComments[0].Id).To(Equal(nestedReplyId))
+				})
+				It("should stop at the requested depth", func() {
+					comment, _, err := models.Comments.GetComment(commentIds[0], 2, cursor.Filters{Take: 20})
+					Expect(err).ToNot(HaveOccurred())
+
+					reply := comment.SubComments[0].SubComments
+					Expect(reply).To(HaveLen(1))
+					Expect(reply[0].NumOfSubComments).To(Equal(1))
+					Expect(reply[0].SubComments).To(BeEmpty())
+				})
+			})
+		})
+	})
+})
+
+var _ = Describe("List revisions", Label("unit"), func() {
+	When("the comment does not exist", func() {
+		It("should return not found", func() {
+			_, _, err := models.Revisions.List(99999, cursor.Filters{Take: 10})
+			Expect(err).To(MatchError(data.ErrRecordNotFound))
+		})
+	})
+
+	When("the comment has been edited", func() {
+		var commentId int64
+		BeforeEach(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			query := `
+				insert into comments (body, user_id, post_id, path, version) values (
+				'third body', $1, $2, '0', 3
+				)
+				returning id
+			`
+			err := conn.QueryRowContext(ctx, query, userId, postId).Scan(&commentId)
+			if err != nil {
+				panic(err)
+			}
+
+			query = `
+				insert into comment_revisions (comment_id, version, body, edited_by) values
+				($1, 1, 'first body', $2), ($1, 2, 'second body', $2)
+			`
+			_, err = conn.ExecContext(ctx, query, commentId, userId)
+			if err != nil {
+				panic(err)
+			}
+		})
+
+		AfterEach(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, err := conn.ExecContext(ctx, `delete from comments`)
+			if err != nil {
+				panic(err)
+			}
+		})
+
+		It("should mark the comment as edited", func() {
+			comment, _, err := models.Comments.GetComment(commentId, 1, cursor.Filters{Take: 10})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(comment.Edited).To(BeTrue())
+			Expect(comment.EditCount).To(Equal(int32(2)))
+		})
+		It("should return the replaced bodies newest first", func() {
+			revisions, _, err := models.Revisions.List(commentId, cursor.Filters{Take: 10})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(revisions).To(HaveLen(2))
+			Expect(revisions[0].Version).To(Equal(int32(2)))
+			Expect(revisions[0].Body).To(Equal("second body"))
+			Expect(revisions[1].Version).To(Equal(int32(1)))
+			Expect(revisions[0].EditedBy.Id).To(Equal(userId))
+		})
+		It("should page the revisions", func() {
+			revisions, metadata, err := models.Revisions.List(commentId, cursor.Filters{Take: 1})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(revisions).To(HaveLen(1))
+			Expect(metadata.NextCursor).ToNot(BeEmpty())
+		})
+		It("should return not found once the comment is deleted", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, err := conn.ExecContext(ctx, `update comments set deleted_at = now() where id = $1`, commentId)
+			if err != nil {
+				panic(err)
+			}
+
+			_, _, err = models.Revisions.List(commentId, cursor.Filters{Take: 10})
+			Expect(err).To(MatchError(data.ErrRecordNotFound))
+		})
+	})
+})
+
+var _ = Describe("Deleted comments", Label("unit"), func() {
+	var rootId, replyId int64
+	BeforeEach(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		query := `
+			insert into comments (body, user_id, post_id, path) values (
+			'root', $1, $2, '0'
+			)
+			returning id
+		`
+		err := conn.QueryRowContext(ctx, query, userId, postId).Scan(&rootId)
+		if err != nil {
+			panic(err)
+		}
+
+		query = `
+			insert into comments (body, user_id, post_id, path) values (
+			'reply', $1, $2, $3::text::ltree
+			)
+			returning id
+		`
+		err = conn.QueryRowContext(ctx, query, userId, postId, fmt.Sprint(rootId)).Scan(&replyId)
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	AfterEach(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_, err := conn.ExecContext(ctx, `delete from comments`)
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	softDelete := func(id int64) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_, err := conn.ExecContext(ctx, `update comments set deleted_at = now() where id = $1`, id)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	When("a deleted comment has live replies", func() {
+		BeforeEach(func() {
+			softDelete(rootId)
+		})
+
+		It("should render the comment as a tombstone", func() {
+			comment, _, err := models.Comments.GetComment(rootId, 1, cursor.Filters{Take: 10})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(comment.Deleted).To(BeTrue())
+			Expect(comment.Body).To(Equal(DELETED_BODY))
+			Expect(comment.User).To(Equal(data.User{}))
+		})
+		It("should keep the replies visible", func() {
+			comment, _, err := models.Comments.GetComment(rootId, 1, cursor.Filters{Take: 10})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(comment.NumOfSubComments).To(Equal(1))
+			Expect(comment.SubComments).To(HaveLen(1))
+			Expect(comment.SubComments[0].Body).To(Equal("reply"))
+			Expect(comment.SubComments[0].Deleted).To(BeFalse())
+		})
+	})
+
+	When("everything below a deleted comment is deleted", func() {
+		BeforeEach(func() {
+			softDelete(replyId)
+			softDelete(rootId)
+		})
+
+		It("should return not found", func() {
+			_, _, err := models.Comments.GetComment(rootId, 1, cursor.Filters{Take: 10})
+			Expect(err).To(MatchError(data.ErrRecordNotFound))
+		})
+	})
+
+	When("only a reply is deleted", func() {
+		BeforeEach(func() {
+			softDelete(replyId)
+		})
+
+		It("should leave the reply out of the thread", func() {
+			comment, _, err := models.Comments.GetComment(rootId, 1, cursor.Filters{Take: 10})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(comment.NumOfSubComments).To(Equal(0))
+			Expect(comment.SubComments).To(BeEmpty())
 		})
 	})
 })
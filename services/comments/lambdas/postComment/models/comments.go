@@ -1,26 +1,18 @@
-package main
+package models
 
 import (
 	"context"
 	"database/sql"
 	"errors"
 	"time"
-)
 
-var (
-	ErrRecordNotFound = errors.New("record not found")
+	"events/common/data"
 )
 
 type CommentModel struct {
 	DB *sql.DB
 }
 
-type User struct {
-	Id             int64  `json:"id"`
-	ProfilePicture string `json:"profile_picture"`
-	Username       string `json:"username"`
-}
-
 type Comment struct {
 	Id               int64     `json:"id"`
 	PostId           int64     `json:"post_id"`
@@ -30,10 +22,10 @@ type Comment struct {
 	UpdatedAt        time.Time `json:"updated_at"`
 	NumOfSubComments int       `json:"num_of_sub_comments"`
 	ParentId         int64     `json:"parent_id"`
-	User             User      `json:"user"`
+	User             data.User `json:"user"`
 }
 
-func (c *CommentModel) insertRootComment(comment *Comment, userId int64) error {
+func (c *CommentModel) InsertRootComment(comment *Comment, userId int64) error {
 	query := `
 	with insert_comment as (
 		INSERT INTO comments (post_id, body, path, user_id)
@@ -47,9 +39,15 @@ func (c *CommentModel) insertRootComment(comment *Comment, userId int64) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	var user User
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var user data.User
 
-	err := c.DB.QueryRowContext(ctx, query, comment.PostId, comment.Body, userId).Scan(
+	err = tx.QueryRowContext(ctx, query, comment.PostId, comment.Body, userId).Scan(
 		&comment.Id,
 		&comment.CreatedAt,
 		&comment.UpdatedAt,
@@ -65,15 +63,27 @@ func (c *CommentModel) insertRootComment(comment *Comment, userId int64) error {
 	comment.SubComments = []Comment{}
 	comment.User = user
 
-	return nil
+	err = enqueueCommentAdded(ctx, tx, comment)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (c *CommentModel) insertSubComment(comment *Comment, userId, parentId int64) error {
+func (c *CommentModel) InsertSubComment(comment *Comment, userId, parentId int64) error {
+	// a reply's path is its parents path with the parent appended, replies to
+	// top level comments drop the '0' so the chain always starts at the root
 	query := `
-	with inseet_comment as (
+	with parent as (
+		SELECT id, path FROM comments
+		WHERE id = $3 AND post_id = $1 AND deleted_at IS NULL
+	), inseet_comment as (
 		INSERT INTO comments (post_id, body, path, user_id)
-		VALUES ($1, $2, $3::text::ltree, $4)
-		RETURNING id, created_at, updated_at, path::text::bigint
+		SELECT $1, $2, CASE WHEN parent.path = '0' THEN parent.id::text::ltree
+			ELSE parent.path || parent.id::text END, $4
+		FROM parent
+		RETURNING id, created_at, updated_at, subpath(path, -1)::text::bigint AS path
 	) select inseet_comment.id, inseet_comment.created_at, inseet_comment.updated_at,
 	inseet_comment.path, users.id as usr_id, users.username, users.profile_picture from inseet_comment
 	left join users on users.id = $4
@@ -82,9 +92,15 @@ func (c *CommentModel) insertSubComment(comment *Comment, userId, parentId int64
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	var user User
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var user data.User
 
-	err := c.DB.QueryRowContext(
+	err = tx.QueryRowContext(
 		ctx,
 		query,
 		comment.PostId,
@@ -102,30 +118,26 @@ func (c *CommentModel) insertSubComment(comment *Comment, userId, parentId int64
 	)
 
 	if err != nil {
-		return err
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return data.ErrRecordNotFound
+		default:
+			return err
+		}
 	}
 
 	comment.SubComments = []Comment{}
 	comment.User = user
 
-	return nil
-}
-
-func (c *CommentModel) getParentCommentUserId(parentId int64) (int64, error) {
-	query := `
-	select user_id from comments where id = $1
-	`
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	var userId int64
-
-	err := c.DB.QueryRowContext(ctx, query, parentId).Scan(&userId)
+	err = enqueueCommentAdded(ctx, tx, comment)
+	if err != nil {
+		return err
+	}
 
+	err = enqueueSubCommentAdded(ctx, tx, comment)
 	if err != nil {
-		return 0, err
+		return err
 	}
 
-	return userId, nil
+	return tx.Commit()
 }
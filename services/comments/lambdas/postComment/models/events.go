@@ -0,0 +1,58 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+
+	"events/common/domain"
+	"events/common/outbox"
+)
+
+// every comment is queued, viewers of the post see it live even when it is the
+// post authors own. the consumer leaves out notifying someone of their own
+// comment
+func enqueueCommentAdded(ctx context.Context, tx *sql.Tx, comment *Comment) error {
+	var postUserId int64
+	query := `select user_id from posts where id = $1`
+	err := tx.QueryRowContext(ctx, query, comment.PostId).Scan(&postUserId)
+	if err != nil {
+		return err
+	}
+
+	event := domain.CommentAdded{
+		PostId:              comment.PostId,
+		CommentId:           comment.Id,
+		ParentCommentId:     comment.ParentId,
+		PostUserId:          postUserId,
+		CommentUserId:       comment.User.Id,
+		CommentUserUsername: comment.User.Username,
+		CommentCreatedAt:    comment.CreatedAt,
+		CommentBodyPreview:  domain.BodyPreview(comment.Body),
+	}
+
+	return outbox.Enqueue(ctx, tx, outbox.AGGREGATE_POST, comment.PostId, event, comment.CreatedAt)
+}
+
+// notifies the parent comment author about a reply and places it under its
+// parent for viewers of the post, queued for replies to your own comments too
+func enqueueSubCommentAdded(ctx context.Context, tx *sql.Tx, comment *Comment) error {
+	var parentCommentUserId int64
+	query := `select user_id from comments where id = $1`
+	err := tx.QueryRowContext(ctx, query, comment.ParentId).Scan(&parentCommentUserId)
+	if err != nil {
+		return err
+	}
+
+	event := domain.SubCommentAdded{
+		PostId:                   comment.PostId,
+		ParentCommentId:          comment.ParentId,
+		ChildCommentId:           comment.Id,
+		ParentCommentUserId:      parentCommentUserId,
+		ChildCommentUserId:       comment.User.Id,
+		ChildCommentUserUsername: comment.User.Username,
+		ChildCommentCreatedAt:    comment.CreatedAt,
+		ChildCommentBodyPreview:  domain.BodyPreview(comment.Body),
+	}
+
+	return outbox.Enqueue(ctx, tx, outbox.AGGREGATE_POST, comment.PostId, event, comment.CreatedAt)
+}
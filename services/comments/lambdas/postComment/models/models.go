@@ -1,4 +1,4 @@
-package main
+package models
 
 import (
 	"database/sql"
@@ -6,12 +6,10 @@ import (
 
 type Models struct {
 	Comments CommentModel
-	Posts    PostModel
 }
 
 func NewModels(db *sql.DB) Models {
 	return Models{
 		Comments: CommentModel{DB: db},
-		Posts:    PostModel{DB: db},
 	}
 }
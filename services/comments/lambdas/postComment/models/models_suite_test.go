@@ -0,0 +1,136 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"events/common/data"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var (
+	container      *PostgresContainer
+	models         Models
+	email          = "testmailbob@pubsub.com"
+	username       = "bob-cool"
+	profilePicture = "https://lh3.googleusercontent.com/a/default-user=s96-c"
+	conn           *sql.DB
+	userId         int64
+	postId         int64
+)
+
+func TestModels(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Models Suite")
+}
+
+var _ = BeforeSuite(func() {
+	ctx := context.Background()
+
+	postgres, err := CreatePostgresContainer(ctx)
+	if err != nil {
+		panic(err)
+	}
+	container = postgres
+
+	dbConn, err := data.OpenDB(container.ConnectionString)
+	if err != nil {
+		panic(err)
+	}
+
+	models = NewModels(dbConn)
+	conn = dbConn
+
+	query := `
+		insert into users (email, name, username, profile_picture) values (
+		$1, 'bob barry', $2, $3
+		)
+		returning id
+		`
+
+	insertCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err = dbConn.QueryRowContext(insertCtx, query, email, username, profilePicture).Scan(&userId)
+	if err != nil {
+		panic(err)
+	}
+
+	query = `
+		insert into posts (body, user_id) values (
+		$1, $2
+		)
+		returning id
+	`
+
+	err = dbConn.QueryRowContext(insertCtx, query, "this is a post", userId).Scan(&postId)
+	if err != nil {
+		panic(err)
+	}
+})
+
+var _ = AfterSuite(func() {
+	ctx := context.Background()
+	err := container.Terminate(ctx)
+	if err != nil {
+		panic(err)
+	}
+})
+
+func storedPath(commentId int64) string {
+	var path string
+	err := conn.QueryRow(`select path::text from comments where id = $1`, commentId).Scan(&path)
+	if err != nil {
+		panic(err)
+	}
+
+	return path
+}
+
+var _ = Describe("Insert comment", Label("unit"), func() {
+	AfterEach(func() {
+		_, err := conn.Exec(`delete from comments`)
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	It("should store a root comment under '0'", func() {
+		root := &Comment{Body: "root", PostId: postId}
+		err := models.Comments.InsertRootComment(root, userId)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(root.User.Username).To(Equal(username))
+		Expect(storedPath(root.Id)).To(Equal("0"))
+	})
+
+	It("should store a reply to a reply under the whole chain", func() {
+		root := &Comment{Body: "root", PostId: postId}
+		err := models.Comments.InsertRootComment(root, userId)
+		Expect(err).ToNot(HaveOccurred())
+
+		reply := &Comment{Body: "reply", PostId: postId}
+		err = models.Comments.InsertSubComment(reply, userId, root.Id)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reply.ParentId).To(Equal(root.Id))
+
+		nested := &Comment{Body: "nested reply", PostId: postId}
+		err = models.Comments.InsertSubComment(nested, userId, reply.Id)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nested.ParentId).To(Equal(reply.Id))
+		Expect(nested.User.Id).To(Equal(userId))
+
+		Expect(storedPath(reply.Id)).To(Equal(fmt.Sprintf("%d", root.Id)))
+		Expect(storedPath(nested.Id)).To(Equal(fmt.Sprintf("%d.%d", root.Id, reply.Id)))
+	})
+
+	It("should return not found for a reply to a missing comment", func() {
+		reply := &Comment{Body: "reply", PostId: postId}
+		err := models.Comments.InsertSubComment(reply, userId, 99999)
+		Expect(err).To(MatchError(data.ErrRecordNotFound))
+	})
+})
@@ -3,24 +3,19 @@ package main
 import (
 	"context"
 	"database/sql"
-	"errors"
+	"fmt"
 	"time"
-)
 
-var (
-	ErrRecordNotFound = errors.New("record not found")
+	"events/common/data"
+	"events/common/domain"
+	"events/common/outbox"
+	"events/common/revisions"
 )
 
 type CommentModel struct {
 	DB *sql.DB
 }
 
-type User struct {
-	Id             int64  `json:"id"`
-	ProfilePicture string `json:"profile_picture"`
-	Username       string `json:"username"`
-}
-
 type Comment struct {
 	Id               int64     `json:"id"`
 	PostId           int64     `json:"post_id"`
@@ -30,21 +25,25 @@ type Comment struct {
 	UpdatedAt        time.Time `json:"updated_at"`
 	NumOfSubComments int       `json:"num_of_sub_comments"`
 	ParentId         int64     `json:"parent_id"`
-	User             User      `json:"user"`
+	Version          int32     `json:"version"`
+	User             data.User `json:"user"`
+	data.EditInfo
+	postUserId int64
 }
 
 func (c *CommentModel) get(id int64) (Comment, error) {
 	query := `
 		select comments.id, comments.body, comments.created_at, comments.updated_at,
-		comments.post_id, comments.path, users.id, 
-		users.username, users.profile_picture
+		comments.version, comments.post_id, subpath(comments.path, -1)::text::bigint, users.id, 
+		users.username, users.profile_picture, posts.user_id
 		from comments
 		left join users on users.id = comments.user_id
-		where comments.id = $1
+		join posts on posts.id = comments.post_id
+		where comments.id = $1 and comments.deleted_at is null
 	`
 
 	comment := Comment{}
-	user := User{}
+	user := data.User{}
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
@@ -53,16 +52,18 @@ func (c *CommentModel) get(id int64) (Comment, error) {
 		&comment.Body,
 		&comment.CreatedAt,
 		&comment.UpdatedAt,
+		&comment.Version,
 		&comment.PostId,
 		&comment.ParentId,
 		&user.Id,
 		&user.Username,
 		&user.ProfilePicture,
+		&comment.postUserId,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return comment, ErrRecordNotFound
+			return comment, data.ErrRecordNotFound
 		}
 
 		return comment, err
@@ -70,29 +71,64 @@ func (c *CommentModel) get(id int64) (Comment, error) {
 
 	comment.SubComments = []Comment{}
 	comment.User = user
+	comment.EditInfo = data.NewEditInfo(comment.Version)
 
 	return comment, nil
 }
 
-func (c *CommentModel) update(comment *Comment) error {
-	query := `
+// the replaced body goes to comment_revisions in the same statement
+func (c *CommentModel) update(comment *Comment, editorId int64) error {
+	query := fmt.Sprintf(`
+		with old as (
+			select id, body, version from comments
+			where id = $3 and version = $4 and deleted_at is null
+			for update
+		), revision as (
+			%s
+		)
 		update comments
-		set body = $1, updated_at = $2
-		where id = $3
-		returning updated_at
-	`
+		set body = $1, updated_at = $2, version = comments.version + 1
+		from old
+		where comments.id = old.id
+		returning comments.updated_at, comments.version
+	`, revisions.Comments.InsertFrom("old", 5))
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := c.DB.QueryRowContext(ctx, query, comment.Body, time.Now(), comment.Id).Scan(&comment.UpdatedAt)
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	args := []any{comment.Body, time.Now(), comment.Id, comment.Version, editorId}
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&comment.UpdatedAt, &comment.Version)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return ErrRecordNotFound
+			return data.ErrEditConflict
 		}
 
 		return err
 	}
 
-	return nil
+	comment.EditInfo = data.NewEditInfo(comment.Version)
+
+	event := domain.CommentUpdated{
+		PostId:             comment.PostId,
+		CommentId:          comment.Id,
+		PostUserId:         comment.postUserId,
+		CommentUserId:      comment.User.Id,
+		EditorUserId:       editorId,
+		Version:            comment.Version,
+		CommentBodyPreview: domain.BodyPreview(comment.Body),
+		UpdatedAt:          comment.UpdatedAt,
+	}
+
+	err = outbox.Enqueue(ctx, tx, outbox.AGGREGATE_POST, comment.PostId, event, comment.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
@@ -5,68 +5,84 @@ import (
 	"net/http"
 	"strconv"
 
+	"events/common/api"
+	"events/common/data"
+	"events/session"
 	"github.com/go-chi/chi/v5"
 )
 
 func (app *app) updateCommentHandler(w http.ResponseWriter, r *http.Request) {
 	commentId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid comment id parameter"))
+		api.BadRequestResponse(w, r, errors.New("invalid comment id parameter"))
 		return
 	}
 
 	var input struct {
-		Body string `json:"body"`
+		Body    string `json:"body"`
+		Version *int32 `json:"version"`
 	}
 
-	err = app.readJSON(w, r, &input)
+	err = api.ReadJSON(w, r, &input)
 	if err != nil {
-		app.badRequestResponse(w, r, err)
+		api.BadRequestResponse(w, r, err)
 		return
 	}
+	expectedVersion, hasExpected, err := api.ReadIfMatch(r)
+	if err != nil {
+		api.BadRequestResponse(w, r, err)
+		return
+	}
+
 	comment, err := app.models.Comments.get(commentId)
 	if err != nil {
 		switch err {
-		case ErrRecordNotFound:
-			app.notFoundHandler(w, r)
+		case data.ErrRecordNotFound:
+			api.NotFoundResponse(w, r)
 		default:
-			app.serverErrorResponse(w, r, err)
+			api.ServerErrorResponse(w, r, err)
 		}
 		return
 	}
 
+	user := session.ContextGetUser(r)
+	if !user.CanModify(comment.User.Id) {
+		api.NotPermittedResponse(w, r)
+		return
+	}
+
 	if input.Body == "" {
-		app.badRequestResponse(w, r, errors.New("body can not be empty"))
+		api.BadRequestResponse(w, r, errors.New("body can not be empty"))
+		return
+	}
+
+	if input.Version != nil {
+		expectedVersion, hasExpected = *input.Version, true
+	}
+
+	if hasExpected && expectedVersion != comment.Version {
+		api.EditConflictResponse(w, r)
 		return
 	}
 
 	comment.Body = input.Body
-	err = app.models.Comments.update(&comment)
+	err = app.models.Comments.update(&comment, user.Id)
 	if err != nil {
 		switch err {
-		case ErrRecordNotFound:
-			app.notFoundHandler(w, r)
+		case data.ErrEditConflict:
+			api.EditConflictResponse(w, r)
 		default:
-			app.serverErrorResponse(w, r, err)
+			api.ServerErrorResponse(w, r, err)
 		}
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"comment": comment}, nil)
-	if err != nil {
-		app.serverErrorResponse(w, r, err)
-		return
-	}
-}
+	headers := make(http.Header)
+	headers.Set("ETag", api.VersionETag(comment.Version))
 
-func (app *app) healthcheckHandler(w http.ResponseWriter, r *http.Request) {
-	err := app.writeJSON(w, http.StatusOK, envelope{"status": "available"}, nil)
+	err = api.WriteJSON(w, http.StatusOK, api.Envelope{"comment": comment}, headers)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.ServerErrorResponse(w, r, err)
 		return
 	}
 }
-
-func (app *app) notFoundHandler(w http.ResponseWriter, r *http.Request) {
-	app.errorResponse(w, r, http.StatusNotFound, "resource not found")
-}
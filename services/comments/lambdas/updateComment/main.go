@@ -1,66 +1,38 @@
 package main
 
 import (
-	"context"
-	"database/sql"
 	"os"
-	"time"
 
-	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/awslabs/aws-lambda-go-api-proxy/chi"
+	"events/common/api"
+	"events/common/data"
+	"events/session"
 	"github.com/go-chi/chi/v5"
-	_ "github.com/lib/pq"
 )
 
-var chiLambda *chiadapter.ChiLambda
+var router *chi.Mux
 
 type app struct {
 	models Models
 }
 
-func openDB() (*sql.DB, error) {
-	addr := os.Getenv("DB_ADDRESS")
-	db, err := sql.Open("postgres", addr)
-	if err != nil {
-		return nil, err
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	err = db.PingContext(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	return db, nil
-}
-
 func init() {
-	db, err := openDB()
+	db, err := data.OpenDB(os.Getenv("DB_ADDRESS"))
 	if err != nil {
 		panic(err)
 	}
 
 	app := app{models: NewModels(db)}
-	r := chi.NewRouter()
+	r := api.NewRouter()
+	sessionModel := session.SessionModel{DB: db}
+	r.Use(sessionModel.Authenticate)
 	r.Route("/update", func(r chi.Router) {
-		r.Get("/healthcheck", app.healthcheckHandler)
-		r.Put("/{id}", app.updateCommentHandler)
+		r.Get("/healthcheck", api.HealthcheckHandler)
+		r.With(session.RequireAuthenticatedUser).Put("/{id}", app.updateCommentHandler)
 	})
-	r.NotFound(app.notFoundHandler)
-
-	chiLambda = chiadapter.New(r)
-}
 
-func Handler(
-	ctx context.Context,
-	event events.APIGatewayProxyRequest,
-) (events.APIGatewayProxyResponse, error) {
-	return chiLambda.ProxyWithContext(ctx, event)
+	router = r
 }
 
 func main() {
-	lambda.StartWithOptions(Handler, lambda.WithContext(context.Background()))
+	api.Start(router)
 }
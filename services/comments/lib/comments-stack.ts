@@ -4,7 +4,6 @@ import { RestApi, LambdaIntegration } from "aws-cdk-lib/aws-apigateway";
 import { Bucket } from 'aws-cdk-lib/aws-s3'
 import { createLambda } from '../../../lib/lambda';
 import * as path from "path"
-import * as events from 'aws-cdk-lib/aws-events';
 
 enum BaseUrlPaths {
   HEALTH = "healthcheck",
@@ -13,22 +12,26 @@ enum BaseUrlPaths {
   COMMENTS = "comments",
   UPDATE_COMMENT = "update",
   DELETE_COMMENT = "delete",
+  REVISIONS = "revisions",
 }
 
 interface CommentsProps {
   db_url?: string
-  eventBus: events.EventBus
+  session_secret?: string
 }
 
 export class Comments extends Construct {
   constructor(scope: Construct, id: string, props: CommentsProps) {
     super(scope, id);
-    const { eventBus } = props
 
     if (!props.db_url) {
       throw new Error("DB env var is not set")
     }
 
+    if (!props.session_secret) {
+      throw new Error("SESSION_SECRET env var is not set")
+    }
+
     const hotReloadBucket = Bucket.fromBucketName(
       this,
       "HotReloadingBucket",
@@ -40,16 +43,15 @@ export class Comments extends Construct {
       "PostCommentLambda",
       path.join(__dirname, "../lambdas/postComment"),
       hotReloadBucket,
-      { DB_ADDRESS: props.db_url, BUS_NAME: eventBus.eventBusName },
+      { DB_ADDRESS: props.db_url, SESSION_SECRET: props.session_secret },
     )
-    eventBus.grantPutEventsTo(postCommentLambda)
 
     const getCommentLambda = createLambda(
       this,
       "GetCommentLambda",
       path.join(__dirname, "../lambdas/getComment"),
       hotReloadBucket,
-      { DB_ADDRESS: props.db_url },
+      { DB_ADDRESS: props.db_url, SESSION_SECRET: props.session_secret },
     )
 
     const updateCommentLambda = createLambda(
@@ -57,7 +59,7 @@ export class Comments extends Construct {
       "UpdateCommentLambda",
       path.join(__dirname, "../lambdas/updateComment"),
       hotReloadBucket,
-      { DB_ADDRESS: props.db_url },
+      { DB_ADDRESS: props.db_url, SESSION_SECRET: props.session_secret },
     )
 
     const deleteCommentLambda = createLambda(
@@ -65,7 +67,7 @@ export class Comments extends Construct {
       "DeleteCommentLambda",
       path.join(__dirname, "../lambdas/deleteComment"),
       hotReloadBucket,
-      { DB_ADDRESS: props.db_url },
+      { DB_ADDRESS: props.db_url, SESSION_SECRET: props.session_secret },
     )
 
     const api = new RestApi(this, "commentsapi", {
@@ -93,6 +95,9 @@ export class Comments extends Construct {
     const getComment = comments.addResource(BaseUrlPaths.BY_ID)
     getComment.addMethod("GET", getCommentIntegration)
 
+    const commentRevisions = getComment.addResource(BaseUrlPaths.REVISIONS)
+    commentRevisions.addMethod("GET", getCommentIntegration)
+
     const getHealth = comments.addResource(BaseUrlPaths.HEALTH)
     getHealth.addMethod("GET", getCommentIntegration)
 
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+)
+
+func ErrorResponse(w http.ResponseWriter, r *http.Request, status int, message any) {
+	env := Envelope{"error": message}
+	err := WriteJSON(w, status, env, nil)
+	if err != nil {
+		w.WriteHeader(500)
+	}
+}
+
+func ServerErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	fmt.Printf("%s %s: %s\n", r.Method, r.URL.Path, err.Error())
+	message := "the server encountared a problem and could not process this request :("
+	ErrorResponse(w, r, http.StatusInternalServerError, message)
+}
+
+func BadRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
+	ErrorResponse(w, r, http.StatusBadRequest, err.Error())
+}
+
+func NotFoundResponse(w http.ResponseWriter, r *http.Request) {
+	ErrorResponse(w, r, http.StatusNotFound, "resource not found")
+}
+
+func MethodNotAllowedResponse(w http.ResponseWriter, r *http.Request) {
+	message := fmt.Sprintf("the %s method is not supported for this resource", r.Method)
+	ErrorResponse(w, r, http.StatusMethodNotAllowed, message)
+}
+
+func InvalidCredentialsResponse(w http.ResponseWriter, r *http.Request) {
+	ErrorResponse(w, r, http.StatusUnauthorized, "invalid authentication credentials")
+}
+
+func InvalidAuthenticationTokenResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	ErrorResponse(w, r, http.StatusUnauthorized, "invalid or missing authentication token")
+}
+
+func AuthenticationRequiredResponse(w http.ResponseWriter, r *http.Request) {
+	ErrorResponse(w, r, http.StatusUnauthorized, "you must be authenticated to access this resource")
+}
+
+func EditConflictResponse(w http.ResponseWriter, r *http.Request) {
+	message := "unable to update the record due to an edit conflict, please try again"
+	ErrorResponse(w, r, http.StatusConflict, message)
+}
+
+func NotPermittedResponse(w http.ResponseWriter, r *http.Request) {
+	ErrorResponse(w, r, http.StatusForbidden, "your user account doesn't have the necessary permissions to access this resource")
+}
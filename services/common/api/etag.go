@@ -0,0 +1,65 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidIfMatch = errors.New("If-Match must be a quoted version number")
+
+// strong etag of a versioned resource as returned by an edit, the same value
+// is accepted back in If-Match. reads that embed other rows use ContentETag
+// instead and clients send the version from the body
+func VersionETag(version int32) string {
+	return fmt.Sprintf("%q", strconv.Itoa(int(version)))
+}
+
+// version from the If-Match header, ok is false when the header is not set
+// or is * which matches whatever version is current
+func ReadIfMatch(r *http.Request) (version int32, ok bool, err error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, false, nil
+	}
+
+	unquoted, err := strconv.Unquote(header)
+	if err != nil {
+		return 0, false, ErrInvalidIfMatch
+	}
+
+	v, err := strconv.ParseInt(unquoted, 10, 32)
+	if err != nil || v < 1 {
+		return 0, false, ErrInvalidIfMatch
+	}
+
+	return int32(v), true, nil
+}
+
+// weak etag over the serialized response, for lists and other responses that
+// have no single version to point at
+func ContentETag(data Envelope) (string, error) {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(js)
+	return fmt.Sprintf(`W/"%x"`, sum[:16]), nil
+}
+
+// reports whether the client already holds the representation tagged etag
+func NotModified(r *http.Request, etag string) bool {
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
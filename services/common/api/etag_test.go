@@ -0,0 +1,63 @@
+package api
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadIfMatch(t *testing.T) {
+	tests := []struct {
+		header  string
+		version int32
+		ok      bool
+		err     error
+	}{
+		{"", 0, false, nil},
+		{"*", 0, false, nil},
+		{VersionETag(3), 3, true, nil},
+		{`"12"`, 12, true, nil},
+		{"12", 0, false, ErrInvalidIfMatch},
+		{`"0"`, 0, false, ErrInvalidIfMatch},
+		{`"abc"`, 0, false, ErrInvalidIfMatch},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("PUT", "/", nil)
+		if tt.header != "" {
+			r.Header.Set("If-Match", tt.header)
+		}
+
+		version, ok, err := ReadIfMatch(r)
+		if version != tt.version || ok != tt.ok || !errors.Is(err, tt.err) {
+			t.Errorf("If-Match %q: got (%d, %v, %v), want (%d, %v, %v)",
+				tt.header, version, ok, err, tt.version, tt.ok, tt.err)
+		}
+	}
+}
+
+func TestContentETag(t *testing.T) {
+	etag, err := ContentETag(Envelope{"posts": []int{1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := ContentETag(Envelope{"posts": []int{1, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if etag == other {
+		t.Fatalf("different content produced the same etag %s", etag)
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("If-None-Match", `"something", `+etag)
+	if !NotModified(r, etag) {
+		t.Errorf("expected %s to match If-None-Match", etag)
+	}
+
+	if NotModified(r, other) {
+		t.Errorf("did not expect %s to match If-None-Match", other)
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package api
 
 import (
 	"encoding/json"
@@ -9,12 +9,12 @@ import (
 	"strings"
 )
 
-type envelope map[string]any
+type Envelope map[string]any
 
-func (app *app) writeJSON(
+func WriteJSON(
 	w http.ResponseWriter,
 	status int,
-	data envelope,
+	data Envelope,
 	headers http.Header,
 ) error {
 	js, err := json.MarshalIndent(data, "", "\t")
@@ -34,20 +34,7 @@ func (app *app) writeJSON(
 	return nil
 }
 
-func (app *app) errorResponse(w http.ResponseWriter, r *http.Request, status int, message any) {
-	env := envelope{"error": message}
-	err := app.writeJSON(w, status, env, nil)
-	if err != nil {
-		w.WriteHeader(500)
-	}
-}
-
-func (app *app) serverErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
-	message := "the server encountared a problem and could not process this request :("
-	app.errorResponse(w, r, http.StatusInternalServerError, message)
-}
-
-func (app *app) readJSON(w http.ResponseWriter, r *http.Request, dist any) error {
+func ReadJSON(w http.ResponseWriter, r *http.Request, dist any) error {
 	maxBytes := 1_048_576
 	r.Body = http.MaxBytesReader(w, r.Body, int64(maxBytes))
 	dec := json.NewDecoder(r.Body)
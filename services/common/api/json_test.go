@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{name: "valid", body: `{"body": "hello"}`},
+		{name: "empty", body: ``, wantErr: "body must not be empty"},
+		{name: "bad json", body: `{"body": }`, wantErr: "body contains badly formatted JSON (at character 10)"},
+		{name: "wrong type", body: `{"body": 1}`, wantErr: `body contains incorrect JSON type for field "body"`},
+		{name: "multiple values", body: `{"body": "a"}{"body": "b"}`, wantErr: "body must contain a single JSON value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input struct {
+				Body string `json:"body"`
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			err := ReadJSON(w, r, &input)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %s", err.Error())
+				}
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNotFoundResponse(t *testing.T) {
+	r := NewRouter()
+	r.Get("/healthcheck", HealthcheckHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/nope", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+
+	var res Envelope
+	err := json.Unmarshal(w.Body.Bytes(), &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res["error"] != "resource not found" {
+		t.Fatalf("unexpected body %v", res)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthcheck", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
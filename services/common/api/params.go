@@ -0,0 +1,36 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// reads an int from the query string, falling back to defaultValue when the
+// key is missing and returning an error when it is not a valid int
+func ReadInt(qs url.Values, key string, defaultValue int) (int, error) {
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue, nil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return defaultValue, errors.New("key must be a valid int")
+	}
+	return i, nil
+}
+
+// reads a positive int64 id from the chi url params
+func ReadIDParam(r *http.Request, key string) (int64, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, key), 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid %s id!", key)
+	}
+
+	return id, nil
+}
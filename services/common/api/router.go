@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/awslabs/aws-lambda-go-api-proxy/chi"
+	"github.com/go-chi/chi/v5"
+)
+
+// returns a chi router with the json notFound and methodNotAllowed handlers
+// every lambda api shares
+func NewRouter() *chi.Mux {
+	r := chi.NewRouter()
+	r.NotFound(NotFoundResponse)
+	r.MethodNotAllowed(MethodNotAllowedResponse)
+
+	return r
+}
+
+func HealthcheckHandler(w http.ResponseWriter, r *http.Request) {
+	err := WriteJSON(w, http.StatusOK, Envelope{"status": "available"}, nil)
+	if err != nil {
+		ServerErrorResponse(w, r, err)
+	}
+}
+
+// starts the lambda runtime proxying api gateway events to the router
+func Start(r *chi.Mux) {
+	chiLambda := chiadapter.New(r)
+	handler := func(
+		ctx context.Context,
+		event events.APIGatewayProxyRequest,
+	) (events.APIGatewayProxyResponse, error) {
+		return chiLambda.ProxyWithContext(ctx, event)
+	}
+
+	lambda.StartWithOptions(handler, lambda.WithContext(context.Background()))
+}
@@ -0,0 +1,87 @@
+package bus
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"events/common/domain"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/eventbridge/eventbridgeiface"
+)
+
+const (
+	DETAIL_TYPE = "NotificationReceived"
+	SOURCE      = "notifications"
+)
+
+func NewEventBridge() *eventbridge.EventBridge {
+	session := session.Must(session.NewSession())
+	eb := eventbridge.New(session, aws.NewConfig().
+		WithRegion("us-east-1").
+		WithEndpoint("http://localstack:4566"),
+	)
+
+	return eb
+}
+
+type Publisher struct {
+	eb      eventbridgeiface.EventBridgeAPI
+	busName string
+}
+
+// publisher for the bus named in the BUS_NAME env var
+func NewPublisher() *Publisher {
+	return &Publisher{
+		eb:      NewEventBridge(),
+		busName: os.Getenv("BUS_NAME"),
+	}
+}
+
+// wraps the payload in a versioned domain.Envelope and puts it on the bus
+func (p *Publisher) Publish(payload domain.Payload, occurredAt time.Time) error {
+	env, err := domain.NewEnvelope(payload, occurredAt)
+	if err != nil {
+		return err
+	}
+
+	detail, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	return p.PublishRaw(detail)
+}
+
+// EventBridge reports entries it rejected in the output rather than as an
+// error, those are returned as errors too so the caller retries them
+func (p *Publisher) PublishRaw(detail []byte) error {
+	out, err := p.eb.PutEvents(&eventbridge.PutEventsInput{
+		Entries: []*eventbridge.PutEventsRequestEntry{
+			{
+				Detail:       aws.String(string(detail)),
+				DetailType:   aws.String(DETAIL_TYPE),
+				Source:       aws.String(SOURCE),
+				EventBusName: aws.String(p.busName),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if aws.Int64Value(out.FailedEntryCount) > 0 {
+		for _, entry := range out.Entries {
+			if entry.ErrorCode != nil {
+				return fmt.Errorf("event rejected: %s: %s", aws.StringValue(entry.ErrorCode), aws.StringValue(entry.ErrorMessage))
+			}
+		}
+
+		return fmt.Errorf("event rejected")
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package bus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/eventbridge/eventbridgeiface"
+)
+
+type stubEventBridge struct {
+	eventbridgeiface.EventBridgeAPI
+	out *eventbridge.PutEventsOutput
+}
+
+func (s *stubEventBridge) PutEvents(*eventbridge.PutEventsInput) (*eventbridge.PutEventsOutput, error) {
+	return s.out, nil
+}
+
+func TestPublishRaw(t *testing.T) {
+	p := &Publisher{eb: &stubEventBridge{out: &eventbridge.PutEventsOutput{
+		FailedEntryCount: aws.Int64(0),
+		Entries:          []*eventbridge.PutEventsResultEntry{{EventId: aws.String("1")}},
+	}}}
+
+	err := p.PublishRaw([]byte(`{}`))
+	if err != nil {
+		t.Fatalf("expected the event to be published, got %v", err)
+	}
+}
+
+func TestPublishRawRejectedEntry(t *testing.T) {
+	p := &Publisher{eb: &stubEventBridge{out: &eventbridge.PutEventsOutput{
+		FailedEntryCount: aws.Int64(1),
+		Entries: []*eventbridge.PutEventsResultEntry{{
+			ErrorCode:    aws.String("ThrottlingException"),
+			ErrorMessage: aws.String("rate exceeded"),
+		}},
+	}}}
+
+	err := p.PublishRaw([]byte(`{}`))
+	if err == nil || !strings.Contains(err.Error(), "ThrottlingException") {
+		t.Fatalf("expected the rejected entry as an error, got %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	MIN_TAKE     = 1
+	MAX_TAKE     = 20
+	DEFAULT_TAKE = 10
+
+	// sort orders of the list being paged, both tie break on id
+	ASC  = "asc"
+	DESC = "desc"
+
+	forward  = "n"
+	backward = "p"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidTake   = errors.New("take must be between 1 and 20")
+)
+
+// Cursor is the position of a row in a list ordered by (created_at, id), the
+// direction says whether the page asked for comes after or before that row
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	Id        int64     `json:"id"`
+	Direction string    `json:"d"`
+}
+
+func (c Cursor) Encode() string {
+	js, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(js)
+}
+
+func Decode(s string) (*Cursor, error) {
+	js, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	err = json.Unmarshal(js, &c)
+	if err != nil || c.Id < 1 || (c.Direction != forward && c.Direction != backward) {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+type Filters struct {
+	Take   int
+	Cursor *Cursor
+}
+
+func ValidateFilters(f Filters) error {
+	if f.Take < MIN_TAKE || f.Take > MAX_TAKE {
+		return ErrInvalidTake
+	}
+
+	return nil
+}
+
+// reads ?take= and ?cursor= and validates them
+func ReadFilters(qs url.Values) (Filters, error) {
+	f := Filters{Take: DEFAULT_TAKE}
+
+	if s := qs.Get("take"); s != "" {
+		take, err := strconv.Atoi(s)
+		if err != nil {
+			return f, ErrInvalidTake
+		}
+
+		f.Take = take
+	}
+
+	if s := qs.Get("cursor"); s != "" {
+		c, err := Decode(s)
+		if err != nil {
+			return f, err
+		}
+
+		f.Cursor = c
+	}
+
+	return f, ValidateFilters(f)
+}
+
+func (f Filters) backward() bool {
+	return f.Cursor != nil && f.Cursor.Direction == backward
+}
+
+// values for the keyset placeholders, first is true when there is no cursor
+// and the comparison should be skipped
+func (f Filters) Args() (createdAt time.Time, id int64, first bool) {
+	if f.Cursor == nil {
+		return time.Time{}, 0, true
+	}
+
+	return f.Cursor.CreatedAt, f.Cursor.Id, false
+}
+
+// comparison operator putting rows on the requested side of the cursor for a
+// list sorted by order
+func (f Filters) Compare(order string) string {
+	if (order == DESC) != f.backward() {
+		return "<"
+	}
+
+	return ">"
+}
+
+// the direction rows have to be scanned in, backward pages are read towards
+// the cursor and flipped back by Paginate
+func (f Filters) ScanOrder(order string) string {
+	if !f.backward() {
+		return order
+	}
+
+	if order == DESC {
+		return ASC
+	}
+
+	return DESC
+}
+
+// rows to ask the db for, one extra tells whether another page exists
+func (f Filters) Limit() int {
+	return f.Take + 1
+}
+
+type Metadata struct {
+	PageSize   int    `json:"page_size"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// trims the lookahead row, restores list order for backward pages and hands
+// out cursors pointing at the pages on either side
+func Paginate[T any](f Filters, items []T, key func(T) (time.Time, int64)) ([]T, Metadata) {
+	more := len(items) > f.Take
+	if more {
+		items = items[:f.Take]
+	}
+
+	if f.backward() {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	metadata := Metadata{PageSize: len(items)}
+	if len(items) == 0 {
+		return items, metadata
+	}
+
+	// coming from a cursor means there is a page back the way we came
+	hasNext := more || f.backward()
+	hasPrev := f.Cursor != nil && !f.backward() || f.backward() && more
+
+	if hasNext {
+		createdAt, id := key(items[len(items)-1])
+		metadata.NextCursor = Cursor{CreatedAt: createdAt, Id: id, Direction: forward}.Encode()
+	}
+
+	if hasPrev {
+		createdAt, id := key(items[0])
+		metadata.PrevCursor = Cursor{CreatedAt: createdAt, Id: id, Direction: backward}.Encode()
+	}
+
+	return items, metadata
+}
@@ -0,0 +1,173 @@
+package cursor
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type row struct {
+	createdAt time.Time
+	id        int64
+}
+
+func rowKey(r row) (time.Time, int64) {
+	return r.createdAt, r.id
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := Cursor{
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC),
+		Id:        7,
+		Direction: forward,
+	}
+
+	got, err := Decode(want.Encode())
+	if err != nil {
+		t.Fatalf("decoding cursor: %v", err)
+	}
+
+	if !got.CreatedAt.Equal(want.CreatedAt) || got.Id != want.Id || got.Direction != want.Direction {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestReadFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		qs   url.Values
+		err  error
+	}{
+		{"defaults", url.Values{}, nil},
+		{"take in range", url.Values{"take": {"20"}}, nil},
+		{"take zero", url.Values{"take": {"0"}}, ErrInvalidTake},
+		{"take too large", url.Values{"take": {"21"}}, ErrInvalidTake},
+		{"take not a number", url.Values{"take": {"ten"}}, ErrInvalidTake},
+		{"garbage cursor", url.Values{"cursor": {"%%%"}}, ErrInvalidCursor},
+		{"cursor without direction", url.Values{"cursor": {Cursor{Id: 1}.Encode()}}, ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadFilters(tt.qs)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestCompareAndScanOrder(t *testing.T) {
+	next := Filters{Take: 1, Cursor: &Cursor{Id: 1, Direction: forward}}
+	prev := Filters{Take: 1, Cursor: &Cursor{Id: 1, Direction: backward}}
+
+	tests := []struct {
+		f       Filters
+		order   string
+		compare string
+		scan    string
+	}{
+		{next, DESC, "<", DESC},
+		{prev, DESC, ">", ASC},
+		{next, ASC, ">", ASC},
+		{prev, ASC, "<", DESC},
+	}
+
+	for _, tt := range tests {
+		if got := tt.f.Compare(tt.order); got != tt.compare {
+			t.Errorf("Compare(%s) %s = %s, want %s", tt.order, tt.f.Cursor.Direction, got, tt.compare)
+		}
+
+		if got := tt.f.ScanOrder(tt.order); got != tt.scan {
+			t.Errorf("ScanOrder(%s) %s = %s, want %s", tt.order, tt.f.Cursor.Direction, got, tt.scan)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	now := time.Now()
+	rows := []row{{now, 3}, {now, 2}, {now, 1}}
+
+	page, metadata := Paginate(Filters{Take: 2}, rows, rowKey)
+	if len(page) != 2 || metadata.PageSize != 2 {
+		t.Fatalf("first page has %d rows", len(page))
+	}
+
+	if metadata.NextCursor == "" || metadata.PrevCursor != "" {
+		t.Fatalf("first page cursors: %+v", metadata)
+	}
+
+	next, err := Decode(metadata.NextCursor)
+	if err != nil || next.Id != 2 {
+		t.Fatalf("next cursor %+v, %v", next, err)
+	}
+
+	page, metadata = Paginate(Filters{Take: 2, Cursor: next}, rows[2:], rowKey)
+	if len(page) != 1 || metadata.NextCursor != "" || metadata.PrevCursor == "" {
+		t.Fatalf("last page: %v %+v", page, metadata)
+	}
+
+	prev, err := Decode(metadata.PrevCursor)
+	if err != nil || prev.Id != 1 {
+		t.Fatalf("prev cursor %+v, %v", prev, err)
+	}
+
+	// backward pages come out of the db reversed, with a lookahead row
+	page, metadata = Paginate(Filters{Take: 1, Cursor: prev}, []row{{now, 2}, {now, 3}}, rowKey)
+	if len(page) != 1 || page[0].id != 2 {
+		t.Fatalf("backward page: %v", page)
+	}
+
+	if metadata.NextCursor == "" || metadata.PrevCursor == "" {
+		t.Fatalf("backward page cursors: %+v", metadata)
+	}
+}
+
+func TestRankedRoundTrip(t *testing.T) {
+	want := Ranked{Scope: "(brown <-> fox)", Score: 0.30000001192092896, Kind: "comment", Id: 42}
+
+	got, err := DecodeRanked(want.Encode(), want.Scope)
+	if err != nil {
+		t.Fatalf("decoding cursor: %v", err)
+	}
+
+	if *got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeInvalidRanked(t *testing.T) {
+	for _, s := range []string{
+		"not base64!",
+		"bm90IGpzb24",
+		Ranked{Id: 1}.Encode(),
+		Ranked{Scope: "fox:*"}.Encode(),
+		Ranked{Scope: "dog:*", Id: 1}.Encode(),
+	} {
+		_, err := DecodeRanked(s, "fox:*")
+		if !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeRanked(%q) = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+}
+
+func TestPaginateRanked(t *testing.T) {
+	key := func(id int64) Ranked { return Ranked{Scope: "fox:*", Score: 1, Id: id} }
+
+	// a full page without the lookahead row is the last one
+	page, metadata := PaginateRanked(2, []int64{3, 2}, key)
+	if len(page) != 2 || metadata.NextCursor != "" || metadata.PrevCursor != "" {
+		t.Fatalf("last page: %v %+v", page, metadata)
+	}
+
+	page, metadata = PaginateRanked(2, []int64{3, 2, 1}, key)
+	if len(page) != 2 || metadata.PageSize != 2 {
+		t.Fatalf("first page: %v", page)
+	}
+
+	next, err := DecodeRanked(metadata.NextCursor, "fox:*")
+	if err != nil || next.Id != 2 {
+		t.Fatalf("next cursor %+v, %v", next, err)
+	}
+}
@@ -0,0 +1,65 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// Ranked is the position of a row in a list ordered by (score, kind, id)
+// descending, which is how search results are sorted. Scope is what the list
+// was built from (the query) so a cursor from one search can not be replayed
+// against another, Kind tells apart rows of different tables sharing a page
+type Ranked struct {
+	Scope string  `json:"q"`
+	Score float64 `json:"s"`
+	Kind  string  `json:"k,omitempty"`
+	Id    int64   `json:"id"`
+}
+
+func (c Ranked) Encode() string {
+	js, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(js)
+}
+
+// decodes a cursor handed out for the list built from scope
+func DecodeRanked(s, scope string) (*Ranked, error) {
+	js, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Ranked
+	err = json.Unmarshal(js, &c)
+	if err != nil || c.Id < 1 || c.Scope == "" || c.Scope != scope {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// values for the keyset placeholders, first is true when there is no cursor
+// and the comparison should be skipped
+func (c *Ranked) Args() (score float64, kind string, id int64, first bool) {
+	if c == nil {
+		return 0, "", 0, true
+	}
+
+	return c.Score, c.Kind, c.Id, false
+}
+
+// trims the lookahead row of a ranked page and hands out a cursor to the next
+// one when there is more. ranks shift as content changes so ranked lists are
+// only paged forward and never get a prev cursor
+func PaginateRanked[T any](take int, items []T, key func(T) Ranked) ([]T, Metadata) {
+	more := len(items) > take
+	if more {
+		items = items[:take]
+	}
+
+	metadata := Metadata{PageSize: len(items)}
+	if more {
+		metadata.NextCursor = key(items[len(items)-1]).Encode()
+	}
+
+	return items, metadata
+}
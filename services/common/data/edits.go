@@ -0,0 +1,16 @@
+package data
+
+// posts and comments start at version 1 and every edit bumps it, so the edit
+// history can be read straight off the version
+type EditInfo struct {
+	Edited    bool  `json:"edited"`
+	EditCount int32 `json:"edit_count"`
+}
+
+func NewEditInfo(version int32) EditInfo {
+	if version <= 1 {
+		return EditInfo{}
+	}
+
+	return EditInfo{Edited: true, EditCount: version - 1}
+}
@@ -0,0 +1,10 @@
+package data
+
+import "errors"
+
+var (
+	ErrRecordNotFound   = errors.New("record not found")
+	ErrEditConflict     = errors.New("edit conflict")
+	ErrAlreadyLiked     = errors.New("user has already liked this")
+	ErrAlreadyFollowing = errors.New("user has already followed this")
+)
@@ -0,0 +1,69 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"events/common/media"
+)
+
+// Post, PostMetadata and PostData are the shape every endpoint listing posts
+// returns so clients can render them with the same components. Comments is
+// only filled in when a single post is read
+type Post struct {
+	Id        int64         `json:"id"`
+	Body      string        `json:"body"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	Version   int32         `json:"version"`
+	Comments  []Comment     `json:"comments"`
+	User      User          `json:"user"`
+	Media     []media.Media `json:"media"`
+	EditInfo
+}
+
+type PostMetadata struct {
+	LastCommentAt time.Time `json:"last_comment_at"`
+	CommentsCount int       `json:"comments_count"`
+	LatestComment string    `json:"latest_comment"`
+}
+
+type PostData struct {
+	Post     Post         `json:"post"`
+	Metadata PostMetadata `json:"metadata"`
+}
+
+type Comment struct {
+	Id               int64     `json:"id"`
+	PostId           int64     `json:"post_id"`
+	SubComments      []Comment `json:"sub_comments"`
+	Body             string    `json:"body"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	NumOfSubComments int       `json:"num_of_sub_comments"`
+	ParentId         int64     `json:"parent_id"`
+	User             User      `json:"user"`
+	Deleted          bool      `json:"deleted"`
+	EditInfo
+}
+
+// fills in the attachments of every post in posts, baseURL is where the
+// media bucket is served from
+func LoadMedia(ctx context.Context, db *sql.DB, baseURL string, posts []PostData) error {
+	postIds := make([]int64, len(posts))
+	for i := range posts {
+		postIds[i] = posts[i].Post.Id
+	}
+
+	attachments, err := media.LoadForPosts(ctx, db, baseURL, postIds)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].Post.Media = media.OrEmpty(attachments[posts[i].Post.Id])
+	}
+
+	return nil
+}
@@ -0,0 +1,7 @@
+package data
+
+type User struct {
+	Id             int64  `json:"id"`
+	ProfilePicture string `json:"profile_picture"`
+	Username       string `json:"username"`
+}
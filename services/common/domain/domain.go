@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// bump when a payload changes in a way older consumers can not read,
+// adding optional fields does not need a new version
+const VERSION = 1
+
+var (
+	ErrUnknownEventType   = errors.New("unknown event type")
+	ErrUnsupportedVersion = errors.New("unsupported event version")
+	ErrMissingEventId     = errors.New("event is missing an id")
+)
+
+// every event published on the bus is wrapped in an Envelope, the payload is
+// kept raw so consumers can route on Type before decoding it
+type Envelope struct {
+	Id         string          `json:"id"`
+	Version    int             `json:"version"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+type Payload interface {
+	EventType() string
+}
+
+func newEventId() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func NewEnvelope(payload Payload, occurredAt time.Time) (*Envelope, error) {
+	if _, ok := registry[payload.EventType()]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, payload.EventType())
+	}
+
+	id, err := newEventId()
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		Id:         id,
+		Version:    VERSION,
+		Type:       payload.EventType(),
+		OccurredAt: occurredAt.UTC(),
+		Payload:    js,
+	}, nil
+}
+
+// parses an event bus detail into an envelope, the payload is left for Decode
+func Parse(detail []byte) (*Envelope, error) {
+	var env Envelope
+	err := json.Unmarshal(detail, &env)
+	if err != nil {
+		return nil, err
+	}
+
+	if env.Id == "" {
+		return nil, ErrMissingEventId
+	}
+
+	if env.Version < 1 || env.Version > VERSION {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, env.Version)
+	}
+
+	if _, ok := registry[env.Type]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, env.Type)
+	}
+
+	return &env, nil
+}
+
+func (e *Envelope) Decode() (Payload, error) {
+	newPayload, ok := registry[e.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, e.Type)
+	}
+
+	payload := newPayload()
+	err := json.Unmarshal(e.Payload, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,210 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var occurredAt = time.Date(2024, 2, 10, 12, 30, 0, 0, time.UTC)
+
+func samplePayloads() []Payload {
+	return []Payload{
+		&PostAdded{PostId: 1, UserId: 2, Username: "bob", CreatedAt: occurredAt},
+		&CommentAdded{
+			PostId:              1,
+			CommentId:           2,
+			PostUserId:          3,
+			CommentUserId:       4,
+			CommentUserUsername: "bob",
+			CommentCreatedAt:    occurredAt,
+			CommentBodyPreview:  "hello",
+		},
+		&SubCommentAdded{
+			PostId:                   1,
+			ParentCommentId:          2,
+			ChildCommentId:           3,
+			ParentCommentUserId:      4,
+			ChildCommentUserId:       5,
+			ChildCommentUserUsername: "bob",
+			ChildCommentCreatedAt:    occurredAt,
+			ChildCommentBodyPreview:  "hello",
+		},
+		&PostLiked{PostId: 1, PostUserId: 2, PostLikeUserId: 3, TotalLikes: 4, LikedAt: occurredAt},
+		&CommentLiked{PostId: 5, CommentId: 1, CommentUserId: 2, CommentLikeUserId: 3, TotalLikes: 4, LikedAt: occurredAt},
+		&PostUnliked{PostId: 1, PostUserId: 2, PostLikeUserId: 3, TotalLikes: 4, UnlikedAt: occurredAt},
+		&CommentUnliked{PostId: 5, CommentId: 1, CommentUserId: 2, CommentLikeUserId: 3, TotalLikes: 4, UnlikedAt: occurredAt},
+		&PostUpdated{
+			PostId:          1,
+			PostUserId:      2,
+			EditorUserId:    2,
+			Version:         3,
+			PostBodyPreview: "hello",
+			UpdatedAt:       occurredAt,
+		},
+		&PostDeleted{PostId: 1, PostUserId: 2, DeletedByUserId: 3, DeletedAt: occurredAt},
+		&CommentUpdated{
+			PostId:             1,
+			CommentId:          2,
+			PostUserId:         3,
+			CommentUserId:      4,
+			EditorUserId:       4,
+			Version:            2,
+			CommentBodyPreview: "hello",
+			UpdatedAt:          occurredAt,
+		},
+		&CommentDeleted{
+			PostId:          1,
+			CommentId:       2,
+			PostUserId:      3,
+			CommentUserId:   4,
+			DeletedByUserId: 5,
+			DeletedAt:       occurredAt,
+		},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	payloads := samplePayloads()
+	if len(payloads) != len(registry) {
+		t.Fatalf("expected a sample payload for all %d event types, got %d", len(registry), len(payloads))
+	}
+
+	for _, payload := range payloads {
+		t.Run(payload.EventType(), func(t *testing.T) {
+			env, err := NewEnvelope(payload, occurredAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			detail, err := json.Marshal(env)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parsed, err := Parse(detail)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if parsed.Id != env.Id || parsed.Version != VERSION || parsed.Type != payload.EventType() {
+				t.Fatalf("envelope did not survive round trip: %+v", parsed)
+			}
+
+			if !parsed.OccurredAt.Equal(occurredAt) {
+				t.Fatalf("expected occurred_at %s, got %s", occurredAt, parsed.OccurredAt)
+			}
+
+			decoded, err := parsed.Decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(decoded, payload) {
+				t.Fatalf("expected %+v, got %+v", payload, decoded)
+			}
+		})
+	}
+}
+
+// the fixtures are what consumers in the wild are reading, if a payload field
+// is renamed or removed the re-encoded payload no longer matches and this fails
+func TestFixturesCompatibility(t *testing.T) {
+	for _, eventType := range EventTypes() {
+		t.Run(eventType, func(t *testing.T) {
+			path := filepath.Join("testdata", fmt.Sprintf("%s.v%d.json", eventType, VERSION))
+			fixture, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("missing fixture for %s: %s", eventType, err.Error())
+			}
+
+			env, err := Parse(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if env.Type != eventType {
+				t.Fatalf("fixture %s has type %s", path, env.Type)
+			}
+
+			payload := registry[eventType]()
+			dec := json.NewDecoder(bytes.NewReader(env.Payload))
+			dec.DisallowUnknownFields()
+			err = dec.Decode(payload)
+			if err != nil {
+				t.Fatalf("fixture payload does not match schema: %s", err.Error())
+			}
+
+			reencoded, err := json.Marshal(payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var want, got map[string]any
+			json.Unmarshal(env.Payload, &want)
+			json.Unmarshal(reencoded, &got)
+
+			if !reflect.DeepEqual(want, got) {
+				t.Fatalf("schema drifted from fixture\nwant: %v\ngot:  %v", want, got)
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalidEnvelopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		detail string
+		err    error
+	}{
+		{
+			name:   "unknown type",
+			detail: `{"id": "1", "version": 1, "type": "Nope", "payload": {}}`,
+			err:    ErrUnknownEventType,
+		},
+		{
+			name:   "future version",
+			detail: `{"id": "1", "version": 99, "type": "PostAdded", "payload": {}}`,
+			err:    ErrUnsupportedVersion,
+		},
+		{
+			name:   "missing id",
+			detail: `{"version": 1, "type": "PostAdded", "payload": {}}`,
+			err:    ErrMissingEventId,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.detail))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestBodyPreview(t *testing.T) {
+	short := "hello"
+	if got := BodyPreview(short); got != short {
+		t.Fatalf("expected %q, got %q", short, got)
+	}
+
+	long := strings.Repeat("a", BODY_PREVIEW_MAX-1) + "é and more"
+	got := BodyPreview(long)
+	if !utf8.ValidString(got) {
+		t.Fatalf("preview split a character: %q", got)
+	}
+
+	if got != strings.Repeat("a", BODY_PREVIEW_MAX-1) {
+		t.Fatalf("expected the preview to stop before the split character, got %q", got)
+	}
+}
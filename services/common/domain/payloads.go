@@ -0,0 +1,188 @@
+package domain
+
+import (
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	POST_ADDED_EVENT        = "PostAdded"
+	COMMENT_ADDED_EVENT     = "CommentAdded"
+	SUB_COMMENT_ADDED_EVENT = "SubCommentAdded"
+	POST_LIKE_EVENT         = "PostLike"
+	COMMENT_LIKE_EVENT      = "CommentLike"
+	POST_UNLIKE_EVENT       = "PostUnlike"
+	COMMENT_UNLIKE_EVENT    = "CommentUnlike"
+	POST_UPDATED_EVENT      = "PostUpdated"
+	POST_DELETED_EVENT      = "PostDeleted"
+	COMMENT_UPDATED_EVENT   = "CommentUpdated"
+	COMMENT_DELETED_EVENT   = "CommentDeleted"
+)
+
+var registry = map[string]func() Payload{
+	POST_ADDED_EVENT:        func() Payload { return &PostAdded{} },
+	COMMENT_ADDED_EVENT:     func() Payload { return &CommentAdded{} },
+	SUB_COMMENT_ADDED_EVENT: func() Payload { return &SubCommentAdded{} },
+	POST_LIKE_EVENT:         func() Payload { return &PostLiked{} },
+	COMMENT_LIKE_EVENT:      func() Payload { return &CommentLiked{} },
+	POST_UNLIKE_EVENT:       func() Payload { return &PostUnliked{} },
+	COMMENT_UNLIKE_EVENT:    func() Payload { return &CommentUnliked{} },
+	POST_UPDATED_EVENT:      func() Payload { return &PostUpdated{} },
+	POST_DELETED_EVENT:      func() Payload { return &PostDeleted{} },
+	COMMENT_UPDATED_EVENT:   func() Payload { return &CommentUpdated{} },
+	COMMENT_DELETED_EVENT:   func() Payload { return &CommentDeleted{} },
+}
+
+// every event type the schema knows about
+func EventTypes() []string {
+	types := make([]string, 0, len(registry))
+	for t := range registry {
+		types = append(types, t)
+	}
+
+	return types
+}
+
+type PostAdded struct {
+	PostId    int64     `json:"post_id"`
+	UserId    int64     `json:"user_id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (PostAdded) EventType() string { return POST_ADDED_EVENT }
+
+// ParentCommentId is set when the comment is a reply, which also adds a
+// SubCommentAdded for the parents author
+type CommentAdded struct {
+	PostId              int64     `json:"post_id"`
+	CommentId           int64     `json:"comment_id"`
+	ParentCommentId     int64     `json:"parent_comment_id,omitempty"`
+	PostUserId          int64     `json:"post_user_id"`
+	CommentUserId       int64     `json:"comment_user_id"`
+	CommentUserUsername string    `json:"comment_user_username"`
+	CommentCreatedAt    time.Time `json:"comment_created_at"`
+	CommentBodyPreview  string    `json:"comment_body_preview"`
+}
+
+func (CommentAdded) EventType() string { return COMMENT_ADDED_EVENT }
+
+type SubCommentAdded struct {
+	PostId                   int64     `json:"post_id"`
+	ParentCommentId          int64     `json:"parent_comment_id"`
+	ChildCommentId           int64     `json:"child_comment_id"`
+	ParentCommentUserId      int64     `json:"parent_comment_user_id"`
+	ChildCommentUserId       int64     `json:"child_comment_user_id"`
+	ChildCommentUserUsername string    `json:"child_comment_user_username"`
+	ChildCommentCreatedAt    time.Time `json:"child_comment_created_at"`
+	ChildCommentBodyPreview  string    `json:"child_comment_body_preview"`
+}
+
+func (SubCommentAdded) EventType() string { return SUB_COMMENT_ADDED_EVENT }
+
+// TotalLikes is missing from events published before live like counts
+type PostLiked struct {
+	PostId         int64     `json:"post_id"`
+	PostUserId     int64     `json:"post_user_id"`
+	PostLikeUserId int64     `json:"post_like_user_id"`
+	TotalLikes     int64     `json:"total_likes,omitempty"`
+	LikedAt        time.Time `json:"liked_at"`
+}
+
+func (PostLiked) EventType() string { return POST_LIKE_EVENT }
+
+// PostId and TotalLikes are missing from events published before live like
+// counts, consumers have to treat 0 as unknown
+type CommentLiked struct {
+	PostId            int64     `json:"post_id,omitempty"`
+	CommentId         int64     `json:"comment_id"`
+	CommentUserId     int64     `json:"comment_user_id"`
+	CommentLikeUserId int64     `json:"comment_like_user_id"`
+	TotalLikes        int64     `json:"total_likes,omitempty"`
+	LikedAt           time.Time `json:"liked_at"`
+}
+
+func (CommentLiked) EventType() string { return COMMENT_LIKE_EVENT }
+
+type PostUnliked struct {
+	PostId         int64     `json:"post_id"`
+	PostUserId     int64     `json:"post_user_id"`
+	PostLikeUserId int64     `json:"post_like_user_id"`
+	TotalLikes     int64     `json:"total_likes"`
+	UnlikedAt      time.Time `json:"unliked_at"`
+}
+
+func (PostUnliked) EventType() string { return POST_UNLIKE_EVENT }
+
+type CommentUnliked struct {
+	PostId            int64     `json:"post_id,omitempty"`
+	CommentId         int64     `json:"comment_id"`
+	CommentUserId     int64     `json:"comment_user_id"`
+	CommentLikeUserId int64     `json:"comment_like_user_id"`
+	TotalLikes        int64     `json:"total_likes"`
+	UnlikedAt         time.Time `json:"unliked_at"`
+}
+
+func (CommentUnliked) EventType() string { return COMMENT_UNLIKE_EVENT }
+
+// EditorUserId differs from PostUserId when a moderator made the edit
+type PostUpdated struct {
+	PostId          int64     `json:"post_id"`
+	PostUserId      int64     `json:"post_user_id"`
+	EditorUserId    int64     `json:"editor_user_id"`
+	Version         int32     `json:"version"`
+	PostBodyPreview string    `json:"post_body_preview"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (PostUpdated) EventType() string { return POST_UPDATED_EVENT }
+
+type PostDeleted struct {
+	PostId          int64     `json:"post_id"`
+	PostUserId      int64     `json:"post_user_id"`
+	DeletedByUserId int64     `json:"deleted_by_user_id"`
+	DeletedAt       time.Time `json:"deleted_at"`
+}
+
+func (PostDeleted) EventType() string { return POST_DELETED_EVENT }
+
+type CommentUpdated struct {
+	PostId             int64     `json:"post_id"`
+	CommentId          int64     `json:"comment_id"`
+	PostUserId         int64     `json:"post_user_id"`
+	CommentUserId      int64     `json:"comment_user_id"`
+	EditorUserId       int64     `json:"editor_user_id"`
+	Version            int32     `json:"version"`
+	CommentBodyPreview string    `json:"comment_body_preview"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+func (CommentUpdated) EventType() string { return COMMENT_UPDATED_EVENT }
+
+type CommentDeleted struct {
+	PostId          int64     `json:"post_id"`
+	CommentId       int64     `json:"comment_id"`
+	PostUserId      int64     `json:"post_user_id"`
+	CommentUserId   int64     `json:"comment_user_id"`
+	DeletedByUserId int64     `json:"deleted_by_user_id"`
+	DeletedAt       time.Time `json:"deleted_at"`
+}
+
+func (CommentDeleted) EventType() string { return COMMENT_DELETED_EVENT }
+
+const BODY_PREVIEW_MAX = 100
+
+// the first BODY_PREVIEW_MAX bytes of a body without splitting a character,
+// events carry a preview rather than the whole body
+func BodyPreview(body string) string {
+	if len(body) <= BODY_PREVIEW_MAX {
+		return body
+	}
+
+	cut := BODY_PREVIEW_MAX
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+
+	return body[:cut]
+}
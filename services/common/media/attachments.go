@@ -0,0 +1,124 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// the full size variant written by the image processing lambda
+const ORIGINAL_VARIANT = "original"
+
+// satisfied by both *sql.DB and *sql.Tx so attachments can be read back in
+// the transaction that attached them
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// only processed variants are ever served, raw uploads keep the metadata the
+// client sent. URL is the cleaned full size copy and stays empty, like
+// Variants, until the upload is processed. Key is never sent to clients
+type Media struct {
+	Key         string             `json:"-"`
+	URL         string             `json:"url"`
+	ContentType string             `json:"content_type"`
+	Width       int                `json:"width"`
+	Height      int                `json:"height"`
+	Blurhash    string             `json:"blurhash"`
+	Variants    map[string]Variant `json:"variants"`
+}
+
+type Variant struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
+
+// attachments of every post in postIds keyed by post id, in the order they
+// were attached. baseURL is where the bucket is served from
+func LoadForPosts(ctx context.Context, db Querier, baseURL string, postIds []int64) (map[int64][]Media, error) {
+	byPost := make(map[int64][]Media, len(postIds))
+	if len(postIds) == 0 {
+		return byPost, nil
+	}
+
+	query := `
+	SELECT post_media.post_id, post_media.key, post_media.content_type,
+	coalesce(images.width, 0), coalesce(images.height, 0), coalesce(images.blurhash, ''),
+	image_variants.name, image_variants.key, image_variants.content_type,
+	image_variants.width, image_variants.height
+	FROM post_media
+	LEFT JOIN images ON images.source_key = post_media.key
+	LEFT JOIN image_variants ON image_variants.source_key = post_media.key
+	WHERE post_media.post_id = ANY($1)
+	ORDER BY post_media.post_id, post_media.position, image_variants.name
+	`
+
+	rows, err := db.QueryContext(ctx, query, pq.Array(postIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// one row per variant, rows of the same upload are next to each other
+	var current *Media
+	for rows.Next() {
+		var postId int64
+		var item Media
+		var name, key, contentType sql.NullString
+		var width, height sql.NullInt32
+		err = rows.Scan(
+			&postId,
+			&item.Key,
+			&item.ContentType,
+			&item.Width,
+			&item.Height,
+			&item.Blurhash,
+			&name,
+			&key,
+			&contentType,
+			&width,
+			&height,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if current == nil || current.Key != item.Key {
+			item.Variants = map[string]Variant{}
+			byPost[postId] = append(byPost[postId], item)
+			current = &byPost[postId][len(byPost[postId])-1]
+		}
+
+		if !name.Valid {
+			continue
+		}
+
+		variant := Variant{
+			URL:         URL(baseURL, key.String),
+			ContentType: contentType.String,
+			Width:       int(width.Int32),
+			Height:      int(height.Int32),
+		}
+		current.Variants[name.String] = variant
+		if name.String == ORIGINAL_VARIANT {
+			current.URL = variant.URL
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return byPost, nil
+}
+
+func OrEmpty(items []Media) []Media {
+	if items == nil {
+		return []Media{}
+	}
+
+	return items
+}
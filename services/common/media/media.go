@@ -0,0 +1,92 @@
+package media
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	MAX_SIZE     = 10 << 20
+	MAX_PER_POST = 4
+
+	// uploads land under KEY_PREFIX/{user id}/ so the key alone says who
+	// may attach it
+	KEY_PREFIX = "posts"
+
+	// everything the image processing lambda writes goes under
+	// VARIANT_PREFIX, it is the only part of the bucket that is served
+	VARIANT_PREFIX = "variants"
+
+	// an upload has to be attached within this long of its url being handed
+	// out, reservations left unattached past it are purged
+	RESERVATION_TTL = 24 * time.Hour
+)
+
+var (
+	ErrUnsupportedType = errors.New("content type must be one of image/jpeg, image/png, image/webp or image/gif")
+	ErrInvalidSize     = fmt.Errorf("size must be between 1 and %d bytes", MAX_SIZE)
+	ErrTooMany         = fmt.Errorf("a post can have at most %d attachments", MAX_PER_POST)
+	ErrInvalidKeys     = errors.New("media keys must be unused uploads of the author")
+	ErrNotUploaded     = errors.New("media has to be uploaded before it is attached")
+)
+
+var extensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/webp": "webp",
+	"image/gif":  "gif",
+}
+
+// checks what a client says it is about to upload, the presigned url is
+// signed with both so S3 rejects anything else
+func ValidateUpload(contentType string, size int64) error {
+	if _, ok := extensions[contentType]; !ok {
+		return ErrUnsupportedType
+	}
+
+	if size < 1 || size > MAX_SIZE {
+		return ErrInvalidSize
+	}
+
+	return nil
+}
+
+// a fresh object key for an upload by userId, contentType must have passed
+// ValidateUpload
+func NewKey(userId int64, contentType string) (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%d/%s.%s", KEY_PREFIX, userId, hex.EncodeToString(b), extensions[contentType]), nil
+}
+
+// checks keys sent with a new post before they are looked up, the lookup
+// itself makes sure they belong to the author and are not attached yet
+func ValidateKeys(keys []string) error {
+	if len(keys) > MAX_PER_POST {
+		return ErrTooMany
+	}
+
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key == "" || seen[key] || !strings.HasPrefix(key, KEY_PREFIX+"/") {
+			return ErrInvalidKeys
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
+
+// public url of a processed variant, baseURL is where VARIANT_PREFIX of the
+// bucket is served from
+func URL(baseURL, variantKey string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/" + strings.TrimPrefix(variantKey, VARIANT_PREFIX+"/")
+}
@@ -0,0 +1,89 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestValidateUpload(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		size        int64
+		err         error
+	}{
+		{"jpeg", "image/jpeg", 1024, nil},
+		{"max size", "image/png", MAX_SIZE, nil},
+		{"not an image", "application/pdf", 1024, ErrUnsupportedType},
+		{"svg", "image/svg+xml", 1024, ErrUnsupportedType},
+		{"empty", "image/webp", 0, ErrInvalidSize},
+		{"too big", "image/gif", MAX_SIZE + 1, ErrInvalidSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateUpload(tt.contentType, tt.size)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestNewKey(t *testing.T) {
+	key, err := NewKey(42, "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(key, "posts/42/") || !strings.HasSuffix(key, ".png") {
+		t.Fatalf("unexpected key %s", key)
+	}
+
+	other, err := NewKey(42, "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key == other {
+		t.Fatal("expected keys to be unique")
+	}
+}
+
+func TestValidateKeys(t *testing.T) {
+	tooMany := make([]string, MAX_PER_POST+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("posts/1/%d.png", i)
+	}
+
+	tests := []struct {
+		name string
+		keys []string
+		err  error
+	}{
+		{"none", nil, nil},
+		{"valid", []string{"posts/1/a.png", "posts/1/b.jpg"}, nil},
+		{"too many", tooMany, ErrTooMany},
+		{"duplicate", []string{"posts/1/a.png", "posts/1/a.png"}, ErrInvalidKeys},
+		{"outside prefix", []string{"other/a.png"}, ErrInvalidKeys},
+		{"empty", []string{""}, ErrInvalidKeys},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateKeys(tt.keys)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestURL(t *testing.T) {
+	got := URL("https://media.example.com/", "variants/posts/1/a/thumb.jpg")
+	if got != "https://media.example.com/posts/1/a/thumb.jpg" {
+		t.Fatalf("unexpected url %s", got)
+	}
+}
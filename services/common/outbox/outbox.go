@@ -0,0 +1,47 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"events/common/domain"
+)
+
+// events of one aggregate are relayed in the order they were written. every
+// comment event, likes included, is written against the post the comment is
+// on, otherwise an edit, delete or like could overtake the add it follows
+const (
+	AGGREGATE_POST = "post"
+	AGGREGATE_USER = "user"
+)
+
+// writes the event into outbox_events using the callers transaction so the
+// event is only ever stored if the change it describes is committed
+func Enqueue(
+	ctx context.Context,
+	tx *sql.Tx,
+	aggregateType string,
+	aggregateId int64,
+	payload domain.Payload,
+	occurredAt time.Time,
+) error {
+	env, err := domain.NewEnvelope(payload, occurredAt)
+	if err != nil {
+		return err
+	}
+
+	js, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	insert into outbox_events (event_id, aggregate_type, aggregate_id, event_type, payload)
+	values ($1, $2, $3, $4, $5)
+	`
+
+	_, err = tx.ExecContext(ctx, query, env.Id, aggregateType, aggregateId, env.Type, js)
+	return err
+}
@@ -0,0 +1,149 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	baseBackoff = 5 * time.Second
+	maxBackoff  = 15 * time.Minute
+)
+
+type Sender interface {
+	PublishRaw(detail []byte) error
+}
+
+type Relay struct {
+	DB        *sql.DB
+	Sender    Sender
+	BatchSize int
+}
+
+type pendingEvent struct {
+	id       int64
+	eventId  string
+	payload  []byte
+	attempts int
+}
+
+// exponential backoff capped at maxBackoff, failed events are retried
+// forever rather than dropped so nothing is ever silently lost
+func Backoff(attempts int) time.Duration {
+	backoff := baseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= maxBackoff {
+			return maxBackoff
+		}
+	}
+
+	return backoff
+}
+
+// sends one batch of due events and returns how many were delivered and how
+// many failed. Only the oldest undelivered event of each aggregate is picked
+// so events for the same post or comment always go out in the order they
+// were written, rows are locked with skip locked so relays can run side by side
+func (r *Relay) DrainBatch(ctx context.Context) (int, int, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+	select o.id, o.event_id, o.payload, o.attempts from outbox_events o
+	where o.delivered_at is null and o.next_attempt_at <= now()
+	and not exists (
+		select 1 from outbox_events earlier
+		where earlier.aggregate_type = o.aggregate_type
+		and earlier.aggregate_id = o.aggregate_id
+		and earlier.delivered_at is null
+		and earlier.id < o.id
+	)
+	order by o.id
+	limit $1
+	for update skip locked
+	`
+
+	rows, err := tx.QueryContext(ctx, query, r.BatchSize)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var events []pendingEvent
+	for rows.Next() {
+		var e pendingEvent
+		err := rows.Scan(&e.id, &e.eventId, &e.payload, &e.attempts)
+		if err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		events = append(events, e)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	delivered, failed := 0, 0
+	for _, e := range events {
+		err := r.Sender.PublishRaw(e.payload)
+		if err != nil {
+			failed++
+			fmt.Printf("Could not relay event %s: %s\n", e.eventId, err.Error())
+
+			_, err = tx.ExecContext(ctx, `
+			update outbox_events set attempts = attempts + 1, last_error = $2,
+			next_attempt_at = now() + $3 * interval '1 millisecond'
+			where id = $1
+			`, e.id, err.Error(), Backoff(e.attempts+1).Milliseconds())
+			if err != nil {
+				return 0, 0, err
+			}
+			continue
+		}
+
+		delivered++
+		_, err = tx.ExecContext(ctx, `
+		update outbox_events set delivered_at = now(), attempts = attempts + 1, last_error = null
+		where id = $1
+		`, e.id)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return delivered, failed, nil
+}
+
+// keeps draining batches until nothing is due or ctx is done
+func (r *Relay) Drain(ctx context.Context) (int, int, error) {
+	totalDelivered, totalFailed := 0, 0
+	for {
+		if ctx.Err() != nil {
+			return totalDelivered, totalFailed, nil
+		}
+
+		delivered, failed, err := r.DrainBatch(ctx)
+		if err != nil {
+			return totalDelivered, totalFailed, err
+		}
+
+		totalDelivered += delivered
+		totalFailed += failed
+
+		if delivered == 0 {
+			return totalDelivered, totalFailed, nil
+		}
+	}
+}
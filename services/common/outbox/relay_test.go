@@ -0,0 +1,25 @@
+package outbox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 5 * time.Second},
+		{attempts: 2, want: 10 * time.Second},
+		{attempts: 4, want: 40 * time.Second},
+		{attempts: 50, want: maxBackoff},
+	}
+
+	for _, tt := range tests {
+		got := Backoff(tt.attempts)
+		if got != tt.want {
+			t.Fatalf("attempts %d: expected %s, got %s", tt.attempts, tt.want, got)
+		}
+	}
+}
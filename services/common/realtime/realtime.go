@@ -0,0 +1,114 @@
+package realtime
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"events/common/domain"
+)
+
+const (
+	// the post itself, its body and like count
+	TOPIC_POST = "post"
+	// everything under a post, new and changed comments and their like counts
+	TOPIC_COMMENTS = "comments"
+
+	ACTION_SUBSCRIBE   = "subscribe"
+	ACTION_UNSUBSCRIBE = "unsubscribe"
+
+	// a client has one page open per connection, a post and its comments is
+	// two topics so this leaves room for a feed of open posts
+	MAX_SUBSCRIPTIONS = 20
+)
+
+var (
+	ErrInvalidTopic  = errors.New(`topic must be "post:{id}" or "comments:{id}"`)
+	ErrInvalidAction = fmt.Errorf("action must be %q or %q", ACTION_SUBSCRIBE, ACTION_UNSUBSCRIBE)
+)
+
+// Message is what clients send on the websocket
+type Message struct {
+	Action string `json:"action"`
+	Topic  string `json:"topic"`
+}
+
+func (m Message) Validate() error {
+	if m.Action != ACTION_SUBSCRIBE && m.Action != ACTION_UNSUBSCRIBE {
+		return ErrInvalidAction
+	}
+
+	_, _, err := ParseTopic(m.Topic)
+	return err
+}
+
+func PostTopic(postId int64) string {
+	return fmt.Sprintf("%s:%d", TOPIC_POST, postId)
+}
+
+func CommentsTopic(postId int64) string {
+	return fmt.Sprintf("%s:%d", TOPIC_COMMENTS, postId)
+}
+
+func ParseTopic(topic string) (kind string, id int64, err error) {
+	kind, rawId, ok := strings.Cut(topic, ":")
+	if !ok || (kind != TOPIC_POST && kind != TOPIC_COMMENTS) {
+		return "", 0, ErrInvalidTopic
+	}
+
+	id, err = strconv.ParseInt(rawId, 10, 64)
+	if err != nil || id < 1 || strconv.FormatInt(id, 10) != rawId {
+		return "", 0, ErrInvalidTopic
+	}
+
+	return kind, id, nil
+}
+
+// the topics whose subscribers see an event, nil for events nobody views
+// live
+func Topics(payload domain.Payload) []string {
+	switch p := payload.(type) {
+	// a reply also raises SubCommentAdded, which carries the parent it goes
+	// under, so only that one reaches the topic
+	case *domain.CommentAdded:
+		if p.ParentCommentId == 0 {
+			return []string{CommentsTopic(p.PostId)}
+		}
+
+	case *domain.SubCommentAdded:
+		return []string{CommentsTopic(p.PostId)}
+
+	case *domain.PostLiked:
+		return []string{PostTopic(p.PostId)}
+
+	case *domain.PostUnliked:
+		return []string{PostTopic(p.PostId)}
+
+	case *domain.PostUpdated:
+		return []string{PostTopic(p.PostId)}
+
+	// the comments go with the post
+	case *domain.PostDeleted:
+		return []string{PostTopic(p.PostId), CommentsTopic(p.PostId)}
+
+	case *domain.CommentUpdated:
+		return []string{CommentsTopic(p.PostId)}
+
+	case *domain.CommentDeleted:
+		return []string{CommentsTopic(p.PostId)}
+
+	// events published before they carried the post id can not be routed
+	case *domain.CommentLiked:
+		if p.PostId != 0 {
+			return []string{CommentsTopic(p.PostId)}
+		}
+
+	case *domain.CommentUnliked:
+		if p.PostId != 0 {
+			return []string{CommentsTopic(p.PostId)}
+		}
+	}
+
+	return nil
+}
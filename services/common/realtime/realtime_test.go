@@ -0,0 +1,82 @@
+package realtime
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"events/common/domain"
+)
+
+func TestParseTopic(t *testing.T) {
+	tests := []struct {
+		topic string
+		kind  string
+		id    int64
+		err   error
+	}{
+		{"post:1", TOPIC_POST, 1, nil},
+		{"comments:42", TOPIC_COMMENTS, 42, nil},
+		{"post:0", "", 0, ErrInvalidTopic},
+		{"post:-1", "", 0, ErrInvalidTopic},
+		{"post:01", "", 0, ErrInvalidTopic},
+		{"post:+1", "", 0, ErrInvalidTopic},
+		{"post:1:2", "", 0, ErrInvalidTopic},
+		{"user:1", "", 0, ErrInvalidTopic},
+		{"post", "", 0, ErrInvalidTopic},
+		{"", "", 0, ErrInvalidTopic},
+	}
+
+	for _, tt := range tests {
+		kind, id, err := ParseTopic(tt.topic)
+		if !errors.Is(err, tt.err) || kind != tt.kind || id != tt.id {
+			t.Errorf("ParseTopic(%q) = %q, %d, %v, want %q, %d, %v", tt.topic, kind, id, err, tt.kind, tt.id, tt.err)
+		}
+	}
+}
+
+func TestMessageValidate(t *testing.T) {
+	tests := []struct {
+		msg Message
+		err error
+	}{
+		{Message{Action: ACTION_SUBSCRIBE, Topic: PostTopic(1)}, nil},
+		{Message{Action: ACTION_UNSUBSCRIBE, Topic: CommentsTopic(1)}, nil},
+		{Message{Action: "publish", Topic: PostTopic(1)}, ErrInvalidAction},
+		{Message{Action: ACTION_SUBSCRIBE, Topic: "post:x"}, ErrInvalidTopic},
+	}
+
+	for _, tt := range tests {
+		if err := tt.msg.Validate(); !errors.Is(err, tt.err) {
+			t.Errorf("%+v.Validate() = %v, want %v", tt.msg, err, tt.err)
+		}
+	}
+}
+
+func TestTopics(t *testing.T) {
+	tests := []struct {
+		payload domain.Payload
+		want    []string
+	}{
+		{&domain.PostAdded{PostId: 1}, nil},
+		{&domain.CommentAdded{PostId: 1}, []string{"comments:1"}},
+		{&domain.CommentAdded{PostId: 1, ParentCommentId: 2}, nil},
+		{&domain.SubCommentAdded{PostId: 1}, []string{"comments:1"}},
+		{&domain.PostLiked{PostId: 1}, []string{"post:1"}},
+		{&domain.PostUnliked{PostId: 1}, []string{"post:1"}},
+		{&domain.PostUpdated{PostId: 1}, []string{"post:1"}},
+		{&domain.PostDeleted{PostId: 1}, []string{"post:1", "comments:1"}},
+		{&domain.CommentUpdated{PostId: 1}, []string{"comments:1"}},
+		{&domain.CommentDeleted{PostId: 1}, []string{"comments:1"}},
+		{&domain.CommentLiked{PostId: 1}, []string{"comments:1"}},
+		{&domain.CommentUnliked{PostId: 1}, []string{"comments:1"}},
+		{&domain.CommentLiked{CommentId: 2}, nil},
+	}
+
+	for _, tt := range tests {
+		got := Topics(tt.payload)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("Topics(%s) = %v, want %v", tt.payload.EventType(), got, tt.want)
+		}
+	}
+}
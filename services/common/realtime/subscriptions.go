@@ -0,0 +1,169 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+const (
+	// the subscriptions of a connection are found through this index on
+	// connectionId, the table itself is keyed by topic
+	CONNECTION_INDEX = "byConnectionId"
+
+	// API Gateway closes websockets after two hours, a subscription the
+	// $disconnect route missed expires with it
+	SUBSCRIPTION_TTL = 2 * time.Hour
+)
+
+var ErrTooManySubscriptions = fmt.Errorf("a connection can have at most %d subscriptions", MAX_SUBSCRIPTIONS)
+
+type Subscription struct {
+	Topic        string `dynamodbav:"topic"`
+	ConnectionId string `dynamodbav:"connectionId"`
+	UserId       int64  `dynamodbav:"userId"`
+}
+
+type SubscriptionModel struct {
+	DB    dynamodbiface.DynamoDBAPI
+	Table string
+}
+
+// subscribing to a topic the connection already has only refreshes it
+func (m *SubscriptionModel) Subscribe(ctx context.Context, sub Subscription) error {
+	topics, err := m.topicsOf(ctx, sub.ConnectionId)
+	if err != nil {
+		return err
+	}
+
+	if len(topics) >= MAX_SUBSCRIPTIONS && !topics[sub.Topic] {
+		return ErrTooManySubscriptions
+	}
+
+	_, err = m.DB.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(m.Table),
+		Item: map[string]*dynamodb.AttributeValue{
+			"topic":        {S: aws.String(sub.Topic)},
+			"connectionId": {S: aws.String(sub.ConnectionId)},
+			"userId":       {N: aws.String(strconv.FormatInt(sub.UserId, 10))},
+			"ttl":          {N: aws.String(strconv.FormatInt(time.Now().Add(SUBSCRIPTION_TTL).Unix(), 10))},
+		},
+	})
+
+	return err
+}
+
+func (m *SubscriptionModel) Unsubscribe(ctx context.Context, topic, connectionId string) error {
+	_, err := m.DB.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(m.Table),
+		Key:       key(topic, connectionId),
+	})
+
+	return err
+}
+
+// drops every subscription of a connection that closed or went away
+func (m *SubscriptionModel) DeleteConnection(ctx context.Context, connectionId string) error {
+	topics, err := m.topicsOf(ctx, connectionId)
+	if err != nil {
+		return err
+	}
+
+	for topic := range topics {
+		err = m.Unsubscribe(ctx, topic, connectionId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// subscribers of every topic, a connection subscribed to several of them is
+// in there once per topic
+func (m *SubscriptionModel) ForTopics(ctx context.Context, topics []string) ([]Subscription, error) {
+	subs := []Subscription{}
+	for _, topic := range topics {
+		input := &dynamodb.QueryInput{
+			TableName:              aws.String(m.Table),
+			KeyConditionExpression: aws.String("topic = :topic"),
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":topic": {S: aws.String(topic)},
+			},
+		}
+
+		err := m.query(ctx, input, func(items []map[string]*dynamodb.AttributeValue) error {
+			var page []Subscription
+			err := dynamodbattribute.UnmarshalListOfMaps(items, &page)
+			subs = append(subs, page...)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return subs, nil
+}
+
+func (m *SubscriptionModel) topicsOf(ctx context.Context, connectionId string) (map[string]bool, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(m.Table),
+		IndexName:              aws.String(CONNECTION_INDEX),
+		KeyConditionExpression: aws.String("connectionId = :connectionId"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":connectionId": {S: aws.String(connectionId)},
+		},
+	}
+
+	topics := map[string]bool{}
+	err := m.query(ctx, input, func(items []map[string]*dynamodb.AttributeValue) error {
+		for _, item := range items {
+			if item["topic"] == nil || item["topic"].S == nil {
+				return errors.New("subscription without a topic")
+			}
+			topics[*item["topic"].S] = true
+		}
+		return nil
+	})
+
+	return topics, err
+}
+
+// runs input to the last page, handing every page of items to fn
+func (m *SubscriptionModel) query(
+	ctx context.Context,
+	input *dynamodb.QueryInput,
+	fn func([]map[string]*dynamodb.AttributeValue) error,
+) error {
+	for {
+		out, err := m.DB.QueryWithContext(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		err = fn(out.Items)
+		if err != nil {
+			return err
+		}
+
+		if len(out.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+func key(topic, connectionId string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"topic":        {S: aws.String(topic)},
+		"connectionId": {S: aws.String(connectionId)},
+	}
+}
@@ -0,0 +1,151 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// runs against the DynamoDB of a local localstack, `make start` at the root
+// of the repo starts one
+func newTestSubscriptions(t *testing.T) *SubscriptionModel {
+	t.Helper()
+
+	endpoint := os.Getenv("LOCALSTACK_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "http://localhost:4566"
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		t.Fatalf("parsing LOCALSTACK_ENDPOINT: %v", err)
+	}
+
+	conn, err := net.DialTimeout("tcp", u.Host, time.Second)
+	if err != nil {
+		t.Skipf("localstack is not reachable at %s: %v", endpoint, err)
+	}
+	conn.Close()
+
+	sess := session.Must(session.NewSession())
+	db := dynamodb.New(sess, aws.NewConfig().
+		WithRegion("us-east-1").
+		WithEndpoint(endpoint).
+		WithCredentials(credentials.NewStaticCredentials("test", "test", "")),
+	)
+
+	// the same keys as the subscriptions table in the cdk stack
+	table := fmt.Sprintf("subscriptions-test-%d", time.Now().UnixNano())
+	_, err = db.CreateTable(&dynamodb.CreateTableInput{
+		TableName:   aws.String(table),
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("topic"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("connectionId"), AttributeType: aws.String("S")},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("topic"), KeyType: aws.String("HASH")},
+			{AttributeName: aws.String("connectionId"), KeyType: aws.String("RANGE")},
+		},
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndex{{
+			IndexName: aws.String(CONNECTION_INDEX),
+			KeySchema: []*dynamodb.KeySchemaElement{
+				{AttributeName: aws.String("connectionId"), KeyType: aws.String("HASH")},
+			},
+			Projection: &dynamodb.Projection{ProjectionType: aws.String(dynamodb.ProjectionTypeKeysOnly)},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("creating table: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.DeleteTable(&dynamodb.DeleteTableInput{TableName: aws.String(table)})
+	})
+
+	return &SubscriptionModel{DB: db, Table: table}
+}
+
+func connectionIds(subs []Subscription) []string {
+	ids := make([]string, len(subs))
+	for i, sub := range subs {
+		ids[i] = sub.ConnectionId
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestSubscriptions(t *testing.T) {
+	m := newTestSubscriptions(t)
+	ctx := context.Background()
+
+	for _, sub := range []Subscription{
+		{Topic: PostTopic(1), ConnectionId: "a", UserId: 1},
+		{Topic: CommentsTopic(1), ConnectionId: "a", UserId: 1},
+		{Topic: PostTopic(1), ConnectionId: "b", UserId: 2},
+		{Topic: PostTopic(2), ConnectionId: "c", UserId: 3},
+		// again, which is not a second subscription
+		{Topic: PostTopic(1), ConnectionId: "b", UserId: 2},
+	} {
+		if err := m.Subscribe(ctx, sub); err != nil {
+			t.Fatalf("subscribing %+v: %v", sub, err)
+		}
+	}
+
+	subs, err := m.ForTopics(ctx, []string{PostTopic(1)})
+	if err != nil {
+		t.Fatalf("listing subscribers: %v", err)
+	}
+	if got := connectionIds(subs); fmt.Sprint(got) != "[a b]" {
+		t.Fatalf("got subscribers %v, want [a b]", got)
+	}
+
+	if err = m.Unsubscribe(ctx, PostTopic(1), "b"); err != nil {
+		t.Fatalf("unsubscribing: %v", err)
+	}
+
+	if err = m.DeleteConnection(ctx, "a"); err != nil {
+		t.Fatalf("deleting connection: %v", err)
+	}
+
+	subs, err = m.ForTopics(ctx, []string{PostTopic(1), CommentsTopic(1), PostTopic(2)})
+	if err != nil {
+		t.Fatalf("listing subscribers: %v", err)
+	}
+	if got := connectionIds(subs); fmt.Sprint(got) != "[c]" {
+		t.Fatalf("got subscribers %v, want [c]", got)
+	}
+}
+
+func TestSubscriptionLimit(t *testing.T) {
+	m := newTestSubscriptions(t)
+	ctx := context.Background()
+
+	for id := int64(1); id <= MAX_SUBSCRIPTIONS; id++ {
+		if err := m.Subscribe(ctx, Subscription{Topic: PostTopic(id), ConnectionId: "a", UserId: 1}); err != nil {
+			t.Fatalf("subscribing to post %d: %v", id, err)
+		}
+	}
+
+	err := m.Subscribe(ctx, Subscription{Topic: PostTopic(MAX_SUBSCRIPTIONS + 1), ConnectionId: "a", UserId: 1})
+	if !errors.Is(err, ErrTooManySubscriptions) {
+		t.Fatalf("got %v, want ErrTooManySubscriptions", err)
+	}
+
+	// refreshing one it already has is fine
+	err = m.Subscribe(ctx, Subscription{Topic: PostTopic(1), ConnectionId: "a", UserId: 1})
+	if err != nil {
+		t.Fatalf("resubscribing: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package revisions
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"events/common/cursor"
+	"events/common/data"
+)
+
+// Table is where one kind of resource keeps the bodies it had before each
+// edit, Exists tells whether the resource itself can still be read
+type Table struct {
+	Name   string
+	Owner  string
+	Exists string
+}
+
+var (
+	Posts = Table{
+		Name:   "post_revisions",
+		Owner:  "post_id",
+		Exists: `SELECT EXISTS (SELECT 1 FROM posts WHERE id = $1)`,
+	}
+	// a deleted comment is only a tombstone, its earlier bodies go with it
+	Comments = Table{
+		Name:   "comment_revisions",
+		Owner:  "comment_id",
+		Exists: `SELECT EXISTS (SELECT 1 FROM comments WHERE id = $1 AND deleted_at IS NULL)`,
+	}
+)
+
+// InsertFrom is the statement writing the row in source (id, version, body)
+// as a revision edited by placeholder $editorArg. it goes in a CTE of the
+// update replacing the body so the history can't miss an edit
+func (t Table) InsertFrom(source string, editorArg int) string {
+	return fmt.Sprintf(
+		`insert into %s (%s, version, body, edited_by) select id, version, body, $%d from %s`,
+		t.Name,
+		t.Owner,
+		editorArg,
+		source,
+	)
+}
+
+// Revision is the body a post or comment had at Version, EditedBy is who
+// replaced it and CreatedAt when, the user is empty once their account is
+// gone
+type Revision struct {
+	Id        int64     `json:"id"`
+	Version   int32     `json:"version"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	EditedBy  data.User `json:"edited_by"`
+}
+
+type Model struct {
+	DB    *sql.DB
+	Table Table
+}
+
+// List pages the revisions of ownerId newest first
+func (m *Model) List(ownerId int64, filters cursor.Filters) ([]Revision, cursor.Metadata, error) {
+	scan := filters.ScanOrder(cursor.DESC)
+	query := fmt.Sprintf(`
+	SELECT revision.id, revision.version, revision.body, revision.created_at,
+	coalesce(users.id, 0), coalesce(users.username, ''), coalesce(users.profile_picture, '')
+	FROM %[1]s AS revision
+	LEFT JOIN users ON users.id = revision.edited_by
+	WHERE revision.%[2]s = $1
+	AND ($4 OR (revision.created_at, revision.id) %[3]s ($2, $3))
+	ORDER BY revision.created_at %[4]s, revision.id %[4]s
+	LIMIT $5
+	`, m.Table.Name, m.Table.Owner, filters.Compare(cursor.DESC), scan)
+
+	revisions := []Revision{}
+	metadata := cursor.Metadata{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var exists bool
+	err := m.DB.QueryRowContext(ctx, m.Table.Exists, ownerId).Scan(&exists)
+	if err != nil {
+		return revisions, metadata, err
+	}
+
+	if !exists {
+		return revisions, metadata, data.ErrRecordNotFound
+	}
+
+	createdAt, id, first := filters.Args()
+	rows, err := m.DB.QueryContext(ctx, query, ownerId, createdAt, id, first, filters.Limit())
+	if err != nil {
+		return revisions, metadata, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		revision := Revision{}
+		err = rows.Scan(
+			&revision.Id,
+			&revision.Version,
+			&revision.Body,
+			&revision.CreatedAt,
+			&revision.EditedBy.Id,
+			&revision.EditedBy.Username,
+			&revision.EditedBy.ProfilePicture,
+		)
+		if err != nil {
+			return revisions, metadata, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return revisions, metadata, err
+	}
+
+	revisions, metadata = cursor.Paginate(filters, revisions, func(r Revision) (time.Time, int64) {
+		return r.CreatedAt, r.Id
+	})
+
+	return revisions, metadata, nil
+}
@@ -0,0 +1,19 @@
+package revisions
+
+import "testing"
+
+func TestInsertFrom(t *testing.T) {
+	tests := []struct {
+		table Table
+		want  string
+	}{
+		{Posts, `insert into post_revisions (post_id, version, body, edited_by) select id, version, body, $4 from old`},
+		{Comments, `insert into comment_revisions (comment_id, version, body, edited_by) select id, version, body, $4 from old`},
+	}
+
+	for _, tt := range tests {
+		if got := tt.table.InsertFrom("old", 4); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.table.Name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package timeline
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// how many of a newly followed accounts posts get copied into the followers
+// timeline, older posts are only reachable from the accounts own page
+const BACKFILL_LIMIT = 50
+
+// satisfied by both *sql.DB and *sql.Tx so callers can fold timeline writes
+// into their own transaction
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// writes the post into the timeline of everyone following its author,
+// returns the number of timelines it landed in
+func FanOut(ctx context.Context, db Execer, authorId, postId int64, createdAt time.Time) (int64, error) {
+	query := `
+		insert into timelines (user_id, post_id, created_at)
+		select follower.userId, $2, $3
+		from friend_nodes author
+		join friend_edges edge on edge.next_node = author.id
+		join friend_nodes follower on follower.id = edge.previous_node
+		where author.userId = $1
+		on conflict do nothing
+	`
+
+	result, err := db.ExecContext(ctx, query, authorId, postId, createdAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// copies the latest posts of followedId into followerIds timeline
+func Backfill(ctx context.Context, db Execer, followerId, followedId int64, limit int) (int64, error) {
+	query := `
+		insert into timelines (user_id, post_id, created_at)
+		select $1, id, created_at
+		from posts
+		where user_id = $2
+		order by created_at desc
+		limit $3
+		on conflict do nothing
+	`
+
+	result, err := db.ExecContext(ctx, query, followerId, followedId, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// drops every post of followedId from followerIds timeline, used on unfollow
+func RemoveAccount(ctx context.Context, db Execer, followerId, followedId int64) (int64, error) {
+	query := `
+		delete from timelines t
+		using posts p
+		where t.post_id = p.id and t.user_id = $1 and p.user_id = $2
+	`
+
+	result, err := db.ExecContext(ctx, query, followerId, followedId)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// throws away userIds timeline and builds it again from everyone they
+// follow, limit posts per followed account
+func Rebuild(ctx context.Context, db *sql.DB, userId int64, limit int) (int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `delete from timelines where user_id = $1`, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		insert into timelines (user_id, post_id, created_at)
+		select $1, post.id, post.created_at
+		from friend_nodes me
+		join friend_edges edge on edge.previous_node = me.id
+		join friend_nodes followed on followed.id = edge.next_node
+		cross join lateral (
+			select id, created_at from posts
+			where user_id = followed.userId
+			order by created_at desc
+			limit $2
+		) post
+		where me.userId = $1
+		on conflict do nothing
+	`
+
+	result, err := tx.ExecContext(ctx, query, userId, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return inserted, tx.Commit()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"events/common/data"
+	"events/common/timeline"
+)
+
+// fills timelines that were never fanned out to: with -followed it copies one
+// accounts posts into the users timeline, without it the users whole timeline
+// is rebuilt from everyone they follow
+func main() {
+	var dsn string
+	var userId, followedId int64
+	var limit int
+	flag.StringVar(&dsn, "db-dsn", os.Getenv("DB_ADDRESS"), "postgres dsn")
+	flag.Int64Var(&userId, "user", 0, "id of the user whose timeline is backfilled")
+	flag.Int64Var(&followedId, "followed", 0, "only backfill posts from this account")
+	flag.IntVar(&limit, "limit", timeline.BACKFILL_LIMIT, "posts copied per followed account")
+	flag.Parse()
+
+	if userId < 1 {
+		fmt.Fprintln(os.Stderr, "-user is required")
+		os.Exit(2)
+	}
+
+	db, err := data.OpenDB(dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to db: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	var inserted int64
+	if followedId > 0 {
+		inserted, err = timeline.Backfill(ctx, db, userId, followedId, limit)
+	} else {
+		inserted, err = timeline.Rebuild(ctx, db, userId, limit)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to backfill timeline: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("added %d posts to the timeline of user %d\n", inserted, userId)
+}
@@ -0,0 +1,12 @@
+module fanout
+
+go 1.21.5
+
+require (
+	events/common v0.0.0
+	github.com/aws/aws-lambda-go v1.43.0
+)
+
+require github.com/lib/pq v1.10.9 // indirect
+
+replace events/common => ../../../common
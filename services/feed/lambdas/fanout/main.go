@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"events/common/data"
+	"events/common/domain"
+	"events/common/timeline"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type app struct {
+	db *sql.DB
+}
+
+// copies every new post into the timelines of the authors followers so the
+// feed reads one users rows instead of walking the social graph
+func (app *app) handler(ctx context.Context, event events.CloudWatchEvent) error {
+	env, err := domain.Parse(event.Detail)
+	if err != nil {
+		fmt.Printf("Invalid event envelope: %s\n", err.Error())
+		return err
+	}
+
+	payload, err := env.Decode()
+	if err != nil {
+		fmt.Printf("Could not decode %s event %s: %s\n", env.Type, env.Id, err.Error())
+		return err
+	}
+
+	post, ok := payload.(*domain.PostAdded)
+	if !ok {
+		return nil
+	}
+
+	inserted, err := timeline.FanOut(ctx, app.db, post.UserId, post.PostId, post.CreatedAt)
+	if err != nil {
+		fmt.Printf("Could not fan out post %d: %s\n", post.PostId, err.Error())
+		return err
+	}
+
+	fmt.Printf("Post %d added to %d timelines\n", post.PostId, inserted)
+	return nil
+}
+
+func main() {
+	db, err := data.OpenDB(os.Getenv("DB_ADDRESS"))
+	if err != nil {
+		fmt.Printf("Could not open db: %s\n", err.Error())
+		return
+	}
+
+	app := &app{db: db}
+	lambda.Start(app.handler)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+
+	"events/common/api"
+	"events/feed/models"
+	"events/session"
+)
+
+func (app *app) getFeedHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	rank := qs.Get("rank")
+	if rank == "" {
+		rank = app.defaultRank
+	}
+
+	ranker, err := models.GetRanker(rank)
+	if err != nil {
+		api.BadRequestResponse(w, r, err)
+		return
+	}
+
+	filters, err := models.ReadFilters(qs, ranker)
+	if err != nil {
+		api.BadRequestResponse(w, r, err)
+		return
+	}
+
+	posts, metadata, err := app.models.Feed.Get(session.ContextGetUser(r).Id, ranker, filters)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = api.WriteJSON(w, http.StatusOK, api.Envelope{"posts": posts, "metadata": metadata}, nil)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+
+	"events/common/api"
+	"events/common/data"
+	"events/feed/models"
+	"events/session"
+	"github.com/go-chi/chi/v5"
+)
+
+var router *chi.Mux
+
+type app struct {
+	models      models.Models
+	defaultRank string
+}
+
+func init() {
+	db, err := data.OpenDB(os.Getenv("DB_ADDRESS"))
+	if err != nil {
+		panic(err)
+	}
+
+	defaultRank := os.Getenv("FEED_RANKER")
+	if _, err := models.GetRanker(defaultRank); err != nil {
+		panic(err)
+	}
+
+	app := app{models: models.NewModels(db, os.Getenv("MEDIA_BASE_URL")), defaultRank: defaultRank}
+	r := api.NewRouter()
+	sessionModel := session.SessionModel{DB: db}
+	r.Use(sessionModel.Authenticate)
+	r.Route("/v1/feed", func(r chi.Router) {
+		r.Get("/healthcheck", api.HealthcheckHandler)
+		r.With(session.RequireAuthenticatedUser).Get("/", app.getFeedHandler)
+	})
+
+	router = r
+}
+
+func main() {
+	api.Start(router)
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"events/common/cursor"
+	"events/common/data"
+)
+
+type FeedModel struct {
+	DB           *sql.DB
+	MediaBaseURL string
+}
+
+// posts from userIds materialized timeline, best first according to ranker.
+// see Filters for how each kind of ranking is paged
+func (f *FeedModel) Get(userId int64, ranker Ranker, filters Filters) ([]data.PostData, cursor.Metadata, error) {
+	metadata := cursor.Metadata{}
+
+	var order, after string
+	var args []any
+	if ranker.Stable() {
+		scan := filters.ScanOrder(cursor.DESC)
+		order = fmt.Sprintf("created_at %s, id %s", scan, scan)
+		after = fmt.Sprintf("(created_at, id) %s ($2, $3)", filters.Compare(cursor.DESC))
+
+		createdAt, id, first := filters.Args()
+		args = []any{userId, createdAt, id, first, filters.Limit()}
+	} else {
+		order = "score DESC, id DESC"
+		after = "(score, id) < ($2::float8, $3::bigint)"
+
+		score, _, id, first := filters.After.Args()
+		args = []any{userId, score, id, first, filters.Limit()}
+	}
+
+	query := fmt.Sprintf(`
+	WITH feed AS (
+		SELECT post.id, post.body, post.created_at, post.updated_at, post.version,
+		COUNT(comment.id) AS comments_count,
+		MAX(comment.created_at) AS last_comment_at, MAX(comment.body) AS last_comment_body,
+		users.id AS user_id, users.username AS user_username, users.profile_picture AS user_pp,
+		%s AS score
+		FROM timelines AS timeline
+		JOIN posts AS post ON post.id = timeline.post_id
+		JOIN users ON users.id = post.user_id
+		LEFT JOIN comments AS comment
+			ON comment.post_id = post.id AND comment.path = '0'
+			AND comment.deleted_at IS NULL
+		WHERE timeline.user_id = $1
+		GROUP BY post.id, users.id
+	)
+	SELECT id, body, created_at, updated_at, version, comments_count, last_comment_at,
+	last_comment_body, user_id, user_username, user_pp, score
+	FROM feed
+	WHERE $4 OR %s
+	ORDER BY %s
+	LIMIT $5
+	`, ranker.Score(), after, order)
+
+	posts := []data.PostData{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := f.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, metadata, err
+	}
+
+	defer rows.Close()
+
+	scores := map[int64]float64{}
+	for rows.Next() {
+		postListed := data.PostData{}
+		var score float64
+
+		var lastCommentAt sql.NullTime
+		var lastCommentBody sql.NullString
+
+		err := rows.Scan(
+			&postListed.Post.Id,
+			&postListed.Post.Body,
+			&postListed.Post.CreatedAt,
+			&postListed.Post.UpdatedAt,
+			&postListed.Post.Version,
+			&postListed.Metadata.CommentsCount,
+			&lastCommentAt,
+			&lastCommentBody,
+			&postListed.Post.User.Id,
+			&postListed.Post.User.Username,
+			&postListed.Post.User.ProfilePicture,
+			&score,
+		)
+		if err != nil {
+			return nil, metadata, err
+		}
+
+		if lastCommentAt.Valid {
+			postListed.Metadata.LastCommentAt = lastCommentAt.Time
+		}
+
+		if lastCommentBody.Valid {
+			postListed.Metadata.LatestComment = lastCommentBody.String
+		}
+
+		postListed.Post.EditInfo = data.NewEditInfo(postListed.Post.Version)
+		scores[postListed.Post.Id] = score
+		posts = append(posts, postListed)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, metadata, err
+	}
+
+	if ranker.Stable() {
+		posts, metadata = cursor.Paginate(filters.Filters, posts, func(p data.PostData) (time.Time, int64) {
+			return p.Post.CreatedAt, p.Post.Id
+		})
+	} else {
+		posts, metadata = cursor.PaginateRanked(filters.Take, posts, func(p data.PostData) cursor.Ranked {
+			return cursor.Ranked{Scope: ranker.Name(), Score: scores[p.Post.Id], Id: p.Post.Id}
+		})
+	}
+
+	err = data.LoadMedia(ctx, f.DB, f.MediaBaseURL, posts)
+	if err != nil {
+		return nil, metadata, err
+	}
+
+	return posts, metadata, nil
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"events/common/cursor"
+	"events/common/data"
+)
+
+func TestGetRanker(t *testing.T) {
+	ranker, err := GetRanker("")
+	if err != nil || ranker.Name() != RANK_CHRONOLOGICAL {
+		t.Fatalf("default ranker = %v, %v", ranker, err)
+	}
+
+	ranker, err = GetRanker(RANK_ENGAGEMENT)
+	if err != nil || ranker.Name() != RANK_ENGAGEMENT {
+		t.Fatalf("engagement ranker = %v, %v", ranker, err)
+	}
+
+	if !(Chronological{}).Stable() || ranker.Stable() {
+		t.Fatal("only the chronological ranking is paged by created_at")
+	}
+
+	_, err = GetRanker("popular")
+	if err == nil {
+		t.Fatal("expected an error for an unknown ranking")
+	}
+}
+
+func TestReadFilters(t *testing.T) {
+	engagement, _ := GetRanker(RANK_ENGAGEMENT)
+	ranked := cursor.Ranked{Scope: RANK_ENGAGEMENT, Score: 12.5, Id: 3}.Encode()
+
+	f, err := ReadFilters(url.Values{"take": {"5"}, "cursor": {ranked}}, engagement)
+	if err != nil || f.Take != 5 || f.After == nil || f.After.Id != 3 {
+		t.Fatalf("ranked filters = %+v, %v", f, err)
+	}
+
+	// a chronological cursor does not page a ranked feed or the other way round
+	chronological := cursor.Cursor{CreatedAt: time.Now(), Id: 3, Direction: "n"}.Encode()
+	tests := []struct {
+		ranker Ranker
+		qs     url.Values
+		want   error
+	}{
+		{engagement, url.Values{"cursor": {chronological}}, cursor.ErrInvalidCursor},
+		{Chronological{}, url.Values{"cursor": {ranked}}, cursor.ErrInvalidCursor},
+		{engagement, url.Values{"take": {"0"}}, cursor.ErrInvalidTake},
+	}
+
+	for _, tt := range tests {
+		_, err := ReadFilters(tt.qs, tt.ranker)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("ReadFilters(%v, %s) = %v, want %v", tt.qs, tt.ranker.Name(), err, tt.want)
+		}
+	}
+}
+
+// runs against a migrated postgres, `make start` at the root of the repo
+// starts one. TEST_DB_ADDRESS has to be set, the test writes to it
+func TestGetPagesEveryRanking(t *testing.T) {
+	dsn := os.Getenv("TEST_DB_ADDRESS")
+	if dsn == "" {
+		t.Skip("TEST_DB_ADDRESS is not set")
+	}
+
+	db, err := data.OpenDB(dsn)
+	if err != nil {
+		t.Skipf("postgres is not reachable: %v", err)
+	}
+	defer db.Close()
+
+	suffix := time.Now().Format("150405.000000000")
+	var userId int64
+	err = db.QueryRow(
+		`insert into users (email, username, profile_picture) values ($1, $2, '') returning id`,
+		"feed"+suffix+"@test.local",
+		"feed"+suffix,
+	).Scan(&userId)
+	if err != nil {
+		t.Fatalf("inserting user: %v", err)
+	}
+	t.Cleanup(func() { db.Exec(`delete from users where id = $1`, userId) })
+
+	// three posts in the users own timeline, liked so the rankings disagree
+	for i := 0; i < 3; i++ {
+		_, err = db.Exec(`
+			with post as (
+				insert into posts (body, user_id, total_likes, created_at)
+				values ($1, $2, $3, now() - $4 * interval '1 hour')
+				returning id, created_at
+			)
+			insert into timelines (user_id, post_id, created_at)
+			select $2, post.id, post.created_at from post
+		`, fmt.Sprintf("post %d", i), userId, i*100, i)
+		if err != nil {
+			t.Fatalf("inserting post: %v", err)
+		}
+	}
+
+	model := FeedModel{DB: db}
+	for _, name := range []string{RANK_CHRONOLOGICAL, RANK_ENGAGEMENT} {
+		ranker, _ := GetRanker(name)
+
+		first, metadata, err := model.Get(userId, ranker, Filters{Filters: cursor.Filters{Take: 2}})
+		if err != nil {
+			t.Fatalf("%s first page: %v", name, err)
+		}
+
+		if len(first) != 2 || metadata.NextCursor == "" {
+			t.Fatalf("%s first page: %d posts, %+v", name, len(first), metadata)
+		}
+
+		filters, err := ReadFilters(url.Values{"take": {"2"}, "cursor": {metadata.NextCursor}}, ranker)
+		if err != nil {
+			t.Fatalf("%s next cursor: %v", name, err)
+		}
+
+		second, metadata, err := model.Get(userId, ranker, filters)
+		if err != nil {
+			t.Fatalf("%s second page: %v", name, err)
+		}
+
+		if len(second) != 1 || metadata.NextCursor != "" {
+			t.Fatalf("%s second page: %d posts, %+v", name, len(second), metadata)
+		}
+
+		for _, p := range first {
+			if p.Post.Id == second[0].Post.Id {
+				t.Fatalf("%s repeated post %d across pages", name, p.Post.Id)
+			}
+		}
+	}
+}
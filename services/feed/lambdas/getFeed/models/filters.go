@@ -0,0 +1,44 @@
+package models
+
+import (
+	"net/url"
+
+	"events/common/api"
+	"events/common/cursor"
+)
+
+// Filters is a validated feed request. stable rankings page with the shared
+// (created_at, id) cursor in both directions, the rest page forward with a
+// ranked cursor scoped to the ranking so one can not be replayed against
+// another
+type Filters struct {
+	cursor.Filters
+	After *cursor.Ranked
+}
+
+func ReadFilters(qs url.Values, ranker Ranker) (Filters, error) {
+	if ranker.Stable() {
+		f, err := cursor.ReadFilters(qs)
+		return Filters{Filters: f}, err
+	}
+
+	f := Filters{}
+	take, err := api.ReadInt(qs, "take", cursor.DEFAULT_TAKE)
+	if err == nil {
+		err = cursor.ValidateFilters(cursor.Filters{Take: take})
+	}
+
+	if err != nil {
+		return f, cursor.ErrInvalidTake
+	}
+	f.Take = take
+
+	if s := qs.Get("cursor"); s != "" {
+		f.After, err = cursor.DecodeRanked(s, ranker.Name())
+		if err != nil {
+			return f, err
+		}
+	}
+
+	return f, nil
+}
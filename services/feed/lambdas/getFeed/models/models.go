@@ -0,0 +1,15 @@
+package models
+
+import (
+	"database/sql"
+)
+
+type Models struct {
+	Feed FeedModel
+}
+
+func NewModels(db *sql.DB, mediaBaseURL string) Models {
+	return Models{
+		Feed: FeedModel{DB: db, MediaBaseURL: mediaBaseURL},
+	}
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+)
+
+// Ranker decides the order of the feed. Score is a sql expression over the
+// feed row (post.*, comments_count) and must not depend on the time of the
+// request. A Stable ranking orders posts newest first and never reorders
+// them so it is paged by (created_at, id) both ways, any other ranking moves
+// with likes and comments and is paged forward by (score, id) like search
+type Ranker interface {
+	Name() string
+	Score() string
+	Stable() bool
+}
+
+const (
+	RANK_CHRONOLOGICAL = "chronological"
+	RANK_ENGAGEMENT    = "engagement"
+)
+
+type Chronological struct{}
+
+func (Chronological) Name() string {
+	return RANK_CHRONOLOGICAL
+}
+
+func (Chronological) Score() string {
+	return `extract(epoch from post.created_at)::float8`
+}
+
+func (Chronological) Stable() bool {
+	return true
+}
+
+// Engagement is a hot ranking: every tenfold increase in likes plus weighted
+// comments is worth the same as being HalfLife seconds newer
+type Engagement struct {
+	CommentWeight float64
+	HalfLife      float64
+}
+
+func (Engagement) Name() string {
+	return RANK_ENGAGEMENT
+}
+
+// the score moves with every like and comment
+func (Engagement) Stable() bool {
+	return false
+}
+
+func (e Engagement) Score() string {
+	return fmt.Sprintf(
+		`(log(greatest(post.total_likes + %g * count(comment.id), 1)) + extract(epoch from post.created_at) / %g)::float8`,
+		e.CommentWeight,
+		e.HalfLife,
+	)
+}
+
+var rankers = map[string]Ranker{
+	RANK_CHRONOLOGICAL: Chronological{},
+	RANK_ENGAGEMENT:    Engagement{CommentWeight: 2, HalfLife: 45000},
+}
+
+func GetRanker(name string) (Ranker, error) {
+	if name == "" {
+		name = RANK_CHRONOLOGICAL
+	}
+
+	ranker, ok := rankers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown ranking %q", name)
+	}
+
+	return ranker, nil
+}
@@ -0,0 +1,91 @@
+import { CfnOutput, Tags } from 'aws-cdk-lib';
+import { Construct } from "constructs";
+import { RestApi, LambdaIntegration } from "aws-cdk-lib/aws-apigateway";
+import { Bucket } from 'aws-cdk-lib/aws-s3';
+import * as events from 'aws-cdk-lib/aws-events';
+import { LambdaFunction } from 'aws-cdk-lib/aws-events-targets';
+import { createLambda } from '../../../lib/lambda';
+import * as path from "path"
+
+interface FeedProps {
+	db_url?: string
+	session_secret?: string
+	ranker?: string
+	// where post media is served from
+	media_base_url: string
+	eventBus: events.EventBus
+}
+
+export class Feed extends Construct {
+	constructor(scope: Construct, id: string, props: FeedProps) {
+		super(scope, id);
+		const { eventBus } = props
+
+		if (!props.db_url) {
+			throw new Error("DB env var is not set")
+		}
+
+		if (!props.session_secret) {
+			throw new Error("SESSION_SECRET env var is not set")
+		}
+
+		const hotReloadBucket = Bucket.fromBucketName(
+			this,
+			"HotReloadingBucket",
+			"hot-reload"
+		)
+
+		const getFeed = createLambda(
+			this,
+			"getFeed",
+			path.join(__dirname, "../lambdas/getFeed"),
+			hotReloadBucket,
+			{
+				DB_ADDRESS: props.db_url,
+				SESSION_SECRET: props.session_secret,
+				FEED_RANKER: props.ranker ?? "chronological",
+				MEDIA_BASE_URL: props.media_base_url,
+			},
+		)
+
+		const fanout = createLambda(
+			this,
+			"feedFanout",
+			path.join(__dirname, "../lambdas/fanout"),
+			hotReloadBucket,
+			{ DB_ADDRESS: props.db_url },
+			"Writes new posts into the timelines of the authors followers",
+		)
+
+		new events.Rule(this, "FeedFanout", {
+			eventBus,
+			enabled: true,
+			ruleName: "FeedFanout",
+			eventPattern: {
+				detailType: ["NotificationReceived"],
+				source: ["notifications"],
+				detail: { type: ["PostAdded"] },
+			},
+			targets: [new LambdaFunction(fanout)],
+		})
+
+		const api = new RestApi(this, "feedapi", {
+			restApiName: "feedapi",
+			description: "API for the home feed",
+		})
+		Tags.of(api).add("_custom_id_", "feedapi")
+
+		// /v1/feed
+		// /v1/feed/healthcheck
+		const feed = api.root.addResource("v1").addResource("feed")
+		const healthcheck = feed.addResource("healthcheck")
+
+		const feedIntegration = new LambdaIntegration(getFeed)
+		feed.addMethod("GET", feedIntegration)
+		healthcheck.addMethod("GET", feedIntegration)
+
+		new CfnOutput(this, "GatewayId", { value: api.restApiId })
+		new CfnOutput(this, "GatewayUrl", { value: api.url })
+		new CfnOutput(this, "GatewayEndPoints", { value: "\n" + api.methods.join("\n") })
+	}
+}
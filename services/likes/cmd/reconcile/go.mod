@@ -0,0 +1,9 @@
+module reconcile
+
+go 1.21.5
+
+require events/common v0.0.0
+
+require github.com/lib/pq v1.10.9 // indirect
+
+replace events/common => ../../../common
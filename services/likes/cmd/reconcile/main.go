@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"events/common/data"
+)
+
+// recomputes posts.total_likes and comments.total_likes from the like tables,
+// meant for repairing counters that drifted before likes were transactional
+func main() {
+	var dsn string
+	var dryRun bool
+	flag.StringVar(&dsn, "db-dsn", os.Getenv("DB_ADDRESS"), "postgres dsn")
+	flag.BoolVar(&dryRun, "dry-run", false, "report drifted rows without updating them")
+	flag.Parse()
+
+	db, err := data.OpenDB(dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to db: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	posts, err := reconcile(db, "posts", "post_likes", "post_id", dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to reconcile post likes: %v\n", err)
+		os.Exit(1)
+	}
+
+	comments, err := reconcile(db, "comments", "comment_likes", "comment_id", dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to reconcile comment likes: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("drifted posts: %d, drifted comments: %d\n", posts, comments)
+}
+
+// table names come from the callers above, never from input
+func reconcile(db *sql.DB, table, likeTable, fkColumn string, dryRun bool) (int64, error) {
+	counts := fmt.Sprintf(`
+		with counts as (
+			select t.id, count(l.id) as total
+			from %[1]s t
+			left join %[2]s l on l.%[3]s = t.id
+			group by t.id
+		)
+	`, table, likeTable, fkColumn)
+
+	var query string
+	if dryRun {
+		query = counts + fmt.Sprintf(`
+			select count(*) from %[1]s t
+			join counts c on c.id = t.id
+			where t.total_likes <> c.total
+		`, table)
+	} else {
+		query = counts + fmt.Sprintf(`
+			, updated as (
+				update %[1]s t set total_likes = c.total
+				from counts c
+				where c.id = t.id and t.total_likes <> c.total
+				returning t.id
+			)
+			select count(*) from updated
+		`, table)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	var drifted int64
+	err := db.QueryRowContext(ctx, query).Scan(&drifted)
+	return drifted, err
+}
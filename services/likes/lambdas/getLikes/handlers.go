@@ -3,56 +3,59 @@ package main
 import (
 	"fmt"
 	"net/http"
+
+	"events/common/api"
+	"events/common/cursor"
 )
 
-func (app *app) healthcheckHandler(w http.ResponseWriter, r *http.Request) {
-	err := app.writeJSON(w, http.StatusOK, envelope{"status": "available"}, nil)
+func (app *app) postLikesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := api.ReadIDParam(r, "id")
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.ErrorResponse(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
-}
-
-func (app *app) notFoundHandler(w http.ResponseWriter, r *http.Request) {
-	app.errorResponse(w, r, http.StatusNotFound, "resource not found")
-}
 
-func (app *app) postLikesHandler(w http.ResponseWriter, r *http.Request) {
-	id, err := app.getId(r, "id")
+	filters, err := cursor.ReadFilters(r.URL.Query())
 	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, err.Error())
+		api.BadRequestResponse(w, r, err)
 		return
 	}
 
-	likes, err := app.models.Like.getPostLikes(id, app.getFilter(r))
+	likes, err := app.models.Like.getPostLikes(id, filters)
 	if err != nil {
 		fmt.Printf("failed to get post likes: %s\n", err.Error())
-		app.serverErrorResponse(w, r, err)
+		api.ServerErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"post_likes": likes}, nil)
+	err = api.WriteJSON(w, http.StatusOK, api.Envelope{"post_likes": likes}, nil)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.ServerErrorResponse(w, r, err)
 	}
 }
 
 func (app *app) commentLikesHandler(w http.ResponseWriter, r *http.Request) {
-	id, err := app.getId(r, "id")
+	id, err := api.ReadIDParam(r, "id")
+	if err != nil {
+		api.ErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filters, err := cursor.ReadFilters(r.URL.Query())
 	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, err.Error())
+		api.BadRequestResponse(w, r, err)
 		return
 	}
 
-	likes, err := app.models.Like.getCommentLikes(id, app.getFilter(r))
+	likes, err := app.models.Like.getCommentLikes(id, filters)
 	if err != nil {
 		fmt.Printf("failed to get comment likes: %s\n", err.Error())
-		app.serverErrorResponse(w, r, err)
+		api.ServerErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"comment_likes": likes}, nil)
+	err = api.WriteJSON(w, http.StatusOK, api.Envelope{"comment_likes": likes}, nil)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.ServerErrorResponse(w, r, err)
 	}
 }
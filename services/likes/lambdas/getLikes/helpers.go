@@ -1,83 +0,0 @@
-package main
-
-import (
-	"encoding/json"
-	"errors"
-	"fmt"
-	"net/http"
-	"net/url"
-	"strconv"
-
-	"github.com/go-chi/chi/v5"
-)
-
-type envelope map[string]any
-
-func (app *app) writeJSON(
-	w http.ResponseWriter,
-	status int,
-	data envelope,
-	headers http.Header,
-) error {
-	js, err := json.MarshalIndent(data, "", "\t")
-	if err != nil {
-		return err
-	}
-
-	js = append(js, '\n')
-
-	for key, value := range headers {
-		w.Header()[key] = value
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	w.Write(js)
-	return nil
-}
-
-func (app *app) getIntParam(qs url.Values, key string, defaultValue int) int {
-	s := qs.Get(key)
-	if s == "" {
-		return defaultValue
-	}
-
-	i, err := strconv.Atoi(s)
-	if err != nil {
-		return defaultValue
-	}
-
-	return i
-}
-
-func (app *app) errorResponse(w http.ResponseWriter, r *http.Request, status int, message any) {
-	env := envelope{"error": message}
-	err := app.writeJSON(w, status, env, nil)
-	if err != nil {
-		w.WriteHeader(500)
-	}
-}
-
-func (app *app) serverErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
-	message := "the server encountared a problem and could not process this request :("
-	app.errorResponse(w, r, http.StatusInternalServerError, message)
-}
-
-func (app *app) getFilter(r *http.Request) *Filter {
-	filter := &Filter{}
-	qs := r.URL.Query()
-	filter.take = app.getIntParam(qs, "take", 30)
-	filter.skip = app.getIntParam(qs, "skip", 0)
-
-	return filter
-}
-
-func (app *app) getId(r *http.Request, key string) (int64, error) {
-	id, err := strconv.ParseInt(chi.URLParam(r, key), 10, 64)
-	if err != nil || id < 1 {
-		errorMSg := fmt.Sprintf("invalid %s id!", key)
-		return 0, errors.New(errorMSg)
-	}
-
-	return id, nil
-}
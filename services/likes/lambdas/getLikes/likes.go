@@ -3,7 +3,11 @@ package main
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"time"
+
+	"events/common/cursor"
+	"events/common/data"
 )
 
 type LikeModel struct {
@@ -15,7 +19,7 @@ type PostLike struct {
 	PostId     int64     `json:"post_id"`
 	UserId     int64     `json:"user_id"`
 	Created_at time.Time `json:"created_at"`
-	User       User      `json:"user"`
+	User       data.User `json:"user"`
 }
 
 type CommentLike struct {
@@ -23,37 +27,12 @@ type CommentLike struct {
 	CommentId  int64     `json:"comment_id"`
 	UserId     int64     `json:"user_id"`
 	Created_at time.Time `json:"created_at"`
-	User       User      `json:"user"`
-}
-
-type User struct {
-	Id             int64  `json:"id"`
-	ProfilePicture string `json:"profile_picture"`
-	Username       string `json:"username"`
-}
-
-type Filter struct {
-	take int
-	skip int
+	User       data.User `json:"user"`
 }
 
 type Metadata struct {
+	cursor.Metadata
 	TotalCount int `json:"total_count"`
-	LeftCount  int `json:"left_count"`
-}
-
-func calculateMetadata(totalCount int, filter *Filter) Metadata {
-	m := Metadata{}
-	m.TotalCount = totalCount
-
-	leftCount := 0
-	if totalCount-(filter.skip+filter.take) > 0 {
-		leftCount = totalCount - (filter.skip + filter.take)
-	}
-
-	m.LeftCount = leftCount
-
-	return m
 }
 
 type PostLikesReturn struct {
@@ -63,17 +42,19 @@ type PostLikesReturn struct {
 
 // TODO the get queries could be combined, at least some parts
 
-func (p *LikeModel) getPostLikes(postId int64, filter *Filter) (PostLikesReturn, error) {
-	query := `
+func (p *LikeModel) getPostLikes(postId int64, filters cursor.Filters) (PostLikesReturn, error) {
+	scan := filters.ScanOrder(cursor.DESC)
+	query := fmt.Sprintf(`
 		select l.id, l.post_id, l.user_id, l.created_at, 
 		u.id, u.username, u.profile_picture,
-		count(*) over() as full_count
+		(select count(*) from post_likes where post_id = $1) as full_count
 		from post_likes l
 		join users u on u.id = l.user_id
 		where l.post_id = $1
-		order by l.created_at desc
-		limit $2 offset $3
-	`
+		and ($4 or (l.created_at, l.id) %s ($2, $3))
+		order by l.created_at %s, l.id %s
+		limit $5
+	`, filters.Compare(cursor.DESC), scan, scan)
 	postLikesReturn := PostLikesReturn{}
 	postLikes := []PostLike{}
 	totalCount := 0
@@ -81,7 +62,8 @@ func (p *LikeModel) getPostLikes(postId int64, filter *Filter) (PostLikesReturn,
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := p.DB.QueryContext(ctx, query, postId, filter.take, filter.skip)
+	createdAt, id, first := filters.Args()
+	rows, err := p.DB.QueryContext(ctx, query, postId, createdAt, id, first, filters.Limit())
 	if err != nil {
 		return postLikesReturn, err
 	}
@@ -90,7 +72,7 @@ func (p *LikeModel) getPostLikes(postId int64, filter *Filter) (PostLikesReturn,
 
 	for rows.Next() {
 		var pl PostLike
-		var u User
+		var u data.User
 
 		err := rows.Scan(
 			&pl.Id,
@@ -115,8 +97,12 @@ func (p *LikeModel) getPostLikes(postId int64, filter *Filter) (PostLikesReturn,
 		return postLikesReturn, err
 	}
 
-	postLikesReturn.Likes = postLikes
-	postLikesReturn.Metadata = calculateMetadata(totalCount, filter)
+	postLikesReturn.Likes, postLikesReturn.Metadata.Metadata = cursor.Paginate(
+		filters,
+		postLikes,
+		func(pl PostLike) (time.Time, int64) { return pl.Created_at, pl.Id },
+	)
+	postLikesReturn.Metadata.TotalCount = totalCount
 
 	return postLikesReturn, nil
 }
@@ -126,17 +112,19 @@ type CommentLikesReturn struct {
 	Metadata Metadata      `json:"metadata"`
 }
 
-func (p *LikeModel) getCommentLikes(commentId int64, filter *Filter) (CommentLikesReturn, error) {
-	query := `
+func (p *LikeModel) getCommentLikes(commentId int64, filters cursor.Filters) (CommentLikesReturn, error) {
+	scan := filters.ScanOrder(cursor.DESC)
+	query := fmt.Sprintf(`
 		select l.id, l.comment_id, l.user_id, l.created_at, 
 		u.id, u.username, u.profile_picture,
-		count(*) over() as full_count
+		(select count(*) from comment_likes where comment_id = $1) as full_count
 		from comment_likes l
 		join users u on u.id = l.user_id
 		where l.comment_id = $1
-		order by l.created_at desc
-		limit $2 offset $3
-	`
+		and ($4 or (l.created_at, l.id) %s ($2, $3))
+		order by l.created_at %s, l.id %s
+		limit $5
+	`, filters.Compare(cursor.DESC), scan, scan)
 
 	commentLikesReturn := CommentLikesReturn{}
 	commentLikes := []CommentLike{}
@@ -145,7 +133,8 @@ func (p *LikeModel) getCommentLikes(commentId int64, filter *Filter) (CommentLik
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := p.DB.QueryContext(ctx, query, commentId, filter.take, filter.skip)
+	createdAt, id, first := filters.Args()
+	rows, err := p.DB.QueryContext(ctx, query, commentId, createdAt, id, first, filters.Limit())
 	if err != nil {
 		return commentLikesReturn, err
 	}
@@ -154,7 +143,7 @@ func (p *LikeModel) getCommentLikes(commentId int64, filter *Filter) (CommentLik
 
 	for rows.Next() {
 		var cl CommentLike
-		var u User
+		var u data.User
 		err := rows.Scan(
 			&cl.Id,
 			&cl.CommentId,
@@ -178,8 +167,12 @@ func (p *LikeModel) getCommentLikes(commentId int64, filter *Filter) (CommentLik
 		return commentLikesReturn, err
 	}
 
-	commentLikesReturn.Likes = commentLikes
-	commentLikesReturn.Metadata = calculateMetadata(totalCount, filter)
+	commentLikesReturn.Likes, commentLikesReturn.Metadata.Metadata = cursor.Paginate(
+		filters,
+		commentLikes,
+		func(cl CommentLike) (time.Time, int64) { return cl.Created_at, cl.Id },
+	)
+	commentLikesReturn.Metadata.TotalCount = totalCount
 
 	return commentLikesReturn, nil
 }
@@ -1,99 +1,44 @@
 package main
 
 import (
-	"encoding/json"
-	"os"
-	"time"
+	"context"
+	"database/sql"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"events/common/domain"
+	"events/common/outbox"
 )
 
-const (
-	POST_LIKE_EVENT    = "PostLike"
-	COMMENT_LIKE_EVENT = "CommentLike"
-)
-
-type PostLikeEvent struct {
-	PostId         int64     `json:"post_id"`
-	PostUserId     int64     `json:"post_user_id"`
-	PostLikeUserId int64     `json:"post_like_user_id"`
-	EventType      string    `json:"event_type"`
-	LikedAt        time.Time `json:"liked_at"`
-}
-
-type CommentLikeEvent struct {
-	CommentId         int64     `json:"comment_id"`
-	CommentUserId     int64     `json:"comment_user_id"`
-	CommentLikeUserId int64     `json:"comment_like_user_id"`
-	EventType         string    `json:"event_type"`
-	LikedAt           time.Time `json:"liked_at"`
-}
-
-func (app *app) publishPostLike(postId, postUserId, likeUserId int64) error {
-	busName := os.Getenv("BUS_NAME")
-
-	p := PostLikeEvent{
-		PostId:         postId,
+func enqueuePostLike(
+	ctx context.Context,
+	tx *sql.Tx,
+	postLike *PostLike,
+	postUserId, totalLikes int64,
+) error {
+	event := domain.PostLiked{
+		PostId:         postLike.PostId,
 		PostUserId:     postUserId,
-		PostLikeUserId: likeUserId,
-		EventType:      POST_LIKE_EVENT,
-		LikedAt:        time.Now(),
-	}
-
-	detail, err := json.Marshal(p)
-	if err != nil {
-		return err
+		PostLikeUserId: postLike.UserId,
+		TotalLikes:     totalLikes,
+		LikedAt:        postLike.Created_at,
 	}
 
-	_, err = app.eb.PutEvents(&eventbridge.PutEventsInput{
-		Entries: []*eventbridge.PutEventsRequestEntry{
-			{
-				Detail:       aws.String(string(detail)),
-				DetailType:   aws.String("NotificationReceived"),
-				Source:       aws.String("notifications"),
-				EventBusName: aws.String(busName),
-			},
-		},
-	})
-
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return outbox.Enqueue(ctx, tx, outbox.AGGREGATE_POST, postLike.PostId, event, postLike.Created_at)
 }
 
-func (app *app) publishCommentLike(commentId, commentUserId, likeUserId int64) error {
-	busName := os.Getenv("BUS_NAME")
-
-	p := CommentLikeEvent{
-		CommentId:         commentId,
+func enqueueCommentLike(
+	ctx context.Context,
+	tx *sql.Tx,
+	commentLike *CommentLike,
+	postId, commentUserId, totalLikes int64,
+) error {
+	event := domain.CommentLiked{
+		PostId:            postId,
+		CommentId:         commentLike.CommentId,
 		CommentUserId:     commentUserId,
-		CommentLikeUserId: likeUserId,
-		EventType:         COMMENT_LIKE_EVENT,
-		LikedAt:           time.Now(),
-	}
-
-	detail, err := json.Marshal(p)
-	if err != nil {
-		return err
-	}
-
-	_, err = app.eb.PutEvents(&eventbridge.PutEventsInput{
-		Entries: []*eventbridge.PutEventsRequestEntry{
-			{
-				Detail:       aws.String(string(detail)),
-				DetailType:   aws.String("NotificationReceived"),
-				Source:       aws.String("notifications"),
-				EventBusName: aws.String(busName),
-			},
-		},
-	})
-
-	if err != nil {
-		return err
+		CommentLikeUserId: commentLike.UserId,
+		TotalLikes:        totalLikes,
+		LikedAt:           commentLike.Created_at,
 	}
 
-	return nil
+	return outbox.Enqueue(ctx, tx, outbox.AGGREGATE_POST, postId, event, commentLike.Created_at)
 }
@@ -3,8 +3,11 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"strings"
 	"time"
+
+	"events/common/data"
 )
 
 type LikeModel struct {
@@ -25,7 +28,20 @@ type CommentLike struct {
 	Created_at time.Time `json:"created_at"`
 }
 
-func (l *LikeModel) likePost(postLike *PostLike) error {
+func likeInsertError(err error) error {
+	switch {
+	case strings.Contains(err.Error(), "duplicate key value violates unique constraint"):
+		return data.ErrAlreadyLiked
+	case strings.Contains(err.Error(), "violates foreign key constraint"):
+		return data.ErrRecordNotFound
+	default:
+		return err
+	}
+}
+
+// inserts the like, bumps the posts counter and queues the notification in
+// one transaction, returns the posts fresh like count
+func (l *LikeModel) likePost(postLike *PostLike) (int64, error) {
 	query := `
 		insert into post_likes (post_id, user_id)
 		values ($1, $2)
@@ -35,38 +51,44 @@ func (l *LikeModel) likePost(postLike *PostLike) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	args := []interface{}{postLike.PostId, postLike.UserId}
-	err := l.DB.QueryRowContext(ctx, query, args...).Scan(&postLike.Id, &postLike.Created_at)
+	tx, err := l.DB.BeginTx(ctx, nil)
 	if err != nil {
-		switch {
-		case strings.Contains(err.Error(), "duplicate key value violates unique constraint"):
-			return ErrAlreadyLiked
-		default:
-			return err
-		}
+		return 0, err
 	}
+	defer tx.Rollback()
 
-	return nil
-}
+	args := []interface{}{postLike.PostId, postLike.UserId}
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&postLike.Id, &postLike.Created_at)
+	if err != nil {
+		return 0, likeInsertError(err)
+	}
 
-func (l *LikeModel) undoPostLike(postLike *PostLike) error {
-	query := `
-		delete from post_likes
+	query = `
+		update posts set total_likes = total_likes + 1
 		where id = $1
+		returning total_likes, user_id
 	`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	var totalLikes, postUserId int64
+	err = tx.QueryRowContext(ctx, query, postLike.PostId).Scan(&totalLikes, &postUserId)
+	if err != nil {
+		return 0, err
+	}
 
-	_, err := l.DB.ExecContext(ctx, query, postLike.Id)
+	err = enqueuePostLike(ctx, tx, postLike, postUserId, totalLikes)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return totalLikes, nil
 }
 
-func (l *LikeModel) likeComment(commentLike *CommentLike) error {
+func (l *LikeModel) likeComment(commentLike *CommentLike) (int64, error) {
 	query := `
 		insert into comment_likes (comment_id, user_id)
 		values ($1, $2)
@@ -76,33 +98,46 @@ func (l *LikeModel) likeComment(commentLike *CommentLike) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	tx, err := l.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
 	args := []interface{}{commentLike.CommentId, commentLike.UserId}
-	err := l.DB.QueryRowContext(ctx, query, args...).Scan(&commentLike.Id, &commentLike.Created_at)
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&commentLike.Id, &commentLike.Created_at)
+	if err != nil {
+		return 0, likeInsertError(err)
+	}
+
+	query = `
+		update comments set total_likes = total_likes + 1
+		where id = $1 and deleted_at is null
+		returning total_likes, user_id, post_id
+	`
+
+	// deleted comments are still in the table as tombstones, they can't be
+	// liked and rolling back drops the like inserted above
+	var totalLikes, commentUserId, postId int64
+	err = tx.QueryRowContext(ctx, query, commentLike.CommentId).Scan(&totalLikes, &commentUserId, &postId)
 	if err != nil {
 		switch {
-		case strings.Contains(err.Error(), "duplicate key value violates unique constraint"):
-			return ErrAlreadyLiked
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, data.ErrRecordNotFound
 		default:
-			return err
+			return 0, err
 		}
 	}
 
-	return nil
-}
-
-func (l *LikeModel) undoCommentLike(commentLike *CommentLike) error {
-	query := `
-		delete from comment_likes
-		where id = $1
-	`
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	err = enqueueCommentLike(ctx, tx, commentLike, postId, commentUserId, totalLikes)
+	if err != nil {
+		return 0, err
+	}
 
-	_, err := l.DB.ExecContext(ctx, query, commentLike.Id)
+	err = tx.Commit()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return totalLikes, nil
 }
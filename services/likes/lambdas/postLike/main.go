@@ -1,82 +1,39 @@
 package main
 
 import (
-	"context"
-	"database/sql"
 	"os"
-	"time"
 
-	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/eventbridge"
-	"github.com/awslabs/aws-lambda-go-api-proxy/chi"
+	"events/common/api"
+	"events/common/data"
+	"events/session"
 	"github.com/go-chi/chi/v5"
-	_ "github.com/lib/pq"
 )
 
-var chiLambda *chiadapter.ChiLambda
-
-func NewEventBridge() *eventbridge.EventBridge {
-	session := session.Must(session.NewSession())
-	eb := eventbridge.New(session, aws.NewConfig().
-		WithRegion("us-east-1").
-		WithEndpoint("http://localstack:4566"),
-	)
-
-	return eb
-}
-
-func openDB() (*sql.DB, error) {
-	addr := os.Getenv("DB_ADDRESS")
-	db, err := sql.Open("postgres", addr)
-	if err != nil {
-		return nil, err
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	err = db.PingContext(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	return db, nil
-}
+var router *chi.Mux
 
 type app struct {
 	models Models
-	eb     *eventbridge.EventBridge
 }
 
 func init() {
-	db, err := openDB()
+	db, err := data.OpenDB(os.Getenv("DB_ADDRESS"))
 	if err != nil {
 		panic(err)
 	}
 
-	app := app{models: NewModels(db), eb: NewEventBridge()}
-	r := chi.NewRouter()
+	app := app{models: NewModels(db)}
+	r := api.NewRouter()
+	sessionModel := session.SessionModel{DB: db}
+	r.Use(sessionModel.Authenticate)
 	r.Route("/like", func(r chi.Router) {
-		r.Post("/post/{id}", app.likePostHandler)
-		r.Post("/comment/{id}", app.likeCommentHandler)
-		r.Get("/create/healthcheck", app.healthcheckHandler)
+		r.With(session.RequireAuthenticatedUser).Post("/post/{id}", app.likePostHandler)
+		r.With(session.RequireAuthenticatedUser).Post("/comment/{id}", app.likeCommentHandler)
+		r.Get("/create/healthcheck", api.HealthcheckHandler)
 	})
 
-	r.NotFound(app.notFoundHandler)
-
-	chiLambda = chiadapter.New(r)
-}
-
-func Handler(
-	ctx context.Context,
-	event events.APIGatewayProxyRequest,
-) (events.APIGatewayProxyResponse, error) {
-	return chiLambda.ProxyWithContext(ctx, event)
+	router = r
 }
 
 func main() {
-	lambda.StartWithOptions(Handler, lambda.WithContext(context.Background()))
+	api.Start(router)
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"events/common/domain"
+	"events/common/outbox"
+)
+
+func enqueuePostUnlike(
+	ctx context.Context,
+	tx *sql.Tx,
+	postId, postUserId, likeUserId, totalLikes int64,
+	unlikedAt time.Time,
+) error {
+	event := domain.PostUnliked{
+		PostId:         postId,
+		PostUserId:     postUserId,
+		PostLikeUserId: likeUserId,
+		TotalLikes:     totalLikes,
+		UnlikedAt:      unlikedAt,
+	}
+
+	return outbox.Enqueue(ctx, tx, outbox.AGGREGATE_POST, postId, event, unlikedAt)
+}
+
+func enqueueCommentUnlike(
+	ctx context.Context,
+	tx *sql.Tx,
+	postId, commentId, commentUserId, likeUserId, totalLikes int64,
+	unlikedAt time.Time,
+) error {
+	event := domain.CommentUnliked{
+		PostId:            postId,
+		CommentId:         commentId,
+		CommentUserId:     commentUserId,
+		CommentLikeUserId: likeUserId,
+		TotalLikes:        totalLikes,
+		UnlikedAt:         unlikedAt,
+	}
+
+	return outbox.Enqueue(ctx, tx, outbox.AGGREGATE_POST, postId, event, unlikedAt)
+}
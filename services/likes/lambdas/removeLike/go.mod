@@ -3,8 +3,17 @@ module removeLike
 go 1.21.5
 
 require (
-	github.com/aws/aws-lambda-go v1.45.0
-	github.com/awslabs/aws-lambda-go-api-proxy v0.16.0
+	events/common v0.0.0
+	events/session v0.0.0
 	github.com/go-chi/chi/v5 v5.0.11
-	github.com/lib/pq v1.10.9
 )
+
+require (
+	github.com/aws/aws-lambda-go v1.45.0 // indirect
+	github.com/awslabs/aws-lambda-go-api-proxy v0.16.0 // indirect
+	github.com/lib/pq v1.10.9 // indirect
+)
+
+replace events/common => ../../../common
+
+replace events/session => ../../../auth/session
@@ -2,78 +2,70 @@ package main
 
 import (
 	"net/http"
-)
-
-func (app *app) healthcheckHandler(w http.ResponseWriter, r *http.Request) {
-	err := app.writeJSON(w, http.StatusOK, envelope{"status": "available"}, nil)
-	if err != nil {
-		app.serverErrorResponse(w, r, err)
-		return
-	}
-}
 
-func (app *app) notFoundHandler(w http.ResponseWriter, r *http.Request) {
-	app.errorResponse(w, r, http.StatusNotFound, "resource not found")
-}
+	"events/common/api"
+	"events/common/data"
+	"events/session"
+)
 
 func (app *app) removePostLikeHandler(w http.ResponseWriter, r *http.Request) {
-	tempUserId := int64(5)
-	postId, err := app.getId(r, "id")
+	userId := session.ContextGetUser(r).Id
+	postId, err := api.ReadIDParam(r, "id")
 	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, err.Error())
+		api.ErrorResponse(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	err = app.models.Like.removePostLike(postId, tempUserId)
+	totalLikes, err := app.models.Like.removePostLike(postId, userId)
 	if err != nil {
 		switch err {
-		case ErrRecordNotFound:
-			app.notFoundHandler(w, r)
+		case data.ErrRecordNotFound:
+			api.NotFoundResponse(w, r)
 		default:
-			app.serverErrorResponse(w, r, err)
+			api.ServerErrorResponse(w, r, err)
 		}
 		return
 	}
 
-	err = app.writeJSON(
+	err = api.WriteJSON(
 		w,
 		http.StatusOK,
-		envelope{"message": "like removed successfully"},
+		api.Envelope{"message": "like removed successfully", "total_likes": totalLikes},
 		nil,
 	)
 
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.ServerErrorResponse(w, r, err)
 	}
 }
 
 func (app *app) removeCommentLikeHandler(w http.ResponseWriter, r *http.Request) {
-	tempUserId := int64(5)
-	commentId, err := app.getId(r, "id")
+	userId := session.ContextGetUser(r).Id
+	commentId, err := api.ReadIDParam(r, "id")
 	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, err.Error())
+		api.ErrorResponse(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	err = app.models.Like.removeCommentLike(commentId, tempUserId)
+	totalLikes, err := app.models.Like.removeCommentLike(commentId, userId)
 	if err != nil {
 		switch err {
-		case ErrRecordNotFound:
-			app.notFoundHandler(w, r)
+		case data.ErrRecordNotFound:
+			api.NotFoundResponse(w, r)
 		default:
-			app.serverErrorResponse(w, r, err)
+			api.ServerErrorResponse(w, r, err)
 		}
 		return
 	}
 
-	err = app.writeJSON(
+	err = api.WriteJSON(
 		w,
 		http.StatusOK,
-		envelope{"message": "like removed successfully"},
+		api.Envelope{"message": "like removed successfully", "total_likes": totalLikes},
 		nil,
 	)
 
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.ServerErrorResponse(w, r, err)
 	}
 }
@@ -4,13 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"time"
+
+	"events/common/data"
 )
 
 type LikeModel struct {
 	DB *sql.DB
 }
 
-func (l *LikeModel) removePostLike(postId, userId int64) error {
+// deletes the like, decrements the posts counter and queues the unlike event
+// in one transaction, returns the posts fresh like count
+func (l *LikeModel) removePostLike(postId, userId int64) (int64, error) {
 	query := `
 		delete from post_likes
 		where post_id = $1 and user_id = $2
@@ -19,24 +23,53 @@ func (l *LikeModel) removePostLike(postId, userId int64) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := l.DB.ExecContext(ctx, query, postId, userId)
+	tx, err := l.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.ExecContext(ctx, query, postId, userId)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	affected, err := rows.RowsAffected()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if affected == 0 {
-		return ErrRecordNotFound
+		return 0, data.ErrRecordNotFound
+	}
+
+	query = `
+		update posts set total_likes = greatest(total_likes - 1, 0)
+		where id = $1
+		returning total_likes, user_id, now()
+	`
+
+	var totalLikes, ownerId int64
+	var unlikedAt time.Time
+	err = tx.QueryRowContext(ctx, query, postId).Scan(&totalLikes, &ownerId, &unlikedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	err = enqueuePostUnlike(ctx, tx, postId, ownerId, userId, totalLikes, unlikedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
 	}
 
-	return nil
+	return totalLikes, nil
 }
 
-func (l *LikeModel) removeCommentLike(commentId, userId int64) error {
+func (l *LikeModel) removeCommentLike(commentId, userId int64) (int64, error) {
 	query := `
 		delete from comment_likes
 		where comment_id = $1 and user_id = $2
@@ -45,19 +78,48 @@ func (l *LikeModel) removeCommentLike(commentId, userId int64) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := l.DB.ExecContext(ctx, query, commentId, userId)
+	tx, err := l.DB.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.ExecContext(ctx, query, commentId, userId)
+	if err != nil {
+		return 0, err
 	}
 
 	affected, err := rows.RowsAffected()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if affected == 0 {
-		return ErrRecordNotFound
+		return 0, data.ErrRecordNotFound
+	}
+
+	query = `
+		update comments set total_likes = greatest(total_likes - 1, 0)
+		where id = $1
+		returning total_likes, user_id, post_id, now()
+	`
+
+	var totalLikes, ownerId, postId int64
+	var unlikedAt time.Time
+	err = tx.QueryRowContext(ctx, query, commentId).Scan(&totalLikes, &ownerId, &postId, &unlikedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	err = enqueueCommentUnlike(ctx, tx, postId, commentId, ownerId, userId, totalLikes, unlikedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
 	}
 
-	return nil
+	return totalLikes, nil
 }
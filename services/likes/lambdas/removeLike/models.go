@@ -2,11 +2,6 @@ package main
 
 import (
 	"database/sql"
-	"errors"
-)
-
-var (
-	ErrRecordNotFound = errors.New("record not found")
 )
 
 type Models struct {
@@ -2,7 +2,6 @@ import { CfnOutput, Tags } from 'aws-cdk-lib';
 import { Construct } from "constructs";
 import { RestApi, LambdaIntegration } from "aws-cdk-lib/aws-apigateway";
 import { Bucket } from 'aws-cdk-lib/aws-s3';
-import * as events from 'aws-cdk-lib/aws-events';
 import { createLambda } from '../../../lib/lambda';
 import * as path from "path"
 
@@ -20,18 +19,21 @@ enum LikeRoute {
 
 interface LikesProps {
 	db_url?: string
-	eventBus: events.EventBus
+	session_secret?: string
 }
 
 export class Likes extends Construct {
 	constructor(scope: Construct, id: string, props: LikesProps) {
 		super(scope, id);
-		const { eventBus } = props
 
 		if (!props.db_url) {
 			throw new Error("DB env var is not set")
 		}
 
+		if (!props.session_secret) {
+			throw new Error("SESSION_SECRET env var is not set")
+		}
+
 		const hotReloadBucket = Bucket.fromBucketName(
 			this,
 			"HotReloadingBucket",
@@ -43,16 +45,15 @@ export class Likes extends Construct {
 			"postLikes",
 			path.join(__dirname, "../lambdas/postLike"),
 			hotReloadBucket,
-			{ DB_ADDRESS: props.db_url, BUS_NAME: eventBus.eventBusName },
+			{ DB_ADDRESS: props.db_url, SESSION_SECRET: props.session_secret },
 		)
-		eventBus.grantPutEventsTo(postLikes)
 
 		const getLikes = createLambda(
 			this,
 			"getLikes",
 			path.join(__dirname, "../lambdas/getLikes"),
 			hotReloadBucket,
-			{ DB_ADDRESS: props.db_url },
+			{ DB_ADDRESS: props.db_url, SESSION_SECRET: props.session_secret },
 		)
 
 		const removeLike = createLambda(
@@ -60,7 +61,7 @@ export class Likes extends Construct {
 			"removeLike",
 			path.join(__dirname, "../lambdas/removeLike"),
 			hotReloadBucket,
-			{ DB_ADDRESS: props.db_url },
+			{ DB_ADDRESS: props.db_url, SESSION_SECRET: props.session_secret },
 		)
 
 		const api = new RestApi(this, "likesapi", {
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"events/session"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	TOKEN_PARAM = "token"
+	CSRF_PARAM  = "csrf"
+)
+
+var (
+	ErrMissingToken = errors.New("missing session token")
+	ErrInvalidCsrf  = errors.New("missing or invalid csrf token")
+)
+
+// the session token of a $connect request. Browsers can not set headers on a
+// websocket handshake so it comes as ?token= or as the session cookie. The
+// cookie is sent along from any page that opens a socket, so like any other
+// request authenticated by the cookie it has to carry the csrf token, as
+// ?csrf= here
+func tokenFromEvent(event events.APIGatewayWebsocketProxyRequest) (string, error) {
+	if token := event.QueryStringParameters[TOKEN_PARAM]; token != "" {
+		return token, nil
+	}
+
+	header := make(http.Header)
+	for name, values := range event.MultiValueHeaders {
+		for _, value := range values {
+			header.Add(name, value)
+		}
+	}
+
+	if len(event.MultiValueHeaders) == 0 {
+		for name, value := range event.Headers {
+			header.Add(name, value)
+		}
+	}
+
+	cookie := session.FindCookie(&http.Request{Header: header}, session.SESSION_COOKIE)
+	if cookie == nil || cookie.Value == "" {
+		return "", ErrMissingToken
+	}
+
+	csrf := event.QueryStringParameters[CSRF_PARAM]
+	if csrf == "" || !session.CheckCsrfToken(cookie.Value, csrf) {
+		return "", ErrInvalidCsrf
+	}
+
+	return cookie.Value, nil
+}
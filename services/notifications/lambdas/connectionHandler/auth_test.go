@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"events/session"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestTokenFromEvent(t *testing.T) {
+	t.Setenv("SESSION_SECRET", "test-secret")
+
+	const token = "session-token"
+	cookie := session.SESSION_COOKIE + "=" + token
+	csrf := session.MakeCsrfToken(token)
+
+	tests := []struct {
+		name    string
+		query   map[string]string
+		headers map[string]string
+		multi   map[string][]string
+		want    string
+		err     error
+	}{
+		{
+			name:  "query param",
+			query: map[string]string{TOKEN_PARAM: token},
+			want:  token,
+		},
+		{
+			name:    "cookie with csrf",
+			query:   map[string]string{CSRF_PARAM: csrf},
+			headers: map[string]string{"cookie": "other=1; " + cookie},
+			want:    token,
+		},
+		{
+			name:  "multi value cookie header",
+			query: map[string]string{CSRF_PARAM: csrf},
+			multi: map[string][]string{"Cookie": {cookie}},
+			want:  token,
+		},
+		{
+			name:    "cookie without csrf",
+			headers: map[string]string{"Cookie": cookie},
+			err:     ErrInvalidCsrf,
+		},
+		{
+			name:    "cookie with csrf of another session",
+			query:   map[string]string{CSRF_PARAM: session.MakeCsrfToken("other-token")},
+			headers: map[string]string{"Cookie": cookie},
+			err:     ErrInvalidCsrf,
+		},
+		{
+			name:    "x-user-id is not trusted",
+			headers: map[string]string{"x-user-id": "1"},
+			err:     ErrMissingToken,
+		},
+		{
+			name: "nothing",
+			err:  ErrMissingToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tokenFromEvent(events.APIGatewayWebsocketProxyRequest{
+				QueryStringParameters: tt.query,
+				Headers:               tt.headers,
+				MultiValueHeaders:     tt.multi,
+			})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+
+			if got != tt.want {
+				t.Fatalf("got token %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,24 +1,40 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
+	"events/common/data"
+	"events/common/realtime"
+	"events/session"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
+	awsSession "github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
+// the table is keyed by userId so $disconnect, which only knows the
+// connection, finds the row through this index
+const CONNECTION_INDEX = "byConnectionId"
+
+// a connection row lives as long as the socket can, the same as the
+// subscriptions made over it, so neither outlives the other
+const CONNECTION_TTL = realtime.SUBSCRIPTION_TTL
+
 type DynamoClient struct {
-	db *dynamodb.DynamoDB
+	db    dynamodbiface.DynamoDBAPI
+	table string
 }
 
 func NewDymanoDbClient() *DynamoClient {
-	session := session.Must(session.NewSession())
+	session := awsSession.Must(awsSession.NewSession())
 	// TODO separate dev and prod configs
 	db := dynamodb.New(session, aws.NewConfig().
 		WithRegion("us-east-1").
@@ -26,106 +42,153 @@ func NewDymanoDbClient() *DynamoClient {
 	)
 
 	return &DynamoClient{
-		db: db,
+		db:    db,
+		table: os.Getenv("TABLE_NAME"),
 	}
 }
 
-func (c *DynamoClient) PutConn(
-	event events.APIGatewayWebsocketProxyRequest,
-) events.APIGatewayV2HTTPResponse {
-	tableName := os.Getenv("TABLE_NAME")
-
-	// temporary hack to add userId to the connection
-	// once rest of logic is confirmed, use cookies from session auth
-	// and check that user has a session based on the cookies
-	connectionUserId, ok := event.Headers["x-user-id"]
-	if !ok {
-		return events.APIGatewayV2HTTPResponse{
-			StatusCode: http.StatusBadRequest,
-			Body:       "Missing x-user-id header",
-		}
+func (c *DynamoClient) PutConn(ctx context.Context, connId string, userId int64) error {
+	expiresAt := time.Now().Add(CONNECTION_TTL)
+	item := &dynamodb.PutItemInput{
+		TableName: aws.String(c.table),
+		Item: map[string]*dynamodb.AttributeValue{
+			"connectionId": {
+				S: aws.String(connId),
+			},
+			"ttl": {
+				N: aws.String(fmt.Sprintf("%d", expiresAt.Unix())),
+			},
+			"userId": {
+				N: aws.String(strconv.FormatInt(userId, 10)),
+			},
+		},
 	}
 
-	connId := event.RequestContext.ConnectionID
-	eventType := event.RequestContext.EventType
-	if eventType == "CONNECT" {
-		oneHourFromNow := time.Now().Add(1 * time.Hour)
-		item := &dynamodb.PutItemInput{
-			TableName: aws.String(tableName),
-			Item: map[string]*dynamodb.AttributeValue{
-				"connectionId": {
-					S: aws.String(connId),
-				},
-				"ttl": {
-					N: aws.String(fmt.Sprintf("%d", oneHourFromNow.Unix())),
-				},
-				"userId": {
-					N: aws.String(connectionUserId),
-				},
-			},
-		}
+	_, err := c.db.PutItemWithContext(ctx, item)
+	return err
+}
 
-		_, err := c.db.PutItem(item)
+// removes every row of connId, whoever it belonged to
+func (c *DynamoClient) DeleteConn(ctx context.Context, connId string) error {
+	query := &dynamodb.QueryInput{
+		TableName:              aws.String(c.table),
+		IndexName:              aws.String(CONNECTION_INDEX),
+		KeyConditionExpression: aws.String("connectionId = :connectionId"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":connectionId": {S: aws.String(connId)},
+		},
+	}
+
+	for {
+		out, err := c.db.QueryWithContext(ctx, query)
 		if err != nil {
-			return events.APIGatewayV2HTTPResponse{
-				StatusCode: http.StatusInternalServerError,
-				Body:       err.Error(),
+			return err
+		}
+
+		for _, item := range out.Items {
+			_, err = c.db.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+				TableName: aws.String(c.table),
+				Key: map[string]*dynamodb.AttributeValue{
+					"connectionId": item["connectionId"],
+					"userId":       item["userId"],
+				},
+			})
+			if err != nil {
+				return err
 			}
 		}
 
-		return events.APIGatewayV2HTTPResponse{
-			StatusCode: http.StatusOK,
-			Body:       connId,
+		if len(out.LastEvaluatedKey) == 0 {
+			return nil
 		}
+		query.ExclusiveStartKey = out.LastEvaluatedKey
 	}
+}
 
-	if eventType == "DISCONNECT" {
-		item := &dynamodb.DeleteItemInput{
-			TableName: aws.String(tableName),
-			Key: map[string]*dynamodb.AttributeValue{
-				"connectionId": {
-					S: aws.String(connId),
-				},
-				"userId": {
-					N: aws.String(connectionUserId),
-				},
-			},
-		}
-		_, err := c.db.DeleteItem(item)
+type App struct {
+	db            *DynamoClient
+	subscriptions realtime.SubscriptionModel
+	sessions      session.SessionModel
+}
+
+func (app *App) handler(
+	ctx context.Context,
+	event events.APIGatewayWebsocketProxyRequest,
+) (events.APIGatewayV2HTTPResponse, error) {
+	connId := event.RequestContext.ConnectionID
+
+	switch event.RequestContext.EventType {
+	case "CONNECT":
+		return app.connect(ctx, event), nil
+
+	case "DISCONNECT":
+		err := app.db.DeleteConn(ctx, connId)
 		if err != nil {
 			fmt.Printf("Unable to delete connectionId from dynamo:\n %s\n", err.Error())
-			return events.APIGatewayV2HTTPResponse{
-				StatusCode: http.StatusInternalServerError,
-				Body:       err.Error(),
-			}
+			return response(http.StatusInternalServerError, err.Error()), nil
 		}
 
-		return events.APIGatewayV2HTTPResponse{
-			StatusCode: http.StatusOK,
-			Body:       "Disconnected.",
+		err = app.subscriptions.DeleteConnection(ctx, connId)
+		if err != nil {
+			fmt.Printf("Unable to delete subscriptions of connectionId from dynamo:\n %s\n", err.Error())
+			return response(http.StatusInternalServerError, err.Error()), nil
 		}
-	}
 
-	return events.APIGatewayV2HTTPResponse{
-		StatusCode: http.StatusOK,
-		Body:       "Ok.",
+		return response(http.StatusOK, "Disconnected."), nil
 	}
+
+	return response(http.StatusOK, "Ok."), nil
 }
 
-type App struct {
-	db *DynamoClient
+// a non 2xx response makes API Gateway refuse the connection
+func (app *App) connect(ctx context.Context, event events.APIGatewayWebsocketProxyRequest) events.APIGatewayV2HTTPResponse {
+	token, err := tokenFromEvent(event)
+	if err != nil {
+		return response(http.StatusUnauthorized, err.Error())
+	}
+
+	user, err := app.sessions.GetUserForToken(token)
+	if err != nil {
+		switch {
+		case errors.Is(err, session.ErrSessionNotFound):
+			return response(http.StatusUnauthorized, "invalid or expired session token")
+		default:
+			fmt.Printf("Could not look up session: %s\n", err.Error())
+			return response(http.StatusInternalServerError, "the server could not process your request")
+		}
+	}
+
+	connId := event.RequestContext.ConnectionID
+	err = app.db.PutConn(ctx, connId, user.Id)
+	if err != nil {
+		return response(http.StatusInternalServerError, err.Error())
+	}
+
+	return response(http.StatusOK, connId)
 }
 
-func (app *App) handler(
-	event events.APIGatewayWebsocketProxyRequest,
-) (events.APIGatewayV2HTTPResponse, error) {
-	return app.db.PutConn(event), nil
+func response(status int, body string) events.APIGatewayV2HTTPResponse {
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: status,
+		Body:       body,
+	}
 }
 
 func main() {
-	dbClient := NewDymanoDbClient()
+	pgDb, err := data.OpenDB(os.Getenv("DB_ADDRESS"))
+	if err != nil {
+		fmt.Printf("Could not open db: %s\n", err.Error())
+		return
+	}
+
+	dynamo := NewDymanoDbClient()
 	app := &App{
-		db: dbClient,
+		db: dynamo,
+		subscriptions: realtime.SubscriptionModel{
+			DB:    dynamo.db,
+			Table: os.Getenv("SUBSCRIPTIONS_TABLE_NAME"),
+		},
+		sessions: session.SessionModel{DB: pgDb},
 	}
 	lambda.Start(app.handler)
 }
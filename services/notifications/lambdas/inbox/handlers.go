@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"events/common/api"
+	"events/common/cursor"
+	"events/common/data"
+	"events/notifications/models"
+	"events/session"
+)
+
+func (app *app) listNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	filters, err := cursor.ReadFilters(r.URL.Query())
+	if err != nil {
+		api.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := session.ContextGetUser(r)
+	notifications, metadata, err := app.models.Notifications.List(user.Id, filters)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	env := api.Envelope{"notifications": notifications, "metadata": metadata}
+	err = api.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+	}
+}
+
+// answers with what is left unread so the badge can be updated without
+// another request
+func (app *app) markReadHandler(w http.ResponseWriter, r *http.Request) {
+	var input models.ReadRequest
+	err := api.ReadJSON(w, r, &input)
+	if err != nil {
+		api.BadRequestResponse(w, r, err)
+		return
+	}
+
+	err = input.Validate()
+	if err != nil {
+		api.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := session.ContextGetUser(r)
+	if input.All {
+		_, err = app.models.Notifications.MarkAllRead(user.Id)
+	} else {
+		err = app.models.Notifications.MarkRead(user.Id, *input.Id)
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			api.NotFoundResponse(w, r)
+		default:
+			api.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.writeUnreadCount(w, r, user.Id)
+}
+
+func (app *app) unreadCountHandler(w http.ResponseWriter, r *http.Request) {
+	user := session.ContextGetUser(r)
+	app.writeUnreadCount(w, r, user.Id)
+}
+
+func (app *app) writeUnreadCount(w http.ResponseWriter, r *http.Request, userId int64) {
+	count, err := app.models.Notifications.UnreadCount(userId)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = api.WriteJSON(w, http.StatusOK, api.Envelope{"unread_count": count}, nil)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+	}
+}
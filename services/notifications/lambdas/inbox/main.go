@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+
+	"events/common/api"
+	"events/common/data"
+	"events/notifications/models"
+	"events/session"
+	"github.com/go-chi/chi/v5"
+)
+
+var router *chi.Mux
+
+type app struct {
+	models models.Models
+}
+
+func init() {
+	db, err := data.OpenDB(os.Getenv("DB_ADDRESS"))
+	if err != nil {
+		panic(err)
+	}
+
+	app := app{models: models.NewModels(db)}
+	r := api.NewRouter()
+	sessionModel := session.SessionModel{DB: db}
+	r.Use(sessionModel.Authenticate)
+	r.Route("/notifications", func(r chi.Router) {
+		r.Get("/healthcheck", api.HealthcheckHandler)
+		r.With(session.RequireAuthenticatedUser).Get("/", app.listNotificationsHandler)
+		r.With(session.RequireAuthenticatedUser).Post("/read", app.markReadHandler)
+		r.With(session.RequireAuthenticatedUser).Get("/unread-count", app.unreadCountHandler)
+	})
+
+	router = r
+}
+
+func main() {
+	api.Start(router)
+}
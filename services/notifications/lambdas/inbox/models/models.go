@@ -0,0 +1,15 @@
+package models
+
+import (
+	"database/sql"
+)
+
+type Models struct {
+	Notifications NotificationModel
+}
+
+func NewModels(db *sql.DB) Models {
+	return Models{
+		Notifications: NotificationModel{DB: db},
+	}
+}
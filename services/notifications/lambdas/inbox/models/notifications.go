@@ -0,0 +1,161 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"events/common/cursor"
+	"events/common/data"
+)
+
+type NotificationModel struct {
+	DB *sql.DB
+}
+
+type Actor struct {
+	Id             int64  `json:"id"`
+	Username       string `json:"username"`
+	ProfilePicture string `json:"profile_picture"`
+}
+
+// Notification is one inbox entry, Type and Payload are the event it was
+// written for so clients render them the same as the websocket pushes
+type Notification struct {
+	Id        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Actor     Actor           `json:"actor"`
+	PostId    int64           `json:"post_id"`
+	CommentId int64           `json:"comment_id,omitempty"`
+	Payload   json.RawMessage `json:"payload"`
+	Read      bool            `json:"read"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// newest first
+func (m *NotificationModel) List(userId int64, filters cursor.Filters) ([]Notification, cursor.Metadata, error) {
+	scan := filters.ScanOrder(cursor.DESC)
+	query := fmt.Sprintf(`
+	SELECT notification.id, notification.type, notification.post_id, notification.comment_id,
+	notification.payload, notification.read_at IS NOT NULL, notification.created_at,
+	users.id, users.username, COALESCE(users.profile_picture, '')
+	FROM notifications AS notification
+	JOIN users ON users.id = notification.actor_id
+	WHERE notification.user_id = $1
+	AND ($4 OR (notification.created_at, notification.id) %s ($2, $3))
+	ORDER BY notification.created_at %s, notification.id %s
+	LIMIT $5
+	`, filters.Compare(cursor.DESC), scan, scan)
+
+	metadata := cursor.Metadata{}
+	notifications := []Notification{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	createdAt, id, first := filters.Args()
+	rows, err := m.DB.QueryContext(ctx, query, userId, createdAt, id, first, filters.Limit())
+	if err != nil {
+		return nil, metadata, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var n Notification
+		var commentId sql.NullInt64
+		var payload []byte
+
+		err := rows.Scan(
+			&n.Id,
+			&n.Type,
+			&n.PostId,
+			&commentId,
+			&payload,
+			&n.Read,
+			&n.CreatedAt,
+			&n.Actor.Id,
+			&n.Actor.Username,
+			&n.Actor.ProfilePicture,
+		)
+		if err != nil {
+			return nil, metadata, err
+		}
+
+		n.CommentId = commentId.Int64
+		n.Payload = payload
+		notifications = append(notifications, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, metadata, err
+	}
+
+	notifications, metadata = cursor.Paginate(filters, notifications, func(n Notification) (time.Time, int64) {
+		return n.CreatedAt, n.Id
+	})
+
+	return notifications, metadata, nil
+}
+
+// marking a read notification again keeps when it was first read, someone
+// else's notification is not found
+func (m *NotificationModel) MarkRead(userId, id int64) error {
+	query := `
+	UPDATE notifications SET read_at = COALESCE(read_at, now())
+	WHERE id = $1 AND user_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, userId)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return data.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// returns how many were unread
+func (m *NotificationModel) MarkAllRead(userId int64) (int64, error) {
+	query := `
+	UPDATE notifications SET read_at = now()
+	WHERE user_id = $1 AND read_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (m *NotificationModel) UnreadCount(userId int64) (int64, error) {
+	query := `
+	SELECT COUNT(*) FROM notifications
+	WHERE user_id = $1 AND read_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var count int64
+	err := m.DB.QueryRowContext(ctx, query, userId).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,24 @@
+package models
+
+import "errors"
+
+var ErrInvalidReadRequest = errors.New(`mark one notification with "id" or every one with "all": true`)
+
+// ReadRequest is the body of POST /notifications/read, it names exactly one
+// notification or asks for all of them
+type ReadRequest struct {
+	Id  *int64 `json:"id"`
+	All bool   `json:"all"`
+}
+
+func (r ReadRequest) Validate() error {
+	if r.All == (r.Id != nil) {
+		return ErrInvalidReadRequest
+	}
+
+	if r.Id != nil && *r.Id < 1 {
+		return ErrInvalidReadRequest
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReadRequestValidate(t *testing.T) {
+	id := func(v int64) *int64 { return &v }
+
+	tests := []struct {
+		name string
+		req  ReadRequest
+		err  error
+	}{
+		{"one", ReadRequest{Id: id(3)}, nil},
+		{"all", ReadRequest{All: true}, nil},
+		{"neither", ReadRequest{}, ErrInvalidReadRequest},
+		{"both", ReadRequest{Id: id(3), All: true}, ErrInvalidReadRequest},
+		{"bad id", ReadRequest{Id: id(0)}, ErrInvalidReadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
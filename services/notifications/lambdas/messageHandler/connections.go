@@ -1,111 +1,180 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"strconv"
+	"sync"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
 )
 
+// users whose connections are queried at the same time, every user is their
+// own partition so the queries do not compete with each other
+const QUERY_CONCURRENCY = 8
+
 type NotificationRow struct {
-	ConnectionId string
-	UserId       int64
+	ConnectionId string `dynamodbav:"connectionId"`
+	UserId       int64  `dynamodbav:"userId"`
 }
 
-func (app *App) getConnectionsForPost(senderUserId int64) (*[]NotificationRow, error) {
-	friendIds, err := app.models.SocialConns.GetFriendsForUser(senderUserId)
-	if err != nil {
-		fmt.Printf("Could not get friends for user: %d\n", senderUserId)
-		return nil, err
-	}
-
-	var filter expression.ConditionBuilder
-	if len(friendIds) == 1 {
-		filter = expression.Name("userId").Equal(expression.Value(friendIds[0]))
-	} else {
-		var expressionRows []expression.OperandBuilder
-		for _, id := range friendIds {
-			expressionRows = append(expressionRows, expression.Value(id))
-		}
-
-		filter = expression.Name("userId").
-			In(expression.Value(expressionRows[0]), expressionRows...)
-	}
+// ConnectionModel reads the open websocket connections, the table is keyed by
+// userId with one item per connectionId as a person might be connected on
+// many tabs and there could be old and new connections mixed
+type ConnectionModel struct {
+	DB    dynamodbiface.DynamoDBAPI
+	Table string
+	// caps the items a single Query returns, 0 leaves it to DynamoDBs 1MB
+	// pages
+	PageSize int64
+}
 
+func (m *ConnectionModel) ForUser(ctx context.Context, userId int64) ([]NotificationRow, error) {
+	keyCond := expression.Key("userId").Equal(expression.Value(userId))
 	toGet := expression.NamesList(
 		expression.Name("connectionId"),
 		expression.Name("userId"),
 	)
 
-	expr, err := expression.NewBuilder().WithFilter(filter).WithProjection(toGet).Build()
+	expr, err := expression.NewBuilder().
+		WithKeyCondition(keyCond).
+		WithProjection(toGet).
+		Build()
 	if err != nil {
-		fmt.Printf("Got error building expression: %s\n", err.Error())
 		return nil, err
 	}
 
-	allClients, err := app.dynamo.Scan(&dynamodb.ScanInput{
-		TableName:                 aws.String(os.Getenv("TABLE_NAME")),
-		FilterExpression:          expr.Filter(),
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(m.Table),
+		KeyConditionExpression:    expr.KeyCondition(),
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
 		ProjectionExpression:      expr.Projection(),
-	})
+	}
 
-	if err != nil {
-		fmt.Printf("Got error calling Scan: %s\n", err.Error())
-		return nil, err
+	if m.PageSize > 0 {
+		input.Limit = aws.Int64(m.PageSize)
 	}
 
-	var rows []NotificationRow
+	rows := []NotificationRow{}
+	for {
+		out, err := m.DB.QueryWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
 
-	err = dynamodbattribute.UnmarshalListOfMaps(allClients.Items, &rows)
-	if err != nil {
-		fmt.Printf("Got error unmarshalling: %s\n", err.Error())
-		return nil, err
-	}
+		var page []NotificationRow
+		err = dynamodbattribute.UnmarshalListOfMaps(out.Items, &page)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, page...)
 
-	return &rows, nil
+		if len(out.LastEvaluatedKey) == 0 {
+			return rows, nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
 }
 
-func (app *App) getAuthorConnection(authorId int64) (*[]NotificationRow, error) {
-	filter := expression.Name("userId").Equal(expression.Value(authorId))
-
-	toGet := expression.NamesList(
-		expression.Name("connectionId"),
-		expression.Name("userId"),
+// connections of every user in userIds, with at most QUERY_CONCURRENCY
+// queries in flight. The first failing query cancels the rest
+func (m *ConnectionModel) ForUsers(ctx context.Context, userIds []int64) ([]NotificationRow, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		rows     = []NotificationRow{}
+		firstErr error
 	)
 
-	expr, err := expression.NewBuilder().WithFilter(filter).WithProjection(toGet).Build()
-	if err != nil {
-		fmt.Printf("Got error building expression: %s\n", err.Error())
-		return nil, err
+	sem := make(chan struct{}, QUERY_CONCURRENCY)
+	seen := make(map[int64]bool, len(userIds))
+	for _, userId := range userIds {
+		if seen[userId] {
+			continue
+		}
+		seen[userId] = true
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(userId int64) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			conns, err := m.ForUser(ctx, userId)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("querying connections of user %d: %w", userId, err)
+					cancel()
+				}
+				return
+			}
+			rows = append(rows, conns...)
+		}(userId)
 	}
 
-	// get many as a person might be connected on many tabs and there could be
-	// old and new connections mixed
-	possibleClients, err := app.dynamo.Scan(&dynamodb.ScanInput{
-		TableName:                 aws.String(os.Getenv("TABLE_NAME")),
-		FilterExpression:          expr.Filter(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		ProjectionExpression:      expr.Projection(),
-	})
+	wg.Wait()
 
-	if err != nil {
-		fmt.Printf("Got error calling Scan: %s\n", err.Error())
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	var rows []NotificationRow
+	return rows, nil
+}
 
-	err = dynamodbattribute.UnmarshalListOfMaps(possibleClients.Items, &rows)
+// the connections of everyone following the sender
+func (app *App) getConnectionsForPost(ctx context.Context, senderUserId int64) ([]NotificationRow, error) {
+	friendIds, err := app.models.SocialConns.GetFriendsForUser(senderUserId)
 	if err != nil {
-		fmt.Printf("Got error unmarshalling: %s\n", err.Error())
+		fmt.Printf("Could not get friends for user: %d\n", senderUserId)
 		return nil, err
 	}
 
-	return &rows, nil
+	return app.models.Connections.ForUsers(ctx, friendIds)
+}
+
+func (app *App) getAuthorConnection(ctx context.Context, authorId int64) ([]NotificationRow, error) {
+	return app.models.Connections.ForUser(ctx, authorId)
+}
+
+// the connections of everyone in authorIds, someone who is more than one of
+// them is only reached once
+func (app *App) getAuthorsConnections(ctx context.Context, authorIds ...int64) ([]NotificationRow, error) {
+	return app.models.Connections.ForUsers(ctx, authorIds)
+}
+
+// drops a connection API Gateway no longer knows about, it went away without
+// the $disconnect route cleaning it up
+func (m *ConnectionModel) Delete(ctx context.Context, row NotificationRow) error {
+	_, err := m.DB.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(m.Table),
+		Key: map[string]*dynamodb.AttributeValue{
+			"userId":       {N: aws.String(strconv.FormatInt(row.UserId, 10))},
+			"connectionId": {S: aws.String(row.ConnectionId)},
+		},
+	})
+
+	return err
 }
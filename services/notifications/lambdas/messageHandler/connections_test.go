@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// runs against the DynamoDB of a local localstack, `make start` at the root
+// of the repo starts one
+func newTestConnections(t *testing.T) *ConnectionModel {
+	t.Helper()
+
+	endpoint := os.Getenv("LOCALSTACK_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "http://localhost:4566"
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		t.Fatalf("parsing LOCALSTACK_ENDPOINT: %v", err)
+	}
+
+	conn, err := net.DialTimeout("tcp", u.Host, time.Second)
+	if err != nil {
+		t.Skipf("localstack is not reachable at %s: %v", endpoint, err)
+	}
+	conn.Close()
+
+	sess := session.Must(session.NewSession())
+	db := dynamodb.New(sess, aws.NewConfig().
+		WithRegion("us-east-1").
+		WithEndpoint(endpoint).
+		WithCredentials(credentials.NewStaticCredentials("test", "test", "")),
+	)
+
+	// the same keys as the notifications table in the cdk stack
+	table := fmt.Sprintf("notifications-test-%d", time.Now().UnixNano())
+	_, err = db.CreateTable(&dynamodb.CreateTableInput{
+		TableName:   aws.String(table),
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("userId"), AttributeType: aws.String("N")},
+			{AttributeName: aws.String("connectionId"), AttributeType: aws.String("S")},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("userId"), KeyType: aws.String("HASH")},
+			{AttributeName: aws.String("connectionId"), KeyType: aws.String("RANGE")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("creating table: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.DeleteTable(&dynamodb.DeleteTableInput{TableName: aws.String(table)})
+	})
+
+	return &ConnectionModel{DB: db, Table: table}
+}
+
+func putConnection(t *testing.T, m *ConnectionModel, userId int64, connectionId string) {
+	t.Helper()
+
+	_, err := m.DB.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(m.Table),
+		Item: map[string]*dynamodb.AttributeValue{
+			"userId":       {N: aws.String(fmt.Sprint(userId))},
+			"connectionId": {S: aws.String(connectionId)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("putting connection: %v", err)
+	}
+}
+
+func connectionIds(rows []NotificationRow) []string {
+	ids := make([]string, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ConnectionId
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestForUserPages(t *testing.T) {
+	m := newTestConnections(t)
+	m.PageSize = 2
+
+	want := []string{}
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("conn-%d", i)
+		putConnection(t, m, 1, id)
+		want = append(want, id)
+	}
+	putConnection(t, m, 2, "someone-else")
+
+	rows, err := m.ForUser(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("querying connections: %v", err)
+	}
+
+	got := connectionIds(rows)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	for _, row := range rows {
+		if row.UserId != 1 {
+			t.Fatalf("got a connection of user %d", row.UserId)
+		}
+	}
+}
+
+func TestForUsersPastOperandLimit(t *testing.T) {
+	m := newTestConnections(t)
+
+	// a Scan with an IN filter stops working past 100 operands
+	userIds := []int64{}
+	want := []string{}
+	for userId := int64(1); userId <= 150; userId++ {
+		userIds = append(userIds, userId)
+		if userId%10 == 0 {
+			id := fmt.Sprintf("conn-%03d", userId)
+			putConnection(t, m, userId, id)
+			want = append(want, id)
+		}
+	}
+	putConnection(t, m, 999, "not-a-friend")
+
+	// duplicates are only queried once
+	userIds = append(userIds, 10, 20)
+
+	rows, err := m.ForUsers(context.Background(), userIds)
+	if err != nil {
+		t.Fatalf("querying connections: %v", err)
+	}
+
+	got := connectionIds(rows)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestForUsersWithoutFriends(t *testing.T) {
+	m := newTestConnections(t)
+	putConnection(t, m, 1, "conn")
+
+	rows, err := m.ForUsers(context.Background(), []int64{})
+	if err != nil {
+		t.Fatalf("querying connections: %v", err)
+	}
+
+	if len(rows) != 0 {
+		t.Fatalf("got %d connections, want none", len(rows))
+	}
+}
+
+func TestForUsersFails(t *testing.T) {
+	m := newTestConnections(t)
+	m.Table = m.Table + "-missing"
+
+	_, err := m.ForUsers(context.Background(), []int64{1, 2, 3})
+	if err == nil {
+		t.Fatal("expected querying a missing table to fail")
+	}
+}
+
+func TestCommentChangesReachBothAuthors(t *testing.T) {
+	m := newTestConnections(t)
+	putConnection(t, m, 1, "post-author")
+	putConnection(t, m, 2, "comment-author")
+	putConnection(t, m, 3, "someone-else")
+
+	app := &App{models: Models{Connections: *m}}
+
+	rows, err := app.getAuthorsConnections(context.Background(), 1, 2)
+	if err != nil {
+		t.Fatalf("querying connections: %v", err)
+	}
+
+	got := connectionIds(rows)
+	want := []string{"comment-author", "post-author"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// commenting on your own post
+	rows, err = app.getAuthorsConnections(context.Background(), 1, 1)
+	if err != nil {
+		t.Fatalf("querying connections: %v", err)
+	}
+
+	if len(rows) != 1 {
+		t.Fatalf("got %d connections, want the author's one", len(rows))
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/apigatewaymanagementapi"
+)
+
+// messages posted to connections at the same time
+const SEND_CONCURRENCY = 16
+
+// Delivery counts what happened to one event, Gone connections had closed
+// without us noticing and were removed from the table
+type Delivery struct {
+	Delivered int
+	Failed    int
+	Gone      int
+}
+
+func (d *Delivery) add(other Delivery) {
+	d.Delivered += other.Delivered
+	d.Failed += other.Failed
+	d.Gone += other.Gone
+}
+
+// posts msg to every connection and waits for all of them, a lambda that
+// returns before its sends finish can be frozen with them half done
+func (app *App) deliver(ctx context.Context, conns []NotificationRow, msg []byte) Delivery {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		delivery Delivery
+	)
+
+	sem := make(chan struct{}, SEND_CONCURRENCY)
+	for _, conn := range conns {
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func(conn NotificationRow) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := app.send(ctx, conn, msg)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				delivery.Delivered++
+			case errors.Is(err, errGone):
+				delivery.Gone++
+			default:
+				delivery.Failed++
+				fmt.Printf("Could not send a msg to conn %s: %s\n", conn.ConnectionId, err.Error())
+			}
+		}(conn)
+	}
+
+	wg.Wait()
+	return delivery
+}
+
+var errGone = errors.New("connection is gone")
+
+func (app *App) send(ctx context.Context, conn NotificationRow, msg []byte) error {
+	_, err := app.gw.PostToConnectionWithContext(ctx, &apigatewaymanagementapi.PostToConnectionInput{
+		ConnectionId: aws.String(conn.ConnectionId),
+		Data:         msg,
+	})
+
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) || awsErr.Code() != apigatewaymanagementapi.ErrCodeGoneException {
+		return err
+	}
+
+	err = app.models.Connections.Delete(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("deleting gone connection: %w", err)
+	}
+
+	err = app.models.Subscriptions.DeleteConnection(ctx, conn.ConnectionId)
+	if err != nil {
+		return fmt.Errorf("deleting subscriptions of gone connection: %w", err)
+	}
+
+	return errGone
+}
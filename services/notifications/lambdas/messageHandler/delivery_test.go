@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"events/common/realtime"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/apigatewaymanagementapi"
+	"github.com/aws/aws-sdk-go/service/apigatewaymanagementapi/apigatewaymanagementapiiface"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// answers by connection id, "gone-" ones are closed and "broken-" ones fail
+type fakeGateway struct {
+	apigatewaymanagementapiiface.ApiGatewayManagementApiAPI
+	inFlight, maxInFlight int32
+}
+
+func (g *fakeGateway) PostToConnectionWithContext(
+	ctx aws.Context,
+	input *apigatewaymanagementapi.PostToConnectionInput,
+	opts ...request.Option,
+) (*apigatewaymanagementapi.PostToConnectionOutput, error) {
+	n := atomic.AddInt32(&g.inFlight, 1)
+	defer atomic.AddInt32(&g.inFlight, -1)
+	for {
+		max := atomic.LoadInt32(&g.maxInFlight)
+		if n <= max || atomic.CompareAndSwapInt32(&g.maxInFlight, max, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+
+	id := aws.StringValue(input.ConnectionId)
+	switch {
+	case len(id) > 5 && id[:5] == "gone-":
+		return nil, awserr.New(apigatewaymanagementapi.ErrCodeGoneException, "gone", nil)
+	case len(id) > 7 && id[:7] == "broken-":
+		return nil, awserr.New(apigatewaymanagementapi.ErrCodeLimitExceededException, "broken", nil)
+	}
+
+	return &apigatewaymanagementapi.PostToConnectionOutput{}, nil
+}
+
+type fakeTable struct {
+	dynamodbiface.DynamoDBAPI
+	mu      sync.Mutex
+	deleted []string
+}
+
+func (f *fakeTable) DeleteItemWithContext(
+	ctx aws.Context,
+	input *dynamodb.DeleteItemInput,
+	opts ...request.Option,
+) (*dynamodb.DeleteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, aws.StringValue(input.Key["userId"].N)+"/"+aws.StringValue(input.Key["connectionId"].S))
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+// the gone connections never subscribed to anything
+func (f *fakeTable) QueryWithContext(ctx aws.Context, input *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+	return &dynamodb.QueryOutput{}, nil
+}
+
+func TestDeliver(t *testing.T) {
+	gw := &fakeGateway{}
+	table := &fakeTable{}
+	app := &App{gw: gw, models: Models{
+		Connections:   ConnectionModel{DB: table, Table: "notifications"},
+		Subscriptions: realtime.SubscriptionModel{DB: table, Table: "subscriptions"},
+	}}
+
+	conns := []NotificationRow{
+		{ConnectionId: "gone-1", UserId: 1},
+		{ConnectionId: "broken-1", UserId: 2},
+		{ConnectionId: "gone-2", UserId: 3},
+	}
+	for i := 0; i < 40; i++ {
+		conns = append(conns, NotificationRow{ConnectionId: "ok", UserId: 4})
+	}
+
+	got := app.deliver(context.Background(), conns, []byte(`{}`))
+	want := Delivery{Delivered: 40, Failed: 1, Gone: 2}
+	if got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	sort.Strings(table.deleted)
+	if len(table.deleted) != 2 || table.deleted[0] != "1/gone-1" || table.deleted[1] != "3/gone-2" {
+		t.Fatalf("deleted %v, want only the gone connections", table.deleted)
+	}
+
+	if gw.maxInFlight > SEND_CONCURRENCY {
+		t.Fatalf("%d sends in flight, want at most %d", gw.maxInFlight, SEND_CONCURRENCY)
+	}
+}
+
+func TestDeliverCountsFailedDeletesAsFailures(t *testing.T) {
+	app := &App{gw: &fakeGateway{}, models: Models{Connections: ConnectionModel{DB: failingTable{}}}}
+
+	got := app.deliver(context.Background(), []NotificationRow{{ConnectionId: "gone-1", UserId: 1}}, nil)
+	if got != (Delivery{Failed: 1}) {
+		t.Fatalf("got %+v, want one failure", got)
+	}
+}
+
+type failingTable struct {
+	dynamodbiface.DynamoDBAPI
+}
+
+func (failingTable) DeleteItemWithContext(aws.Context, *dynamodb.DeleteItemInput, ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	return nil, errors.New("table unavailable")
+}
+
+func TestMergeConnections(t *testing.T) {
+	friends := []NotificationRow{{ConnectionId: "a", UserId: 1}, {ConnectionId: "b", UserId: 2}}
+	subscribers := []NotificationRow{{ConnectionId: "b", UserId: 2}, {ConnectionId: "c", UserId: 3}, {ConnectionId: "c", UserId: 3}}
+
+	got := mergeConnections(friends, subscribers)
+	if len(got) != 3 || got[0].ConnectionId != "a" || got[1].ConnectionId != "b" || got[2].ConnectionId != "c" {
+		t.Fatalf("got %v, want a, b and c once each", got)
+	}
+}
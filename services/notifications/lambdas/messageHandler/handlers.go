@@ -1,170 +1,108 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"time"
 
+	"events/common/domain"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/apigatewaymanagementapi"
 )
 
-const (
-	POST_ADDED_EVENT        = "PostAdded"
-	SUB_COMMENT_ADDED_EVENT = "SubCommentAdded"
-	COMMENT_ADDED_EVENT     = "CommentAdded"
-	POST_LIKE_EVENT         = "PostLike"
-	COMMENT_LIKE_EVENT      = "CommentLike"
-)
-
-type PostAddedEvent struct {
-	PostId    int64     `json:"postId"`
-	UserId    int64     `json:"userId"`
-	EventType string    `json:"eventType"`
-	Username  string    `json:"username"`
-	CreatedAt time.Time `json:"sentAt"`
-}
-
-type CommentAddedEvent struct {
-	PostId             int64     `json:"postId"`
-	CommentId          int64     `json:"commentId"`
-	PostUserId         int64     `json:"postUserId"`
-	CommentUserId      int64     `json:"commentUserId"`
-	CommentUserName    string    `json:"commentUserName"`
-	CommentCreatedAt   time.Time `json:"commentCreatedAt"`
-	CommentBodyPreview string    `json:"commentBody"`
-	EventType          string    `json:"eventType"`
-}
-
-type SubCommentAddedEvent struct {
-	PostId                   int64     `json:"postId"`
-	ParentCommentId          int64     `json:"parentCommentId"`
-	ChildCommentId           int64     `json:"childCommentId"`
-	ParentCommentUserId      int64     `json:"parentCommentUserId"`
-	ChildCommentUserId       int64     `json:"childCommentUserId"`
-	ChildCommentUserUsername string    `json:"childCommentUserUsername"`
-	ChildCommentCreatedAt    time.Time `json:"childCommentCreatedAt"`
-	ChildCommentBodyPreview  string    `json:"childCommentBody"`
-	EventType                string    `json:"eventType"`
-}
-
-type PostLikeEvent struct {
-	PostId         int64     `json:"post_id"`
-	PostUserId     int64     `json:"post_user_id"`
-	PostLikeUserId int64     `json:"post_like_user_id"`
-	EventType      string    `json:"event_type"`
-	LikedAt        time.Time `json:"liked_at"`
-}
-
-type CommentLikeEvent struct {
-	CommentId         int64     `json:"comment_id"`
-	CommentUserId     int64     `json:"comment_user_id"`
-	CommentLikeUserId int64     `json:"comment_like_user_id"`
-	EventType         string    `json:"event_type"`
-	LikedAt           time.Time `json:"liked_at"`
-}
-
-type Event struct {
-	EventType string `json:"eventType"`
-}
-
-func (app *App) handler(event events.CloudWatchEvent) error {
-	var e Event
+func (app *App) handler(ctx context.Context, event events.CloudWatchEvent) error {
+	env, err := domain.Parse(event.Detail)
+	if err != nil {
+		fmt.Printf("Invalid event envelope: %s\n", err.Error())
+		return err
+	}
 
-	err := json.Unmarshal(event.Detail, &e)
+	payload, err := env.Decode()
 	if err != nil {
-		fmt.Printf("Event detail missing EventType!\n")
+		fmt.Printf("Could not decode %s event %s: %s\n", env.Type, env.Id, err.Error())
 		return err
 	}
 
-	var conns *[]NotificationRow
-	switch e.EventType {
-	case POST_ADDED_EVENT:
-		var eventData PostAddedEvent
-		err = json.Unmarshal(event.Detail, &eventData)
-		if err != nil {
-			fmt.Printf("Could not unmarshal event: %v\n", event.Detail)
-			return err
-		}
+	var conns []NotificationRow
+	switch p := payload.(type) {
+	case *domain.PostAdded:
+		conns, err = app.getConnectionsForPost(ctx, p.UserId)
 
-		conns, err = app.getConnectionsForPost(eventData.UserId)
-		if err != nil {
-			fmt.Printf("Could not get connections: %v\n", eventData)
-			return err
+	// nobody is notified about their own comment, the topic subscribers
+	// below still see it
+	case *domain.CommentAdded:
+		if p.CommentUserId != p.PostUserId {
+			conns, err = app.getAuthorConnection(ctx, p.PostUserId)
 		}
 
-	case COMMENT_ADDED_EVENT:
-		var eventData CommentAddedEvent
-		err = json.Unmarshal(event.Detail, &eventData)
-		if err != nil {
-			fmt.Printf("Could not unmarshal event: %v\n", event.Detail)
-			return err
+	case *domain.SubCommentAdded:
+		if p.ChildCommentUserId != p.ParentCommentUserId {
+			conns, err = app.getAuthorConnection(ctx, p.ParentCommentUserId)
 		}
 
-		conns, err = app.getAuthorConnection(eventData.PostUserId)
-		if err != nil {
-			fmt.Printf("Could not get connections: %v\n", eventData)
-			return err
-		}
+	case *domain.PostLiked:
+		conns, err = app.getAuthorConnection(ctx, p.PostUserId)
 
-	case SUB_COMMENT_ADDED_EVENT:
-		var eventData SubCommentAddedEvent
-		err = json.Unmarshal(event.Detail, &eventData)
-		if err != nil {
-			fmt.Printf("Could not unmarshal event: %v\n", event.Detail)
-			return err
-		}
+	case *domain.CommentLiked:
+		conns, err = app.getAuthorConnection(ctx, p.CommentUserId)
 
-		conns, err = app.getAuthorConnection(eventData.ParentCommentUserId)
-		if err != nil {
-			fmt.Printf("Could not get connections: %v\n", eventData)
-			return err
-		}
+	// changes go to whoever was told about the content when it was created,
+	// the feed readers for posts and the post author for comments, so open
+	// views of it can update or drop it. the comment author hears about a
+	// moderator changing their comment, viewers of the post are reached
+	// through their topic subscriptions below
+	case *domain.PostUpdated:
+		conns, err = app.getConnectionsForPost(ctx, p.PostUserId)
 
-	case POST_LIKE_EVENT:
-		var eventData PostLikeEvent
-		err = json.Unmarshal(event.Detail, &eventData)
-		if err != nil {
-			fmt.Printf("Could not unmarshal event: %v\n", event.Detail)
-			return err
-		}
+	case *domain.PostDeleted:
+		conns, err = app.getConnectionsForPost(ctx, p.PostUserId)
 
-		conns, err = app.getAuthorConnection(eventData.PostUserId)
-		if err != nil {
-			fmt.Printf("Could not get connections: %v\n", eventData)
-			return err
-		}
+	case *domain.CommentUpdated:
+		conns, err = app.getAuthorsConnections(ctx, p.PostUserId, p.CommentUserId)
 
-	case COMMENT_LIKE_EVENT:
-		var eventData CommentLikeEvent
-		err = json.Unmarshal(event.Detail, &eventData)
-		if err != nil {
-			fmt.Printf("Could not unmarshal event: %v\n", event.Detail)
-			return err
-		}
+	case *domain.CommentDeleted:
+		conns, err = app.getAuthorsConnections(ctx, p.PostUserId, p.CommentUserId)
 
-		conns, err = app.getAuthorConnection(eventData.CommentUserId)
+	case *domain.PostUnliked:
+		conns, err = app.getAuthorConnection(ctx, p.PostUserId)
+
+	case *domain.CommentUnliked:
+		conns, err = app.getAuthorConnection(ctx, p.CommentUserId)
+
+	default:
+		fmt.Printf("No notification handler for %s event\n", env.Type)
+		return nil
+	}
+
+	if err != nil {
+		fmt.Printf("Could not get connections for %s event %s\n", env.Type, env.Id)
+		return err
+	}
+
+	subscribers, err := app.getSubscribers(ctx, payload)
+	if err != nil {
+		fmt.Printf("Could not get subscribers for %s event %s\n", env.Type, env.Id)
+		return err
+	}
+	conns = mergeConnections(conns, subscribers)
+
+	var delivery Delivery
+	if n, ok := newNotification(env, payload); ok {
+		delivery, err = app.deliverNotification(ctx, env, n, conns, event.Detail)
 		if err != nil {
-			fmt.Printf("Could not get connections: %v\n", eventData)
+			fmt.Printf("Could not store notification for %s event %s: %s\n", env.Type, env.Id, err.Error())
 			return err
 		}
+	} else {
+		delivery = app.deliver(ctx, conns, event.Detail)
 	}
-
-	for _, conn := range *conns {
-		connection := conn
-		go func(conn NotificationRow) {
-			_, err = app.gw.PostToConnection(
-				&apigatewaymanagementapi.PostToConnectionInput{
-					ConnectionId: aws.String(conn.ConnectionId),
-					Data:         event.Detail,
-				})
-
-			if err != nil {
-				fmt.Printf("Could not send a msg to a conn: %s\n", err.Error())
-			}
-		}(connection)
-	}
+	fmt.Printf(
+		"Sent %s event %s to %d connections: %d delivered, %d failed, %d gone\n",
+		env.Type,
+		env.Id,
+		len(conns),
+		delivery.Delivered,
+		delivery.Failed,
+		delivery.Gone,
+	)
 
 	return nil
 }
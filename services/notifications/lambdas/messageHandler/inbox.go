@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"events/common/domain"
+)
+
+// Notification is an inbox row, written for the one user an event is about so
+// it is still there when they were offline at the time
+type Notification struct {
+	UserId  int64
+	ActorId int64
+	EventId string
+	Type    string
+	PostId  int64
+	// 0 when the notification is about the post itself
+	CommentId int64
+	Payload   json.RawMessage
+	CreatedAt time.Time
+}
+
+// the inbox entry of an event, false when the event does not notify anyone or
+// the user did it to their own content
+func newNotification(env *domain.Envelope, payload domain.Payload) (Notification, bool) {
+	n := Notification{
+		EventId:   env.Id,
+		Type:      env.Type,
+		Payload:   env.Payload,
+		CreatedAt: env.OccurredAt,
+	}
+
+	switch p := payload.(type) {
+	case *domain.CommentAdded:
+		n.UserId, n.ActorId, n.PostId, n.CommentId = p.PostUserId, p.CommentUserId, p.PostId, p.CommentId
+
+	case *domain.SubCommentAdded:
+		n.UserId, n.ActorId, n.PostId, n.CommentId = p.ParentCommentUserId, p.ChildCommentUserId, p.PostId, p.ChildCommentId
+
+	case *domain.PostLiked:
+		n.UserId, n.ActorId, n.PostId = p.PostUserId, p.PostLikeUserId, p.PostId
+
+	// PostId is 0 on older events, the insert finds it through the comment
+	case *domain.CommentLiked:
+		n.UserId, n.ActorId, n.PostId, n.CommentId = p.CommentUserId, p.CommentLikeUserId, p.PostId, p.CommentId
+
+	default:
+		return n, false
+	}
+
+	return n, n.UserId != 0 && n.UserId != n.ActorId
+}
+
+type InboxModel struct {
+	DB *sql.DB
+}
+
+// stores n and returns its id, a redelivered event gets the id of the row it
+// wrote the first time. 0 means the post or comment is already gone and there
+// is nothing left to notify about
+func (m *InboxModel) Insert(ctx context.Context, n Notification) (int64, error) {
+	query := `
+	INSERT INTO notifications (user_id, actor_id, event_id, type, post_id, comment_id, payload, created_at)
+	SELECT $1::bigint, $2::bigint, $3::text, $4::text, post.id, $6::bigint, $7::jsonb, $8::timestamptz
+	FROM posts AS post
+	WHERE post.id = COALESCE($5::bigint, (SELECT post_id FROM comments WHERE id = $6::bigint))
+	ON CONFLICT (event_id, user_id) DO UPDATE SET event_id = EXCLUDED.event_id
+	RETURNING id
+	`
+
+	postId := sql.NullInt64{Int64: n.PostId, Valid: n.PostId != 0}
+	commentId := sql.NullInt64{Int64: n.CommentId, Valid: n.CommentId != 0}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var id int64
+	err := m.DB.QueryRowContext(
+		ctx,
+		query,
+		n.UserId,
+		n.ActorId,
+		n.EventId,
+		n.Type,
+		postId,
+		commentId,
+		string(n.Payload),
+		n.CreatedAt,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+
+	return id, err
+}
+
+// the event as pushed to the user it notifies, the id lets the client mark
+// it read without fetching the inbox first
+type inboxPush struct {
+	domain.Envelope
+	NotificationId int64 `json:"notification_id"`
+}
+
+func inboxMessage(env *domain.Envelope, notificationId int64) ([]byte, error) {
+	return json.Marshal(inboxPush{Envelope: *env, NotificationId: notificationId})
+}
+
+// stores n before pushing anything so a failed insert is retried with the
+// event, then sends the notified user the event with its notification id and
+// everyone else watching the plain event
+func (app *App) deliverNotification(
+	ctx context.Context,
+	env *domain.Envelope,
+	n Notification,
+	conns []NotificationRow,
+	detail []byte,
+) (Delivery, error) {
+	id, err := app.models.Inbox.Insert(ctx, n)
+	if err != nil {
+		return Delivery{}, err
+	}
+
+	if id == 0 {
+		return app.deliver(ctx, conns, detail), nil
+	}
+
+	msg, err := inboxMessage(env, id)
+	if err != nil {
+		return Delivery{}, err
+	}
+
+	var notified, others []NotificationRow
+	for _, conn := range conns {
+		if conn.UserId == n.UserId {
+			notified = append(notified, conn)
+		} else {
+			others = append(others, conn)
+		}
+	}
+
+	delivery := app.deliver(ctx, notified, msg)
+	delivery.add(app.deliver(ctx, others, detail))
+	return delivery, nil
+}
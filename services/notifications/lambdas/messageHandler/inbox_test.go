@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"events/common/domain"
+)
+
+func TestNewNotification(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload domain.Payload
+		want    Notification
+		ok      bool
+	}{
+		{
+			name:    "comment",
+			payload: &domain.CommentAdded{PostId: 1, CommentId: 2, PostUserId: 3, CommentUserId: 4},
+			want:    Notification{UserId: 3, ActorId: 4, PostId: 1, CommentId: 2},
+			ok:      true,
+		},
+		{
+			name:    "reply",
+			payload: &domain.SubCommentAdded{PostId: 1, ParentCommentId: 2, ChildCommentId: 5, ParentCommentUserId: 3, ChildCommentUserId: 4},
+			want:    Notification{UserId: 3, ActorId: 4, PostId: 1, CommentId: 5},
+			ok:      true,
+		},
+		{
+			name:    "post like",
+			payload: &domain.PostLiked{PostId: 1, PostUserId: 3, PostLikeUserId: 4},
+			want:    Notification{UserId: 3, ActorId: 4, PostId: 1},
+			ok:      true,
+		},
+		{
+			name:    "comment like from before post ids",
+			payload: &domain.CommentLiked{CommentId: 2, CommentUserId: 3, CommentLikeUserId: 4},
+			want:    Notification{UserId: 3, ActorId: 4, CommentId: 2},
+			ok:      true,
+		},
+		{
+			name:    "liking your own post",
+			payload: &domain.PostLiked{PostId: 1, PostUserId: 3, PostLikeUserId: 3},
+		},
+		{
+			name:    "unlike",
+			payload: &domain.PostUnliked{PostId: 1, PostUserId: 3, PostLikeUserId: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := domain.NewEnvelope(tt.payload, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, ok := newNotification(env, tt.payload)
+			if ok != tt.ok {
+				t.Fatalf("got ok %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+
+			if got.EventId != env.Id || got.Type != env.Type || !got.CreatedAt.Equal(env.OccurredAt) {
+				t.Fatalf("event fields not copied: %+v", got)
+			}
+
+			got.EventId, got.Type, got.Payload, got.CreatedAt = "", "", nil, time.Time{}
+			if got.UserId != tt.want.UserId || got.ActorId != tt.want.ActorId ||
+				got.PostId != tt.want.PostId || got.CommentId != tt.want.CommentId {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInboxMessage(t *testing.T) {
+	env, err := domain.NewEnvelope(&domain.PostLiked{PostId: 1, PostUserId: 3, PostLikeUserId: 4}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := inboxMessage(env, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// clients that only know envelopes still read it as one
+	parsed, err := domain.Parse(msg)
+	if err != nil {
+		t.Fatalf("push is not an envelope: %v", err)
+	}
+	if parsed.Id != env.Id || string(parsed.Payload) != string(env.Payload) {
+		t.Fatalf("got %+v, want %+v", parsed, env)
+	}
+
+	var push struct {
+		NotificationId int64 `json:"notification_id"`
+	}
+	err = json.Unmarshal(msg, &push)
+	if err != nil || push.NotificationId != 42 {
+		t.Fatalf("got notification id %d (%v), want 42", push.NotificationId, err)
+	}
+}
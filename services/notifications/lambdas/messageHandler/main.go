@@ -1,18 +1,16 @@
 package main
 
 import (
-	"context"
-	"database/sql"
 	"fmt"
 	"os"
-	"time"
 
+	"events/common/data"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/apigatewaymanagementapi"
+	"github.com/aws/aws-sdk-go/service/apigatewaymanagementapi/apigatewaymanagementapiiface"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
-	_ "github.com/lib/pq"
 )
 
 func NewDymanoDbClient() *dynamodb.DynamoDB {
@@ -35,43 +33,23 @@ func NewGatewayClient() *apigatewaymanagementapi.ApiGatewayManagementApi {
 	return gw
 }
 
-func openDB() (*sql.DB, error) {
-	addr := os.Getenv("DB_ADDRESS")
-	db, err := sql.Open("postgres", addr)
-	if err != nil {
-		return nil, err
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	err = db.PingContext(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	return db, nil
-}
-
 type App struct {
-	dynamo *dynamodb.DynamoDB
-	gw     *apigatewaymanagementapi.ApiGatewayManagementApi
+	gw     apigatewaymanagementapiiface.ApiGatewayManagementApiAPI
 	models Models
 }
 
 func main() {
 	dbClient := NewDymanoDbClient()
 	gwClient := NewGatewayClient()
-	pgDb, err := openDB()
+	pgDb, err := data.OpenDB(os.Getenv("DB_ADDRESS"))
 	if err != nil {
 		fmt.Printf("Could not open db: %s\n", err.Error())
 		return
 	}
 
 	app := &App{
-		dynamo: dbClient,
 		gw:     gwClient,
-		models: NewModels(pgDb),
+		models: NewModels(pgDb, dbClient, os.Getenv("TABLE_NAME"), os.Getenv("SUBSCRIPTIONS_TABLE_NAME")),
 	}
 
 	lambda.Start(app.handler)
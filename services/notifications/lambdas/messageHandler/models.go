@@ -2,14 +2,23 @@ package main
 
 import (
 	"database/sql"
+
+	"events/common/realtime"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 type Models struct {
-	SocialConns SocialConnsModel
+	SocialConns   SocialConnsModel
+	Inbox         InboxModel
+	Connections   ConnectionModel
+	Subscriptions realtime.SubscriptionModel
 }
 
-func NewModels(db *sql.DB) Models {
+func NewModels(db *sql.DB, dynamo dynamodbiface.DynamoDBAPI, table, subscriptionsTable string) Models {
 	return Models{
-		SocialConns: SocialConnsModel{DB: db},
+		SocialConns:   SocialConnsModel{DB: db},
+		Inbox:         InboxModel{DB: db},
+		Connections:   ConnectionModel{DB: dynamo, Table: table},
+		Subscriptions: realtime.SubscriptionModel{DB: dynamo, Table: subscriptionsTable},
 	}
 }
@@ -11,11 +11,6 @@ type SocialConnsModel struct {
 	DB *sql.DB
 }
 
-var (
-	ErrRecordNotFound = errors.New("record not found")
-	ErrEditConflict   = errors.New("edit conflict")
-)
-
 type FriendNode struct {
 	Id         int64
 	UserId     int64
@@ -23,6 +18,9 @@ type FriendNode struct {
 	nullUserId sql.NullInt64
 }
 
+// the users following userId, an edge points from the follower to the account
+// they follow. a user without a node has never been followed, which like a
+// user with no edges leaves nobody to notify
 func (s *SocialConnsModel) GetFriendsForUser(userId int64) ([]int64, error) {
 	userNodeQuery := `
 		select id, userid from friend_nodes where userid = $1
@@ -40,7 +38,7 @@ func (s *SocialConnsModel) GetFriendsForUser(userId int64) ([]int64, error) {
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
-			return nil, ErrRecordNotFound
+			return []int64{}, nil
 		default:
 			return nil, err
 		}
@@ -49,21 +47,18 @@ func (s *SocialConnsModel) GetFriendsForUser(userId int64) ([]int64, error) {
 	friendsQuery := `
 		SELECT friend_nodes.id, friend_nodes.userId
 		FROM friend_nodes
-		JOIN friend_edges ON friend_nodes.id = friend_edges.next_node
-		WHERE friend_edges.previous_node = $1;
+		JOIN friend_edges ON friend_nodes.id = friend_edges.previous_node
+		WHERE friend_edges.next_node = $1;
 	`
 
 	rows, err := s.DB.QueryContext(context, friendsQuery, friendNode.Id)
 	if err != nil {
-		switch {
-		case errors.Is(err, sql.ErrNoRows):
-			return nil, ErrRecordNotFound
-		default:
-			return nil, err
-		}
+		return nil, err
 	}
 
-	var friends []int64
+	defer rows.Close()
+
+	friends := []int64{}
 	for rows.Next() {
 		var friend FriendNode
 		err := rows.Scan(
@@ -80,7 +75,7 @@ func (s *SocialConnsModel) GetFriendsForUser(userId int64) ([]int64, error) {
 		}
 	}
 
-	if rows.Err() != nil {
+	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"events/common/data"
+)
+
+// runs against a migrated postgres, `make start` at the root of the repo
+// starts one. TEST_DB_ADDRESS has to be set, the test writes to it
+func newTestSocialConns(t *testing.T) *SocialConnsModel {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DB_ADDRESS")
+	if dsn == "" {
+		t.Skip("TEST_DB_ADDRESS is not set")
+	}
+
+	db, err := data.OpenDB(dsn)
+	if err != nil {
+		t.Skipf("postgres is not reachable: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return &SocialConnsModel{DB: db}
+}
+
+func insertTestUser(t *testing.T, db *sql.DB, name string) (userId, nodeId int64) {
+	t.Helper()
+
+	suffix := time.Now().Format("150405.000000000")
+	err := db.QueryRow(
+		`insert into users (email, username, profile_picture) values ($1, $2, '') returning id`,
+		name+suffix+"@test.local",
+		name+suffix,
+	).Scan(&userId)
+	if err != nil {
+		t.Fatalf("inserting user: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.Exec(`delete from friend_edges where previous_node = $1 or next_node = $1`, nodeId)
+		db.Exec(`delete from users where id = $1`, userId)
+	})
+
+	err = db.QueryRow(`insert into friend_nodes (userId) values ($1) returning id`, userId).Scan(&nodeId)
+	if err != nil {
+		t.Fatalf("inserting node: %v", err)
+	}
+
+	return userId, nodeId
+}
+
+func TestGetFriendsForUserReturnsFollowers(t *testing.T) {
+	model := newTestSocialConns(t)
+
+	author, authorNode := insertTestUser(t, model.DB, "author")
+	follower, followerNode := insertTestUser(t, model.DB, "follower")
+	followed, followedNode := insertTestUser(t, model.DB, "followed")
+
+	// follower follows the author, the author follows someone else
+	for _, edge := range [][2]int64{{followerNode, authorNode}, {authorNode, followedNode}} {
+		_, err := model.DB.Exec(`insert into friend_edges (previous_node, next_node) values ($1, $2)`, edge[0], edge[1])
+		if err != nil {
+			t.Fatalf("inserting edge: %v", err)
+		}
+	}
+
+	got, err := model.GetFriendsForUser(author)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 || got[0] != follower {
+		t.Fatalf("got %v, want only the follower %d and not %d", got, follower, followed)
+	}
+}
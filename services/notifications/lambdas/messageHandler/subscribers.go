@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+
+	"events/common/domain"
+	"events/common/realtime"
+)
+
+// the connections subscribed to a topic the event belongs to, they are
+// watching the post or its comments whether or not they are friends with
+// anyone involved
+func (app *App) getSubscribers(ctx context.Context, payload domain.Payload) ([]NotificationRow, error) {
+	topics := realtime.Topics(payload)
+	if len(topics) == 0 {
+		return nil, nil
+	}
+
+	subs, err := app.models.Subscriptions.ForTopics(ctx, topics)
+	if err != nil {
+		return nil, err
+	}
+
+	conns := make([]NotificationRow, 0, len(subs))
+	for _, sub := range subs {
+		conns = append(conns, NotificationRow{ConnectionId: sub.ConnectionId, UserId: sub.UserId})
+	}
+
+	return conns, nil
+}
+
+// joins both lists keeping every connection once, a post author watching
+// their own post would otherwise get each event twice
+func mergeConnections(a, b []NotificationRow) []NotificationRow {
+	seen := make(map[string]bool, len(a)+len(b))
+	merged := make([]NotificationRow, 0, len(a)+len(b))
+	for _, conns := range [][]NotificationRow{a, b} {
+		for _, conn := range conns {
+			if seen[conn.ConnectionId] {
+				continue
+			}
+			seen[conn.ConnectionId] = true
+			merged = append(merged, conn)
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"events/common/realtime"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// the index connectionHandler finds connections by on $disconnect
+const CONNECTION_INDEX = "byConnectionId"
+
+var ErrUnknownConnection = errors.New("connection is not registered")
+
+func NewDymanoDbClient() *dynamodb.DynamoDB {
+	session := session.Must(session.NewSession())
+	db := dynamodb.New(session, aws.NewConfig().
+		WithRegion("us-east-1").
+		WithEndpoint("http://localstack:4566"),
+	)
+
+	return db
+}
+
+type App struct {
+	dynamo           dynamodbiface.DynamoDBAPI
+	connectionsTable string
+	subscriptions    realtime.SubscriptionModel
+}
+
+// the reply is sent back on the socket, the route has to be set up to
+// return responses
+type reply struct {
+	Action string `json:"action"`
+	Topic  string `json:"topic,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (app *App) handler(
+	ctx context.Context,
+	event events.APIGatewayWebsocketProxyRequest,
+) (events.APIGatewayProxyResponse, error) {
+	var msg realtime.Message
+	err := json.Unmarshal([]byte(event.Body), &msg)
+	if err != nil {
+		return respond(http.StatusBadRequest, reply{Error: "body must be a json message"}), nil
+	}
+
+	err = msg.Validate()
+	if err != nil {
+		return respond(http.StatusBadRequest, reply{Action: msg.Action, Topic: msg.Topic, Error: err.Error()}), nil
+	}
+
+	connId := event.RequestContext.ConnectionID
+	userId, err := app.userOf(ctx, connId)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUnknownConnection):
+			return respond(http.StatusForbidden, reply{Action: msg.Action, Topic: msg.Topic, Error: err.Error()}), nil
+		default:
+			fmt.Printf("Could not look up connection %s: %s\n", connId, err.Error())
+			return respond(http.StatusInternalServerError, reply{Action: msg.Action, Topic: msg.Topic, Error: "could not process the message"}), nil
+		}
+	}
+
+	switch msg.Action {
+	case realtime.ACTION_SUBSCRIBE:
+		err = app.subscriptions.Subscribe(ctx, realtime.Subscription{
+			Topic:        msg.Topic,
+			ConnectionId: connId,
+			UserId:       userId,
+		})
+	case realtime.ACTION_UNSUBSCRIBE:
+		err = app.subscriptions.Unsubscribe(ctx, msg.Topic, connId)
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, realtime.ErrTooManySubscriptions):
+			return respond(http.StatusBadRequest, reply{Action: msg.Action, Topic: msg.Topic, Error: err.Error()}), nil
+		default:
+			fmt.Printf("Could not %s %s to %s: %s\n", msg.Action, connId, msg.Topic, err.Error())
+			return respond(http.StatusInternalServerError, reply{Action: msg.Action, Topic: msg.Topic, Error: "could not process the message"}), nil
+		}
+	}
+
+	return respond(http.StatusOK, reply{Action: msg.Action, Topic: msg.Topic}), nil
+}
+
+// the user $connect authenticated the connection as
+func (app *App) userOf(ctx context.Context, connId string) (int64, error) {
+	out, err := app.dynamo.QueryWithContext(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(app.connectionsTable),
+		IndexName:              aws.String(CONNECTION_INDEX),
+		KeyConditionExpression: aws.String("connectionId = :connectionId"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":connectionId": {S: aws.String(connId)},
+		},
+		Limit: aws.Int64(1),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(out.Items) == 0 || out.Items[0]["userId"] == nil {
+		return 0, ErrUnknownConnection
+	}
+
+	return strconv.ParseInt(aws.StringValue(out.Items[0]["userId"].N), 10, 64)
+}
+
+func respond(status int, body reply) events.APIGatewayProxyResponse {
+	js, _ := json.Marshal(body)
+	return events.APIGatewayProxyResponse{
+		StatusCode: status,
+		Body:       string(js),
+	}
+}
+
+func main() {
+	dbClient := NewDymanoDbClient()
+	app := &App{
+		dynamo:           dbClient,
+		connectionsTable: os.Getenv("TABLE_NAME"),
+		subscriptions: realtime.SubscriptionModel{
+			DB:    dbClient,
+			Table: os.Getenv("SUBSCRIPTIONS_TABLE_NAME"),
+		},
+	}
+
+	lambda.Start(app.handler)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+
+	"events/common/realtime"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// just enough of DynamoDB for the two tables, rows are keyed by table and
+// then by whatever identifies them
+type fakeDynamo struct {
+	dynamodbiface.DynamoDBAPI
+	mu          sync.Mutex
+	connections map[string]string
+	subs        map[[2]string]bool
+}
+
+func (f *fakeDynamo) QueryWithContext(ctx aws.Context, in *dynamodb.QueryInput, _ ...request.Option) (*dynamodb.QueryOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	connId := aws.StringValue(in.ExpressionAttributeValues[":connectionId"].S)
+	out := &dynamodb.QueryOutput{}
+	if aws.StringValue(in.TableName) == "connections" {
+		if userId, ok := f.connections[connId]; ok {
+			out.Items = append(out.Items, map[string]*dynamodb.AttributeValue{
+				"connectionId": {S: aws.String(connId)},
+				"userId":       {N: aws.String(userId)},
+			})
+		}
+		return out, nil
+	}
+
+	for key := range f.subs {
+		if key[1] == connId {
+			out.Items = append(out.Items, map[string]*dynamodb.AttributeValue{
+				"topic":        {S: aws.String(key[0])},
+				"connectionId": {S: aws.String(key[1])},
+			})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeDynamo) PutItemWithContext(ctx aws.Context, in *dynamodb.PutItemInput, _ ...request.Option) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subs[[2]string{aws.StringValue(in.Item["topic"].S), aws.StringValue(in.Item["connectionId"].S)}] = true
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamo) DeleteItemWithContext(ctx aws.Context, in *dynamodb.DeleteItemInput, _ ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.subs, [2]string{aws.StringValue(in.Key["topic"].S), aws.StringValue(in.Key["connectionId"].S)})
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func TestHandler(t *testing.T) {
+	db := &fakeDynamo{connections: map[string]string{"conn": "7"}, subs: map[[2]string]bool{}}
+	app := &App{
+		dynamo:           db,
+		connectionsTable: "connections",
+		subscriptions:    realtime.SubscriptionModel{DB: db, Table: "subscriptions"},
+	}
+
+	send := func(connId, body string) (int, reply) {
+		res, err := app.handler(context.Background(), events.APIGatewayWebsocketProxyRequest{
+			Body:           body,
+			RequestContext: events.APIGatewayWebsocketProxyRequestContext{ConnectionID: connId},
+		})
+		if err != nil {
+			t.Fatalf("handler failed: %v", err)
+		}
+
+		var r reply
+		if err := json.Unmarshal([]byte(res.Body), &r); err != nil {
+			t.Fatalf("reply is not json: %s", res.Body)
+		}
+		return res.StatusCode, r
+	}
+
+	status, r := send("conn", `{"action": "subscribe", "topic": "comments:3"}`)
+	if status != http.StatusOK || r.Error != "" || r.Topic != "comments:3" {
+		t.Fatalf("subscribing: %d %+v", status, r)
+	}
+	if !db.subs[[2]string{"comments:3", "conn"}] {
+		t.Fatalf("subscription was not stored: %v", db.subs)
+	}
+
+	status, r = send("conn", `{"action": "unsubscribe", "topic": "comments:3"}`)
+	if status != http.StatusOK || len(db.subs) != 0 {
+		t.Fatalf("unsubscribing: %d %+v, left %v", status, r, db.subs)
+	}
+
+	tests := []struct {
+		name   string
+		connId string
+		body   string
+		status int
+	}{
+		{"not json", "conn", `subscribe`, http.StatusBadRequest},
+		{"unknown action", "conn", `{"action": "publish", "topic": "post:1"}`, http.StatusBadRequest},
+		{"unknown topic", "conn", `{"action": "subscribe", "topic": "user:1"}`, http.StatusBadRequest},
+		{"unknown connection", "other", `{"action": "subscribe", "topic": "post:1"}`, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, r := send(tt.connId, tt.body)
+			if status != tt.status || r.Error == "" {
+				t.Fatalf("got %d %+v, want %d with an error", status, r, tt.status)
+			}
+		})
+	}
+}
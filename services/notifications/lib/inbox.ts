@@ -0,0 +1,71 @@
+import { CfnOutput, Tags } from 'aws-cdk-lib';
+import { Construct } from "constructs";
+import { RestApi, LambdaIntegration } from "aws-cdk-lib/aws-apigateway";
+import { Bucket } from 'aws-cdk-lib/aws-s3';
+import { createLambda } from '../../../lib/lambda';
+import * as path from "path"
+
+interface InboxProps {
+	db_url?: string
+	session_secret?: string
+}
+
+// the inbox keeps what was pushed for people who were not connected. the
+// message handler in Notifications writes it, reading it only needs postgres
+// so it is deployed on its own like the other rest apis
+export class Inbox extends Construct {
+	constructor(scope: Construct, id: string, props: InboxProps) {
+		super(scope, id);
+
+		if (!props.db_url) {
+			throw new Error("DB env var is not set")
+		}
+
+		if (!props.session_secret) {
+			throw new Error("SESSION_SECRET env var is not set")
+		}
+
+		const hotReloadBucket = Bucket.fromBucketName(
+			this,
+			"HotReloadingBucket",
+			"hot-reload"
+		)
+
+		const inbox = createLambda(
+			this,
+			"InboxHandler",
+			path.join(__dirname, "../lambdas/inbox"),
+			hotReloadBucket,
+			{
+				DB_ADDRESS: props.db_url,
+				SESSION_SECRET: props.session_secret,
+			},
+			"Lists notifications and marks them read",
+		)
+
+		const api = new RestApi(this, "notificationsapi", {
+			restApiName: "notificationsapi",
+			description: "API for the notifications inbox",
+		})
+		Tags.of(api).add("_custom_id_", "notificationsapi")
+
+		// /notifications
+		// /notifications/healthcheck
+		// /notifications/read
+		// /notifications/unread-count
+		const notifications = api.root.addResource("notifications")
+		const healthcheck = notifications.addResource("healthcheck")
+		const read = notifications.addResource("read")
+		const unreadCount = notifications.addResource("unread-count")
+
+		const inboxIntegration = new LambdaIntegration(inbox)
+		notifications.addMethod("GET", inboxIntegration)
+		healthcheck.addMethod("GET", inboxIntegration)
+		read.addMethod("POST", inboxIntegration)
+		unreadCount.addMethod("GET", inboxIntegration)
+
+		new CfnOutput(this, "GatewayId", { value: api.restApiId })
+		new CfnOutput(this, "GatewayUrl", { value: api.url })
+		new CfnOutput(this, "GatewayEndPoints", { value: "\n" + api.methods.join("\n") })
+	}
+}
@@ -5,7 +5,7 @@ import { WebSocketLambdaIntegration } from 'aws-cdk-lib/aws-apigatewayv2-integra
 import * as apigw2 from 'aws-cdk-lib/aws-apigatewayv2';
 import { Bucket } from 'aws-cdk-lib/aws-s3'
 import * as events from 'aws-cdk-lib/aws-events';
-import { AttributeType, Table } from 'aws-cdk-lib/aws-dynamodb';
+import { AttributeType, ProjectionType, Table } from 'aws-cdk-lib/aws-dynamodb';
 import { Effect, PolicyStatement, Role, ServicePrincipal } from 'aws-cdk-lib/aws-iam';
 import { EventBus, LambdaFunction } from 'aws-cdk-lib/aws-events-targets';
 import { createLambda } from '../../../lib/lambda';
@@ -18,6 +18,7 @@ interface NotifciationsProps {
 	isProd: boolean,
 	eventBus: events.EventBus
 	db_url?: string,
+	session_secret?: string,
 }
 
 export class Notifications extends Construct {
@@ -29,6 +30,10 @@ export class Notifications extends Construct {
 			throw new Error("DB URL must be provided")
 		}
 
+		if (!props.session_secret) {
+			throw new Error("SESSION_SECRET env var is not set")
+		}
+
 		const hotReloadBucket = Bucket.fromBucketName(
 			this,
 			"HotReloadingBucket",
@@ -47,14 +52,68 @@ export class Notifications extends Construct {
 			}
 		})
 
+		// $disconnect only knows the connection id
+		table.addGlobalSecondaryIndex({
+			indexName: "byConnectionId",
+			partitionKey: {
+				name: "connectionId",
+				type: AttributeType.STRING
+			},
+			projectionType: ProjectionType.KEYS_ONLY,
+		})
+
+		// who is watching which post, see common/realtime for the topics
+		const subscriptionsTable = new Table(this, "SubscriptionsTable", {
+			tableName: "subscriptions",
+			partitionKey: {
+				name: "topic",
+				type: AttributeType.STRING
+			},
+			sortKey: {
+				name: "connectionId",
+				type: AttributeType.STRING
+			},
+			timeToLiveAttribute: "ttl",
+		})
+
+		subscriptionsTable.addGlobalSecondaryIndex({
+			indexName: "byConnectionId",
+			partitionKey: {
+				name: "connectionId",
+				type: AttributeType.STRING
+			},
+			projectionType: ProjectionType.KEYS_ONLY,
+		})
+
 		const connLambda = createLambda(
 			this,
 			"ConnectionHandler",
 			path.join(__dirname, "../lambdas/connectionHandler"),
 			hotReloadBucket,
-			{ TABLE_NAME: table.tableName }
+			{
+				TABLE_NAME: table.tableName,
+				SUBSCRIPTIONS_TABLE_NAME: subscriptionsTable.tableName,
+				DB_ADDRESS: props.db_url,
+				SESSION_SECRET: props.session_secret,
+			},
+			"Authenticates websocket connections and keeps track of them",
 		)
 		table.grantFullAccess(connLambda)
+		subscriptionsTable.grantFullAccess(connLambda)
+
+		const subscriptionLambda = createLambda(
+			this,
+			"SubscriptionHandler",
+			path.join(__dirname, "../lambdas/subscriptionHandler"),
+			hotReloadBucket,
+			{
+				TABLE_NAME: table.tableName,
+				SUBSCRIPTIONS_TABLE_NAME: subscriptionsTable.tableName,
+			},
+			"Subscribes websocket connections to posts and their comments",
+		)
+		table.grantReadData(subscriptionLambda)
+		subscriptionsTable.grantFullAccess(subscriptionLambda)
 
 
 		const api = new apigw2.WebSocketApi(this, "NotificationsApi", {
@@ -72,6 +131,14 @@ export class Notifications extends Construct {
 					connLambda
 				)
 			},
+			// every message a client sends is a subscribe or unsubscribe
+			defaultRouteOptions: {
+				integration: new WebSocketLambdaIntegration(
+					"defaultInt",
+					subscriptionLambda
+				),
+				returnResponse: true,
+			},
 		})
 
 		const wsStage = new apigw2.WebSocketStage(this, "NotificationsStage", {
@@ -93,7 +160,11 @@ export class Notifications extends Construct {
 			"ProcessHandler",
 			path.join(__dirname, "../lambdas/messageHandler"),
 			hotReloadBucket,
-			{ TABLE_NAME: table.tableName, DB_ADDRESS: props.db_url }
+			{
+				TABLE_NAME: table.tableName,
+				SUBSCRIPTIONS_TABLE_NAME: subscriptionsTable.tableName,
+				DB_ADDRESS: props.db_url,
+			}
 		)
 
 		processLambda.addToRolePolicy(allowConnectionManagementOnApiGatewayPolicy)
@@ -134,6 +205,7 @@ export class Notifications extends Construct {
 
 		eventBus.grantPutEventsTo(processLambda)
 		table.grantFullAccess(processLambda)
+		subscriptionsTable.grantFullAccess(processLambda)
 
 
 		new CfnOutput(this, 'bucketName', {
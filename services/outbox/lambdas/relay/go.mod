@@ -0,0 +1,16 @@
+module relay
+
+go 1.21.5
+
+require (
+	events/common v0.0.0
+	github.com/aws/aws-lambda-go v1.43.0
+)
+
+require (
+	github.com/aws/aws-sdk-go v1.49.18 // indirect
+	github.com/jmespath/go-jmespath v0.4.0 // indirect
+	github.com/lib/pq v1.10.9 // indirect
+)
+
+replace events/common => ../../../common
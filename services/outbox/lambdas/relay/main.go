@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	"events/common/bus"
+	"events/common/data"
+	"events/common/outbox"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+const (
+	BATCH_SIZE = 50
+	// leave headroom under the lambda timeout so an in flight batch can commit
+	DRAIN_TIMEOUT = 90 * time.Second
+)
+
+type app struct {
+	relay *outbox.Relay
+}
+
+func (app *app) handler(ctx context.Context, event events.CloudWatchEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, DRAIN_TIMEOUT)
+	defer cancel()
+
+	delivered, failed, err := app.relay.Drain(ctx)
+	fmt.Printf("Relayed %d events, %d failed\n", delivered, failed)
+	if err != nil {
+		fmt.Printf("Could not drain outbox: %s\n", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func newApp(db *sql.DB) *app {
+	return &app{
+		relay: &outbox.Relay{
+			DB:        db,
+			Sender:    bus.NewPublisher(),
+			BatchSize: BATCH_SIZE,
+		},
+	}
+}
+
+func main() {
+	db, err := data.OpenDB(os.Getenv("DB_ADDRESS"))
+	if err != nil {
+		fmt.Printf("Could not open db: %s\n", err.Error())
+		return
+	}
+
+	app := newApp(db)
+	lambda.Start(app.handler)
+}
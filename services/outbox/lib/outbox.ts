@@ -0,0 +1,47 @@
+import { Duration } from 'aws-cdk-lib';
+import { Construct } from "constructs";
+import { Bucket } from 'aws-cdk-lib/aws-s3';
+import * as events from 'aws-cdk-lib/aws-events';
+import { LambdaFunction } from 'aws-cdk-lib/aws-events-targets';
+import { createLambda } from '../../../lib/lambda';
+import * as path from "path"
+
+
+interface OutboxProps {
+	db_url?: string
+	eventBus: events.EventBus
+}
+
+export class Outbox extends Construct {
+	constructor(scope: Construct, id: string, props: OutboxProps) {
+		super(scope, id);
+		const { eventBus } = props
+
+		if (!props.db_url) {
+			throw new Error("DB env var is not set")
+		}
+
+		const hotReloadBucket = Bucket.fromBucketName(
+			this,
+			"HotReloadingBucket",
+			"hot-reload"
+		)
+
+		const relay = createLambda(
+			this,
+			"outboxRelay",
+			path.join(__dirname, "../lambdas/relay"),
+			hotReloadBucket,
+			{ DB_ADDRESS: props.db_url, BUS_NAME: eventBus.eventBusName },
+			"Drains outbox_events to the notifications event bus",
+		)
+		eventBus.grantPutEventsTo(relay)
+
+		new events.Rule(this, "OutboxRelaySchedule", {
+			enabled: true,
+			ruleName: "OutboxRelaySchedule",
+			schedule: events.Schedule.rate(Duration.minutes(1)),
+			targets: [new LambdaFunction(relay)],
+		})
+	}
+}
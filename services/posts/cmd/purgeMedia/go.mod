@@ -0,0 +1,13 @@
+module purgeMedia
+
+go 1.21.5
+
+require (
+	events/common v0.0.0
+	github.com/aws/aws-sdk-go v1.49.18
+	github.com/lib/pq v1.10.9
+)
+
+require github.com/jmespath/go-jmespath v0.4.0 // indirect
+
+replace events/common => ../../../common
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"events/common/data"
+	"events/common/media"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/lib/pq"
+)
+
+// S3 deletes at most this many objects per request
+const DELETE_BATCH = 1000
+
+// deletes uploads that were reserved but never attached to a post, along
+// with their processed variants. keys a user picked as their avatar are kept
+func main() {
+	var dsn, bucket, endpoint string
+	var retention time.Duration
+	var dryRun bool
+	flag.StringVar(&dsn, "db-dsn", os.Getenv("DB_ADDRESS"), "postgres dsn")
+	flag.StringVar(&bucket, "bucket", os.Getenv("MEDIA_BUCKET"), "media bucket")
+	flag.StringVar(&endpoint, "s3-endpoint", os.Getenv("S3_ENDPOINT"), "s3 endpoint, empty for aws")
+	flag.DurationVar(&retention, "retention", media.RESERVATION_TTL, "how long an unattached upload is kept")
+	flag.BoolVar(&dryRun, "dry-run", false, "report purgeable uploads without deleting them")
+	flag.Parse()
+
+	// anything younger could still be attached
+	if retention < media.RESERVATION_TTL {
+		fmt.Fprintf(os.Stderr, "retention must be at least %s\n", media.RESERVATION_TTL)
+		os.Exit(1)
+	}
+
+	db, err := data.OpenDB(dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to db: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	keys, objects, err := purgeable(ctx, db, time.Now().Add(-retention))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to find unattached uploads: %v\n", err)
+		os.Exit(1)
+	}
+
+	if dryRun {
+		fmt.Printf("purgeable uploads: %d (%d objects)\n", len(keys), len(objects))
+		return
+	}
+
+	// objects go first, a failure leaves the rows behind for the next run
+	// instead of objects nothing points at anymore
+	err = deleteObjects(ctx, newS3Client(endpoint), bucket, objects)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete objects: %v\n", err)
+		os.Exit(1)
+	}
+
+	purged, err := deleteRows(ctx, db, keys)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to delete uploads: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("purged uploads: %d (%d objects)\n", purged, len(objects))
+}
+
+func newS3Client(endpoint string) *s3.S3 {
+	config := aws.NewConfig().WithRegion("us-east-1")
+	if endpoint != "" {
+		config = config.WithEndpoint(endpoint).WithS3ForcePathStyle(true)
+	}
+
+	return s3.New(session.Must(session.NewSession()), config)
+}
+
+// the unattached upload keys reserved before cutoff and every object stored
+// for them, the upload itself and its variants
+func purgeable(ctx context.Context, db *sql.DB, cutoff time.Time) ([]string, []string, error) {
+	query := `
+		select post_media.key, image_variants.key
+		from post_media
+		left join image_variants on image_variants.source_key = post_media.key
+		where post_media.post_id is null and post_media.created_at < $1
+		and not exists (select 1 from users where users.avatar_key = post_media.key)
+		order by post_media.key
+	`
+
+	rows, err := db.QueryContext(ctx, query, cutoff)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	objects := []string{}
+	for rows.Next() {
+		var key string
+		var variantKey sql.NullString
+		err = rows.Scan(&key, &variantKey)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(keys) == 0 || keys[len(keys)-1] != key {
+			keys = append(keys, key)
+			objects = append(objects, key)
+		}
+
+		if variantKey.Valid {
+			objects = append(objects, variantKey.String)
+		}
+	}
+
+	return keys, objects, rows.Err()
+}
+
+func deleteObjects(ctx context.Context, client *s3.S3, bucket string, objects []string) error {
+	for start := 0; start < len(objects); start += DELETE_BATCH {
+		end := min(start+DELETE_BATCH, len(objects))
+
+		ids := make([]*s3.ObjectIdentifier, 0, end-start)
+		for _, key := range objects[start:end] {
+			ids = append(ids, &s3.ObjectIdentifier{Key: aws.String(key)})
+		}
+
+		out, err := client.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucket),
+			Delete: &s3.Delete{Objects: ids, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(out.Errors) > 0 {
+			first := out.Errors[0]
+			return fmt.Errorf("%d objects not deleted, %s: %s", len(out.Errors), aws.StringValue(first.Key), aws.StringValue(first.Message))
+		}
+	}
+
+	return nil
+}
+
+// a key attached since it was picked up is left alone, the variants go with
+// the images row
+func deleteRows(ctx context.Context, db *sql.DB, keys []string) (int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		with deleted as (
+			delete from post_media
+			where key = any($1) and post_id is null
+			returning key
+		), images as (
+			delete from images
+			using deleted
+			where images.source_key = deleted.key
+		)
+		select count(*) from deleted
+	`
+
+	var purged int64
+	err = tx.QueryRowContext(ctx, query, pq.Array(keys)).Scan(&purged)
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, tx.Commit()
+}
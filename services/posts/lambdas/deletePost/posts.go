@@ -5,38 +5,76 @@ import (
 	"database/sql"
 	"errors"
 	"time"
-)
 
-var (
-	ErrRecordNotFound = errors.New("record not found")
+	"events/common/data"
+	"events/common/domain"
+	"events/common/outbox"
 )
 
 type PostModel struct {
 	DB *sql.DB
 }
 
-func (p *PostModel) delete(id int64) error {
+// deletedBy is the user who asked for the delete, the owner or a moderator
+func (p *PostModel) delete(id, deletedBy int64) error {
 	if id < 1 {
-		return ErrRecordNotFound
+		return data.ErrRecordNotFound
 	}
 
-	query := `delete from posts where id = $1`
+	query := `delete from posts where id = $1 returning user_id, now()`
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	res, err := p.DB.ExecContext(ctx, query, id)
+	tx, err := p.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	rows, err := res.RowsAffected()
+	var postUserId int64
+	var deletedAt time.Time
+	err = tx.QueryRowContext(ctx, query, id).Scan(&postUserId, &deletedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return data.ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	event := domain.PostDeleted{
+		PostId:          id,
+		PostUserId:      postUserId,
+		DeletedByUserId: deletedBy,
+		DeletedAt:       deletedAt,
+	}
+
+	err = outbox.Enqueue(ctx, tx, outbox.AGGREGATE_POST, id, event, deletedAt)
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return ErrRecordNotFound
+	return tx.Commit()
+}
+
+// user id of whoever created the post
+func (p *PostModel) owner(id int64) (int64, error) {
+	query := `select user_id from posts where id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var userId int64
+	err := p.DB.QueryRowContext(ctx, query, id).Scan(&userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, data.ErrRecordNotFound
+		default:
+			return 0, err
+		}
 	}
 
-	return nil
+	return userId, nil
 }
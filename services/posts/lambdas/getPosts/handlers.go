@@ -1,95 +1,119 @@
 package main
 
 import (
-	"context"
 	"errors"
 	"net/http"
 	"strconv"
 
-	"events/posts/models"
-	"github.com/aws/aws-lambda-go/events"
+	"events/common/api"
+	"events/common/cursor"
+	"events/common/data"
 	"github.com/go-chi/chi/v5"
 )
 
-func Handler(
-	ctx context.Context,
-	event events.APIGatewayProxyRequest,
-) (events.APIGatewayProxyResponse, error) {
-	return chiLambda.ProxyWithContext(ctx, event)
-}
-
 func (app *app) listPostsHandler(w http.ResponseWriter, r *http.Request) {
-	qs := r.URL.Query()
-	take, err := app.readInt(qs, "take", 10)
+	filters, err := cursor.ReadFilters(r.URL.Query())
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.BadRequestResponse(w, r, err)
 		return
 	}
 
-	skip, err := app.readInt(qs, "skip", 0)
+	posts, metadata, err := app.models.Posts.List(filters)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.ServerErrorResponse(w, r, err)
 		return
 	}
 
-	posts, metadata, err := app.models.Posts.List(take, skip)
+	env := api.Envelope{"posts": posts, "metadata": metadata}
+	etag, err := api.ContentETag(env)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	if api.NotModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"posts": posts, "metadata": metadata}, nil)
+	err = api.WriteJSON(w, http.StatusOK, env, nil)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.ServerErrorResponse(w, r, err)
 	}
 }
 
 func (app *app) getPostHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil || id < 1 {
-		app.notFoundHandler(w, r)
+		api.NotFoundResponse(w, r)
 		return
 	}
 
-	qs := r.URL.Query()
-	take, err := app.readInt(qs, "take", 10)
+	filters, err := cursor.ReadFilters(r.URL.Query())
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.BadRequestResponse(w, r, err)
 		return
 	}
 
-	skip, err := app.readInt(qs, "skip", 0)
-	if err != nil {
-		app.serverErrorResponse(w, r, err)
-		return
-	}
-
-	post, err := app.models.Posts.Get(int64(id), take, skip)
+	post, metadata, err := app.models.Posts.Get(int64(id), filters)
 	if err != nil {
 		switch {
-		case errors.Is(err, models.ErrRecordNotFound):
-			app.notFoundHandler(w, r)
+		case errors.Is(err, data.ErrRecordNotFound):
+			api.NotFoundResponse(w, r)
 		default:
-			app.serverErrorResponse(w, r, err)
+			api.ServerErrorResponse(w, r, err)
 		}
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"post": post}, nil)
+	// the comments change without the post's version moving, so the etag has
+	// to cover the whole page
+	env := api.Envelope{"post": post, "metadata": metadata}
+	etag, err := api.ContentETag(env)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	if api.NotModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
+
+	err = api.WriteJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+	}
 }
 
-func (app *app) healthcheckHandler(w http.ResponseWriter, r *http.Request) {
-	err := app.writeJSON(w, http.StatusOK, envelope{"status": "available"}, nil)
+func (app *app) listRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 1 {
+		api.NotFoundResponse(w, r)
+		return
+	}
+
+	filters, err := cursor.ReadFilters(r.URL.Query())
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.BadRequestResponse(w, r, err)
 		return
 	}
-}
 
-func (app *app) notFoundHandler(w http.ResponseWriter, r *http.Request) {
-	app.errorResponse(w, r, http.StatusNotFound, "resource not found")
+	revisions, metadata, err := app.models.Revisions.List(int64(id), filters)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			api.NotFoundResponse(w, r)
+		default:
+			api.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = api.WriteJSON(w, http.StatusOK, api.Envelope{"revisions": revisions, "metadata": metadata}, nil)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+	}
 }
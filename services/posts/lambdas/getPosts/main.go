@@ -1,18 +1,17 @@
 package main
 
 import (
-	"context"
 	"net/http"
 	"os"
 
+	"events/common/api"
+	"events/common/data"
 	"events/posts/models"
-	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/awslabs/aws-lambda-go-api-proxy/chi"
+	"events/session"
 	"github.com/go-chi/chi/v5"
-	_ "github.com/lib/pq"
 )
 
-var chiLambda *chiadapter.ChiLambda
+var router *chi.Mux
 
 type app struct {
 	models models.Models
@@ -20,28 +19,30 @@ type app struct {
 
 func init() {
 	addr := os.Getenv("DB_ADDRESS")
-	db, err := models.OpenDB(addr)
+	db, err := data.OpenDB(addr)
 	if err != nil {
 		panic(err)
 	}
 
-	app := app{models: models.NewModels(db)}
-	r := chi.NewRouter()
+	app := app{models: models.NewModels(db, os.Getenv("MEDIA_BASE_URL"))}
+	r := api.NewRouter()
+	sessionModel := session.SessionModel{DB: db}
+	r.Use(sessionModel.Authenticate)
 
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		app.writeJSON(w, http.StatusOK, envelope{"message": "hello from root"}, nil)
+		api.WriteJSON(w, http.StatusOK, api.Envelope{"message": "hello from root"}, nil)
 	})
 
 	r.Route("/posts", func(r chi.Router) {
 		r.Get("/", app.listPostsHandler)
 		r.Get("/{id}", app.getPostHandler)
-		r.Get("/healthcheck", app.healthcheckHandler)
+		r.Get("/{id}/revisions", app.listRevisionsHandler)
+		r.Get("/healthcheck", api.HealthcheckHandler)
 	})
 
-	r.NotFound(app.notFoundHandler)
-	chiLambda = chiadapter.New(r)
+	router = r
 }
 
 func main() {
-	lambda.StartWithOptions(Handler, lambda.WithContext(context.Background()))
+	api.Start(router)
 }
@@ -1,34 +1,19 @@
 package models
 
 import (
-	"context"
 	"database/sql"
-	"time"
+
+	"events/common/revisions"
 )
 
 type Models struct {
-	Posts PostModel
-}
-
-func OpenDB(addr string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", addr)
-	if err != nil {
-		return nil, err
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	err = db.PingContext(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	return db, nil
+	Posts     PostModel
+	Revisions revisions.Model
 }
 
-func NewModels(db *sql.DB) Models {
+func NewModels(db *sql.DB, mediaBaseURL string) Models {
 	return Models{
-		Posts: PostModel{DB: db},
+		Posts:     PostModel{DB: db, MediaBaseURL: mediaBaseURL},
+		Revisions: revisions.Model{DB: db, Table: revisions.Posts},
 	}
 }
@@ -6,6 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"events/common/cursor"
+	"events/common/data"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -15,6 +18,7 @@ var (
 	models         Models
 	email          = "testmailbob@pubsub.com"
 	username       = "bob-cool"
+	mediaBaseURL   = "http://localhost:4566/post-media/variants"
 	profilePicture = "https://lh3.googleusercontent.com/a/default-user=s96-c"
 	conn           *sql.DB
 	userId         int64
@@ -34,12 +38,12 @@ var _ = BeforeSuite(func() {
 	}
 	container = postgres
 
-	dbConn, err := OpenDB(container.ConnectionString)
+	dbConn, err := data.OpenDB(container.ConnectionString)
 	if err != nil {
 		panic(err)
 	}
 
-	models = NewModels(dbConn)
+	models = NewModels(dbConn, mediaBaseURL)
 	conn = dbConn
 
 	query := `
@@ -71,7 +75,7 @@ var _ = Describe("getting posts", Label("unit"), func() {
 	When("there are no posts in the db", func() {
 		When("getting a list of posts", func() {
 			It("should return an empty slice", func() {
-				posts, _, err := models.Posts.List(10, 0)
+				posts, _, err := models.Posts.List(cursor.Filters{Take: 10})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(posts).To(BeEmpty())
 			})
@@ -79,9 +83,9 @@ var _ = Describe("getting posts", Label("unit"), func() {
 
 		When("getting a post by id", func() {
 			It("should return an error", func() {
-				_, err := models.Posts.Get(1, 10, 0)
+				_, _, err := models.Posts.Get(1, cursor.Filters{Take: 10})
 				Expect(err).To(HaveOccurred())
-				Expect(err).To(Equal(ErrRecordNotFound))
+				Expect(err).To(Equal(data.ErrRecordNotFound))
 			})
 		})
 	})
@@ -151,21 +155,21 @@ var _ = Describe("getting posts", Label("unit"), func() {
 
 		When("getting a list of posts", func() {
 			It("should return a list of posts", func() {
-				posts, _, err := models.Posts.List(10, 0)
+				posts, _, err := models.Posts.List(cursor.Filters{Take: 10})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(len(posts)).Should(BeNumerically(">", 0))
 			})
 			It("should return posts in the same order when posts have not changed in db", func() {
-				posts, _, err := models.Posts.List(10, 0)
+				posts, _, err := models.Posts.List(cursor.Filters{Take: 10})
 				Expect(err).ToNot(HaveOccurred())
 
-				posts2, _, err := models.Posts.List(10, 0)
+				posts2, _, err := models.Posts.List(cursor.Filters{Take: 10})
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(posts).To(Equal(posts2))
 			})
 			It("should have user with profile picture, username and id", func() {
-				posts, _, err := models.Posts.List(10, 0)
+				posts, _, err := models.Posts.List(cursor.Filters{Take: 10})
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(posts[0].Post.User.Id).To(Equal(userId))
@@ -173,29 +177,32 @@ var _ = Describe("getting posts", Label("unit"), func() {
 				Expect(posts[0].Post.User.ProfilePicture).To(Equal(profilePicture))
 			})
 			It("should have a body", func() {
-				posts, _, err := models.Posts.List(10, 0)
+				posts, _, err := models.Posts.List(cursor.Filters{Take: 10})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(posts[0].Post.Body).ToNot(Equal(""))
 			})
 			It("should be controlled via pagination", func() {
-				posts, _, err := models.Posts.List(10, 0)
+				posts, metadata, err := models.Posts.List(cursor.Filters{Take: 10})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(len(posts)).To(Equal(10))
 
 				finalId := posts[len(posts)-1].Post.Id
 
-				posts, _, err = models.Posts.List(10, 10)
+				next, err := cursor.Decode(metadata.NextCursor)
+				Expect(err).ToNot(HaveOccurred())
+
+				posts, _, err = models.Posts.List(cursor.Filters{Take: 10, Cursor: next})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(posts[len(posts)-1].Post.Id).ToNot(Equal(finalId))
 
-				posts, _, err = models.Posts.List(1, 0)
+				posts, _, err = models.Posts.List(cursor.Filters{Take: 1})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(len(posts)).To(Equal(1))
 			})
 
 			When("10 posts are taken, metadata should reflect that", func() {
 				It("has page size metadata as 10", func() {
-					_, metadata, err := models.Posts.List(10, 0)
+					_, metadata, err := models.Posts.List(cursor.Filters{Take: 10})
 					Expect(err).ToNot(HaveOccurred())
 					Expect(metadata.PageSize).To(Equal(10))
 				})
@@ -203,22 +210,22 @@ var _ = Describe("getting posts", Label("unit"), func() {
 
 			When("posts have no comments", func() {
 				It("shows post metadata with 0 comments indicated", func() {
-					posts, _, err := models.Posts.List(10, 0)
+					posts, _, err := models.Posts.List(cursor.Filters{Take: 10})
 					Expect(err).ToNot(HaveOccurred())
 					Expect(posts[0].Metadata.CommentsCount).To(Equal(0))
 				})
 				It("shows post metadata with last comment as empty string", func() {
-					posts, _, err := models.Posts.List(10, 0)
+					posts, _, err := models.Posts.List(cursor.Filters{Take: 10})
 					Expect(err).ToNot(HaveOccurred())
 					Expect(posts[0].Metadata.LatestComment).To(Equal(""))
 				})
 				It("shows post metadata with last comment at as empty time", func() {
-					posts, _, err := models.Posts.List(10, 0)
+					posts, _, err := models.Posts.List(cursor.Filters{Take: 10})
 					Expect(err).ToNot(HaveOccurred())
 					Expect(posts[0].Metadata.LastCommentAt).To(Equal(time.Time{}))
 				})
 				It("shows post comments as nil", func() {
-					posts, _, err := models.Posts.List(10, 0)
+					posts, _, err := models.Posts.List(cursor.Filters{Take: 10})
 					Expect(err).ToNot(HaveOccurred())
 					Expect(posts[0].Post.Comments).To(BeNil())
 				})
@@ -285,12 +292,12 @@ var _ = Describe("getting posts", Label("unit"), func() {
 					}
 				})
 				It("has metadata that shows num of comments", func() {
-					posts, _, err := models.Posts.List(10, 0)
+					posts, _, err := models.Posts.List(cursor.Filters{Take: 10})
 					Expect(err).ToNot(HaveOccurred())
 					Expect(posts[0].Metadata.CommentsCount).To(Equal(1))
 				})
 				It("metadata that shows last comment body", func() {
-					posts, _, err := models.Posts.List(10, 0)
+					posts, _, err := models.Posts.List(cursor.Filters{Take: 10})
 					Expect(err).ToNot(HaveOccurred())
 					Expect(posts[0].Metadata.LatestComment).To(Equal("hello world"))
 				})
@@ -299,13 +306,13 @@ var _ = Describe("getting posts", Label("unit"), func() {
 
 		When("getting a post by id", func() {
 			It("post should have a body", func() {
-				post, err := models.Posts.Get(postId, 10, 0)
+				post, _, err := models.Posts.Get(postId, cursor.Filters{Take: 10})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(post.Body).ToNot(Equal(""))
 			})
 
 			It("should return a post with an user", func() {
-				post, err := models.Posts.Get(postId, 10, 0)
+				post, _, err := models.Posts.Get(postId, cursor.Filters{Take: 10})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(post.User.Id).To(Equal(userId))
 				Expect(post.User.Username).To(Equal(username))
@@ -314,9 +321,9 @@ var _ = Describe("getting posts", Label("unit"), func() {
 
 			When("a post has no comments", func() {
 				It("should return a post with an empty comments slice", func() {
-					post, err := models.Posts.Get(postId, 10, 0)
+					post, _, err := models.Posts.Get(postId, cursor.Filters{Take: 10})
 					Expect(err).ToNot(HaveOccurred())
-					Expect(post.Comments).To(Equal([]Comment{}))
+					Expect(post.Comments).To(Equal([]data.Comment{}))
 				})
 			})
 
@@ -354,38 +361,41 @@ var _ = Describe("getting posts", Label("unit"), func() {
 				})
 
 				It("should return a post with comments", func() {
-					post, err := models.Posts.Get(postId, 10, 0)
+					post, _, err := models.Posts.Get(postId, cursor.Filters{Take: 10})
 					Expect(err).ToNot(HaveOccurred())
 					Expect(len(post.Comments)).To(Equal(10))
 				})
 				It("should return a post with comments with user", func() {
-					post, err := models.Posts.Get(postId, 10, 0)
+					post, _, err := models.Posts.Get(postId, cursor.Filters{Take: 10})
 					Expect(err).ToNot(HaveOccurred())
 					Expect(post.Comments[0].User.Id).To(Equal(userId))
 					Expect(post.Comments[0].User.Username).To(Equal(username))
 					Expect(post.Comments[0].User.ProfilePicture).To(Equal(profilePicture))
 				})
 				It("should return same comments in the same order for a post when comments haven't changed", func() {
-					post, err := models.Posts.Get(postId, 10, 0)
+					post, _, err := models.Posts.Get(postId, cursor.Filters{Take: 10})
 					Expect(err).ToNot(HaveOccurred())
 
-					post2, err := models.Posts.Get(postId, 10, 0)
+					post2, _, err := models.Posts.Get(postId, cursor.Filters{Take: 10})
 					Expect(err).ToNot(HaveOccurred())
 
 					Expect(post.Comments).To(Equal(post2.Comments))
 				})
 				It("should be able to use pagination on post comments", func() {
-					post, err := models.Posts.Get(postId, 5, 0)
+					post, metadata, err := models.Posts.Get(postId, cursor.Filters{Take: 5})
 					Expect(err).ToNot(HaveOccurred())
 					Expect(len(post.Comments)).To(Equal(5))
 
 					finalId := post.Comments[len(post.Comments)-1].Id
 
-					post, err = models.Posts.Get(postId, 5, 5)
+					next, err := cursor.Decode(metadata.NextCursor)
+					Expect(err).ToNot(HaveOccurred())
+
+					post, _, err = models.Posts.Get(postId, cursor.Filters{Take: 5, Cursor: next})
 					Expect(err).ToNot(HaveOccurred())
 					Expect(post.Comments[len(post.Comments)-1].Id).ToNot(Equal(finalId))
 
-					post, err = models.Posts.Get(postId, 1, 0)
+					post, _, err = models.Posts.Get(postId, cursor.Filters{Take: 1})
 					Expect(err).ToNot(HaveOccurred())
 					Expect(len(post.Comments)).To(Equal(1))
 				})
@@ -440,16 +450,169 @@ var _ = Describe("getting posts", Label("unit"), func() {
 				})
 
 				It("should return a post comment with number of sub comments", func() {
-					post, err := models.Posts.Get(postId, 10, 0)
+					post, _, err := models.Posts.Get(postId, cursor.Filters{Take: 10})
 					Expect(err).ToNot(HaveOccurred())
 					Expect(post.Comments[0].NumOfSubComments).To(Equal(numOfSubComments))
 				})
 				It("should return a post with comments that have sub comments as an empty slice", func() {
-					post, err := models.Posts.Get(postId, 10, 0)
+					post, _, err := models.Posts.Get(postId, cursor.Filters{Take: 10})
 					Expect(err).ToNot(HaveOccurred())
-					Expect(post.Comments[0].SubComments).To(Equal([]Comment{}))
+					Expect(post.Comments[0].SubComments).To(Equal([]data.Comment{}))
 				})
 			})
 		})
 	})
 })
+
+var _ = Describe("listing post revisions", Label("unit"), func() {
+	When("the post does not exist", func() {
+		It("should return not found", func() {
+			_, _, err := models.Revisions.List(99999, cursor.Filters{Take: 10})
+			Expect(err).To(MatchError(data.ErrRecordNotFound))
+		})
+	})
+
+	When("the post has been edited", func() {
+		var postId int64
+		BeforeEach(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			query := `
+				insert into posts (body, user_id, version) values ('third body', $1, 3)
+				returning id
+			`
+			err := conn.QueryRowContext(ctx, query, userId).Scan(&postId)
+			if err != nil {
+				panic(err)
+			}
+
+			query = `
+				insert into post_revisions (post_id, version, body, edited_by) values
+				($1, 1, 'first body', $2), ($1, 2, 'second body', $2)
+			`
+			_, err = conn.ExecContext(ctx, query, postId, userId)
+			if err != nil {
+				panic(err)
+			}
+		})
+
+		AfterEach(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, err := conn.ExecContext(ctx, `delete from posts`)
+			if err != nil {
+				panic(err)
+			}
+		})
+
+		It("should mark the post as edited", func() {
+			post, _, err := models.Posts.Get(postId, cursor.Filters{Take: 10})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(post.Edited).To(BeTrue())
+			Expect(post.EditCount).To(Equal(int32(2)))
+		})
+		It("should return the replaced bodies newest first", func() {
+			revisions, _, err := models.Revisions.List(postId, cursor.Filters{Take: 10})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(revisions).To(HaveLen(2))
+			Expect(revisions[0].Body).To(Equal("second body"))
+			Expect(revisions[1].Body).To(Equal("first body"))
+			Expect(revisions[0].EditedBy.Id).To(Equal(userId))
+		})
+	})
+})
+
+var _ = Describe("post media", Label("unit"), func() {
+	var postId int64
+	BeforeEach(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		err := conn.QueryRowContext(ctx, `insert into posts (body, user_id) values ('with media', $1) returning id`, userId).Scan(&postId)
+		if err != nil {
+			panic(err)
+		}
+
+		query := `
+			insert into post_media (key, user_id, post_id, content_type, size, position) values
+			('posts/1/second.png', $1, $2, 'image/png', 10, 2),
+			('posts/1/first.jpg', $1, $2, 'image/jpeg', 10, 1),
+			('posts/1/unattached.png', $1, null, 'image/png', 10, 0)
+		`
+		_, err = conn.ExecContext(ctx, query, userId, postId)
+		if err != nil {
+			panic(err)
+		}
+
+		query = `
+			with image as (
+				insert into images (source_key, width, height, blurhash)
+				values ('posts/1/first.jpg', 2000, 1000, 'LEHV6nWB2yk8pyo0adR*.7kCMdnj')
+				returning source_key
+			)
+			insert into image_variants (source_key, name, key, content_type, width, height)
+			select source_key, v.name, v.key, 'image/jpeg', v.width, v.height
+			from image, (values
+				('thumb', 'variants/posts/1/first/thumb.jpg', 200, 200),
+				('original', 'variants/posts/1/first/original.jpg', 2000, 1000)
+			) as v(name, key, width, height)
+		`
+		_, err = conn.ExecContext(ctx, query)
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	AfterEach(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_, err := conn.ExecContext(ctx, `delete from images`)
+		if err != nil {
+			panic(err)
+		}
+
+		_, err = conn.ExecContext(ctx, `delete from post_media`)
+		if err != nil {
+			panic(err)
+		}
+
+		_, err = conn.ExecContext(ctx, `delete from posts`)
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	It("should return the attachments of a post in order with urls", func() {
+		post, _, err := models.Posts.Get(postId, cursor.Filters{Take: 10})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(post.Media).To(HaveLen(2))
+		Expect(post.Media[0].Key).To(Equal("posts/1/first.jpg"))
+		Expect(post.Media[1].ContentType).To(Equal("image/png"))
+	})
+	It("should serve the processed variants once an upload is processed", func() {
+		post, _, err := models.Posts.Get(postId, cursor.Filters{Take: 10})
+		Expect(err).ToNot(HaveOccurred())
+
+		processed := post.Media[0]
+		Expect(processed.URL).To(Equal(mediaBaseURL + "/posts/1/first/original.jpg"))
+		Expect(processed.Blurhash).ToNot(BeEmpty())
+		Expect(processed.Width).To(Equal(2000))
+		Expect(processed.Variants).To(HaveLen(2))
+		Expect(processed.Variants["thumb"].Width).To(Equal(200))
+	})
+	It("should not serve the upload itself until it is processed", func() {
+		post, _, err := models.Posts.Get(postId, cursor.Filters{Take: 10})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(post.Media[1].URL).To(BeEmpty())
+		Expect(post.Media[1].Variants).To(BeEmpty())
+	})
+	It("should include attachments when listing posts", func() {
+		posts, _, err := models.Posts.List(cursor.Filters{Take: 10})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(posts).To(HaveLen(1))
+		Expect(posts[0].Post.Media).To(HaveLen(2))
+	})
+})
@@ -4,136 +4,100 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
-)
 
-type PostModel struct {
-	DB *sql.DB
-}
-
-var (
-	ErrRecordNotFound = errors.New("record not found")
+	"events/common/cursor"
+	"events/common/data"
+	"events/common/media"
 )
 
-type Post struct {
-	Id        int64     `json:"id"`
-	Body      string    `json:"body"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Comments  []Comment `json:"comments"`
-	User      User      `json:"user"`
-}
-
-type PostMetadata struct {
-	LastCommentAt time.Time `json:"last_comment_at"`
-	CommentsCount int       `json:"comments_count"`
-	LatestComment string    `json:"latest_comment"`
-}
+const DELETED_BODY = "[deleted]"
 
-type PostData struct {
-	Post     Post         `json:"post"`
-	Metadata PostMetadata `json:"metadata"`
-}
-
-type User struct {
-	Id                int64          `json:"id"`
-	ProfilePicture    string         `json:"profile_picture"`
-	Username          string         `json:"username"`
-	sqlId             sql.NullInt64  `json:"-"`
-	sqlProfilePicture sql.NullString `json:"-"`
-	sqlUsername       sql.NullString `json:"-"`
-}
+// a deleted top level comment is kept as a tombstone while any of its replies
+// are still live
+const visibleCommentSQL = `(comment.deleted_at IS NULL OR EXISTS (
+	SELECT 1 FROM comments AS live
+	WHERE live.path <@ comment.id::text::ltree AND live.deleted_at IS NULL
+))`
 
-func (u *User) parseSqlNulls() {
-	if u.sqlId.Valid {
-		u.Id = u.sqlId.Int64
-	}
-
-	if u.sqlProfilePicture.Valid {
-		u.ProfilePicture = u.sqlProfilePicture.String
-	}
-
-	if u.sqlUsername.Valid {
-		u.Username = u.sqlUsername.String
-	}
+type PostModel struct {
+	DB           *sql.DB
+	MediaBaseURL string
 }
 
-type Comment struct {
-	Id               int64          `json:"id"`
-	PostId           int64          `json:"post_id"`
-	SubComments      []Comment      `json:"sub_comments"`
-	Body             string         `json:"body"`
-	CreatedAt        time.Time      `json:"created_at"`
-	UpdatedAt        time.Time      `json:"updated_at"`
-	NumOfSubComments int            `json:"num_of_sub_comments"`
-	ParentId         int64          `json:"parent_id"`
-	User             User           `json:"user"`
-	sqlId            sql.NullInt64  `json:"-"`
-	sqlPostId        sql.NullInt64  `json:"-"`
-	sqlBody          sql.NullString `json:"-"`
-	sqlCreatedAt     sql.NullTime   `json:"-"`
-	sqlUpdatedAt     sql.NullTime   `json:"-"`
+// a top level comment and its author come from left joins, every column can
+// be null when the post has no comments on the page
+type sqlComment struct {
+	id             sql.NullInt64
+	postId         sql.NullInt64
+	body           sql.NullString
+	createdAt      sql.NullTime
+	updatedAt      sql.NullTime
+	version        sql.NullInt32
+	deletedAt      sql.NullTime
+	userId         sql.NullInt64
+	username       sql.NullString
+	profilePicture sql.NullString
 }
 
-func (c *Comment) parseSqlNulls() {
-	if c.sqlId.Valid {
-		c.Id = c.sqlId.Int64
-	}
-
-	if c.sqlPostId.Valid {
-		c.PostId = c.sqlPostId.Int64
-	}
-
-	if c.sqlBody.Valid {
-		c.Body = c.sqlBody.String
+func (c *sqlComment) comment() data.Comment {
+	comment := data.Comment{
+		Id:        c.id.Int64,
+		PostId:    c.postId.Int64,
+		Body:      c.body.String,
+		CreatedAt: c.createdAt.Time,
+		UpdatedAt: c.updatedAt.Time,
+		Deleted:   c.deletedAt.Valid,
+		User: data.User{
+			Id:             c.userId.Int64,
+			Username:       c.username.String,
+			ProfilePicture: c.profilePicture.String,
+		},
 	}
 
-	if c.sqlCreatedAt.Valid {
-		c.CreatedAt = c.sqlCreatedAt.Time
+	if c.version.Valid {
+		comment.EditInfo = data.NewEditInfo(c.version.Int32)
 	}
 
-	if c.sqlUpdatedAt.Valid {
-		c.UpdatedAt = c.sqlUpdatedAt.Time
-	}
+	return comment
 }
 
-type Metadata struct {
-	PageSize int `json:"page_size"`
-}
-
-func calculateMetadata(pageSize int) Metadata {
-	if pageSize == 0 {
-		return Metadata{}
-	}
-
-	return Metadata{
-		PageSize: pageSize,
-	}
+// keeps the comments place in the thread without anything it said or who
+// said it
+func tombstone(c *data.Comment) {
+	c.Body = DELETED_BODY
+	c.User = data.User{}
+	c.EditInfo = data.EditInfo{}
 }
 
-func (p *PostModel) List(take, skip int) ([]PostData, Metadata, error) {
-	query := `
-	SELECT post.id, post.body, post.created_at, post.updated_at, 
+func (p *PostModel) List(filters cursor.Filters) ([]data.PostData, cursor.Metadata, error) {
+	scan := filters.ScanOrder(cursor.DESC)
+	query := fmt.Sprintf(`
+	SELECT post.id, post.body, post.created_at, post.updated_at, post.version,
 	COUNT(comment.id) AS comments_count, 
 	MAX(comment.created_at) AS last_comment_at, MAX(comment.body) as last_comment_body,
 	users.id as user_id, users.username as user_username, users.profile_picture as user_pp
 	FROM posts AS post
 	LEFT JOIN comments AS comment 
 		ON comment.post_id = post.id AND comment.path = '0'
+		AND comment.deleted_at IS NULL
 	LEFT JOIN users
 		ON users.id = post.user_id	
+	WHERE $3 OR (post.created_at, post.id) %s ($1, $2)
 	GROUP BY post.id, users.id
-	ORDER BY post.created_at DESC
-	LIMIT $1 OFFSET $2
-	`
+	ORDER BY post.created_at %s, post.id %s
+	LIMIT $4
+	`, filters.Compare(cursor.DESC), scan, scan)
 
-	metadata := Metadata{}
-	posts := []PostData{}
+	metadata := cursor.Metadata{}
+	posts := []data.PostData{}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := p.DB.QueryContext(ctx, query, take, skip)
+	createdAt, id, first := filters.Args()
+	rows, err := p.DB.QueryContext(ctx, query, createdAt, id, first, filters.Limit())
 	if err != nil {
 		return nil, metadata, err
 	}
@@ -141,10 +105,10 @@ func (p *PostModel) List(take, skip int) ([]PostData, Metadata, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		postListed := PostData{}
-		post := Post{}
-		postMetadata := PostMetadata{}
-		user := User{}
+		postListed := data.PostData{}
+		post := data.Post{}
+		postMetadata := data.PostMetadata{}
+		user := data.User{}
 		commentsCount := 0
 
 		var lastCommentAt sql.NullTime
@@ -155,6 +119,7 @@ func (p *PostModel) List(take, skip int) ([]PostData, Metadata, error) {
 			&post.Body,
 			&post.CreatedAt,
 			&post.UpdatedAt,
+			&post.Version,
 			&commentsCount,
 			&lastCommentAt,
 			&lastCommentBody,
@@ -176,6 +141,7 @@ func (p *PostModel) List(take, skip int) ([]PostData, Metadata, error) {
 		}
 
 		postMetadata.CommentsCount = commentsCount
+		post.EditInfo = data.NewEditInfo(post.Version)
 		postListed.Post = post
 		postListed.Metadata = postMetadata
 		postListed.Post.User = user
@@ -187,16 +153,31 @@ func (p *PostModel) List(take, skip int) ([]PostData, Metadata, error) {
 		return nil, metadata, err
 	}
 
-	return posts, calculateMetadata(len(posts)), nil
+	posts, metadata = cursor.Paginate(filters, posts, func(p data.PostData) (time.Time, int64) {
+		return p.Post.CreatedAt, p.Post.Id
+	})
+
+	err = data.LoadMedia(ctx, p.DB, p.MediaBaseURL, posts)
+	if err != nil {
+		return nil, metadata, err
+	}
+
+	return posts, metadata, nil
 }
 
-func (p *PostModel) Get(id int64, take, offset int) (Post, error) {
-	query := `
-	SELECT post.id, post.body, post.created_at, post.updated_at, comment.id, 
-	comment.body, comment.created_at, comment.updated_at, comment.post_id,
-	(select count(*) 
+func (p *PostModel) Get(id int64, filters cursor.Filters) (data.Post, cursor.Metadata, error) {
+	scan := filters.ScanOrder(cursor.ASC)
+	query := fmt.Sprintf(`
+	SELECT post.id, post.body, post.created_at, post.updated_at, post.version, comment.id, 
+	comment.body, comment.created_at, comment.updated_at, comment.post_id, comment.version,
+	comment.deleted_at, (select count(*) 
 		from comments 
 		where path = comment.id::text::ltree
+		AND (deleted_at IS NULL OR EXISTS (
+			SELECT 1 FROM comments AS live
+			WHERE live.path <@ (comments.path || comments.id::text)
+			AND live.deleted_at IS NULL
+		))
 	) as num_of_sub_comments, users.id as user_id, users.username as user_username, 
 	users.profile_picture as user_pp, comment_user.id as comment_user_id,
 	comment_user.username as comment_user_username, 
@@ -204,38 +185,42 @@ func (p *PostModel) Get(id int64, take, offset int) (Post, error) {
 	FROM posts as post
 	LEFT JOIN comments AS comment 
 		ON comment.post_id = post.id AND comment.path = '0'
+		AND %s
+		AND ($4 OR (comment.created_at, comment.id) %s ($2, $3))
 	LEFT JOIN users 
 		ON users.id = post.user_id
 	LEFT JOIN users AS comment_user 
 		ON comment_user.id = comment.user_id
 	WHERE post.id = $1
 	GROUP BY post.id, comment.id, users.id, comment_user.id
-	ORDER BY post.created_at DESC, comment.created_at ASC
-	LIMIT $2 OFFSET $3
-	`
+	ORDER BY comment.created_at %s, comment.id %s
+	LIMIT $5
+	`, visibleCommentSQL, filters.Compare(cursor.ASC), scan, scan)
 
-	post := Post{}
-	comments := []Comment{}
+	post := data.Post{}
+	comments := []data.Comment{}
+	metadata := cursor.Metadata{}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
-	rows, err := p.DB.QueryContext(ctx, query, id, take, offset)
+
+	createdAt, commentId, first := filters.Args()
+	rows, err := p.DB.QueryContext(ctx, query, id, createdAt, commentId, first, filters.Limit())
 
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
-			return post, ErrRecordNotFound
+			return post, metadata, data.ErrRecordNotFound
 		default:
-			return post, err
+			return post, metadata, err
 		}
 	}
 
 	defer rows.Close()
 
 	for rows.Next() {
-		comment := Comment{}
-		user := User{}
-		commentUser := User{}
+		row := sqlComment{}
+		user := data.User{}
 		numOfSubComments := 0
 
 		err := rows.Scan(
@@ -243,45 +228,59 @@ func (p *PostModel) Get(id int64, take, offset int) (Post, error) {
 			&post.Body,
 			&post.CreatedAt,
 			&post.UpdatedAt,
-			&comment.sqlId,
-			&comment.sqlBody,
-			&comment.sqlCreatedAt,
-			&comment.sqlUpdatedAt,
-			&comment.sqlPostId,
+			&post.Version,
+			&row.id,
+			&row.body,
+			&row.createdAt,
+			&row.updatedAt,
+			&row.postId,
+			&row.version,
+			&row.deletedAt,
 			&numOfSubComments,
 			&user.Id,
 			&user.Username,
 			&user.ProfilePicture,
-			&commentUser.sqlId,
-			&commentUser.sqlUsername,
-			&commentUser.sqlProfilePicture,
+			&row.userId,
+			&row.username,
+			&row.profilePicture,
 		)
 
 		if err != nil {
-			return post, err
+			return post, metadata, err
 		}
 
 		post.User = user
+		post.EditInfo = data.NewEditInfo(post.Version)
 
-		comment.parseSqlNulls()
-		commentUser.parseSqlNulls()
-
-		if comment.Id != 0 {
+		if row.id.Valid {
+			comment := row.comment()
 			comment.NumOfSubComments = numOfSubComments
-			comment.User = commentUser
-			comment.SubComments = []Comment{}
+			comment.SubComments = []data.Comment{}
+			if comment.Deleted {
+				tombstone(&comment)
+			}
+
 			comments = append(comments, comment)
 		}
 	}
 
 	if err := rows.Err(); err != nil {
-		return post, err
+		return post, metadata, err
 	}
 
 	if post.Id == 0 {
-		return post, ErrRecordNotFound
+		return post, metadata, data.ErrRecordNotFound
+	}
+
+	post.Comments, metadata = cursor.Paginate(filters, comments, func(c data.Comment) (time.Time, int64) {
+		return c.CreatedAt, c.Id
+	})
+
+	attachments, err := media.LoadForPosts(ctx, p.DB, p.MediaBaseURL, []int64{post.Id})
+	if err != nil {
+		return post, metadata, err
 	}
 
-	post.Comments = comments
-	return post, nil
+	post.Media = media.OrEmpty(attachments[post.Id])
+	return post, metadata, nil
 }
@@ -45,3 +45,62 @@ create table if not exists friend_edges (
     next_node bigint references friend_nodes(id),
     primary key (previous_node, next_node)
 );
+
+alter table if exists posts
+    add column if not exists version integer not null default 1;
+alter table if exists comments
+    add column if not exists version integer not null default 1;
+
+create table if not exists post_revisions (
+    id bigserial primary key,
+    post_id bigint not null references posts on delete cascade,
+    version integer not null,
+    body text not null,
+    edited_by bigint references users on delete set null,
+    created_at timestamptz not null default now(),
+    unique (post_id, version)
+);
+
+create table if not exists comment_revisions (
+    id bigserial primary key,
+    comment_id bigint not null references comments on delete cascade,
+    version integer not null,
+    body text not null,
+    edited_by bigint references users on delete set null,
+    created_at timestamptz not null default now(),
+    unique (comment_id, version)
+);
+
+
+alter table if exists comments
+    add column if not exists deleted_at timestamptz;
+
+create table if not exists post_media (
+    id bigserial primary key,
+    key text not null unique,
+    user_id bigint not null references users on delete cascade,
+    post_id bigint references posts on delete cascade,
+    content_type text not null,
+    size bigint not null,
+    position integer not null default 0,
+    created_at timestamptz not null default now()
+);
+
+create table if not exists images (
+    source_key text primary key,
+    width integer not null default 0,
+    height integer not null default 0,
+    blurhash text not null default '',
+    error text,
+    processed_at timestamptz not null default now()
+);
+
+create table if not exists image_variants (
+    source_key text not null references images on delete cascade,
+    name text not null,
+    key text not null,
+    content_type text not null,
+    width integer not null,
+    height integer not null,
+    primary key (source_key, name)
+);
@@ -3,11 +3,20 @@ module postPosts
 go 1.21.5
 
 require (
-	github.com/aws/aws-lambda-go v1.43.0
+	events/common v0.0.0
+	events/session v0.0.0
 	github.com/aws/aws-sdk-go v1.49.18
-	github.com/awslabs/aws-lambda-go-api-proxy v0.16.0
 	github.com/go-chi/chi/v5 v5.0.11
 	github.com/lib/pq v1.10.9
 )
 
-require github.com/jmespath/go-jmespath v0.4.0 // indirect
+require (
+	github.com/aws/aws-lambda-go v1.43.0 // indirect
+	github.com/awslabs/aws-lambda-go-api-proxy v0.16.0 // indirect
+	github.com/jmespath/go-jmespath v0.4.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+)
+
+replace events/common => ../../../common
+
+replace events/session => ../../../auth/session
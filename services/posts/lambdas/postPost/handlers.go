@@ -1,36 +1,31 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
-)
+	"time"
 
-func (app *app) healthcheckHandler(w http.ResponseWriter, r *http.Request) {
-	err := app.writeJSON(w, http.StatusOK, envelope{"status": "available"}, nil)
-	if err != nil {
-		app.serverErrorResponse(w, r, err)
-		return
-	}
-}
-
-func (app *app) notFoundHandler(w http.ResponseWriter, r *http.Request) {
-	app.errorResponse(w, r, http.StatusNotFound, "resource not found")
-}
+	"events/common/api"
+	"events/common/media"
+	"events/session"
+)
 
 func (app *app) createHandler(w http.ResponseWriter, r *http.Request) {
-	tempUsrId := int64(3)
+	userId := session.ContextGetUser(r).Id
 	var input struct {
-		Body string `json:"body"`
+		Body  string   `json:"body"`
+		Media []string `json:"media"`
 	}
 
-	err := app.readJSON(w, r, &input)
+	err := api.ReadJSON(w, r, &input)
 	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, err)
+		api.ErrorResponse(w, r, http.StatusBadRequest, err)
 		return
 	}
 
 	if input.Body == "" {
-		app.errorResponse(
+		api.ErrorResponse(
 			w,
 			r,
 			http.StatusBadRequest,
@@ -40,7 +35,7 @@ func (app *app) createHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if len(input.Body) > 20_000 {
-		app.errorResponse(
+		api.ErrorResponse(
 			w,
 			r,
 			http.StatusBadRequest,
@@ -49,29 +44,100 @@ func (app *app) createHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	post := &Post{
-		Body: input.Body,
+	err = media.ValidateKeys(input.Media)
+	if err != nil {
+		api.BadRequestResponse(w, r, err)
+		return
 	}
 
-	err = app.models.Posts.Insert(post, tempUsrId)
+	err = app.uploader.CheckUploaded(r.Context(), input.Media)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, media.ErrNotUploaded):
+			api.BadRequestResponse(w, r, err)
+		default:
+			api.ServerErrorResponse(w, r, err)
+		}
 		return
 	}
 
-	go func(post *Post) {
-		err = app.publishPost(post)
-		if err != nil {
-			fmt.Printf("Could not publish event for post %d: \n%v\n", post.Id, err)
+	post := &Post{
+		Body: input.Body,
+	}
+
+	err = app.models.Posts.Insert(post, userId, input.Media)
+	if err != nil {
+		switch {
+		case errors.Is(err, media.ErrInvalidKeys):
+			api.BadRequestResponse(w, r, err)
+		default:
+			api.ServerErrorResponse(w, r, err)
 		}
-	}(post)
+		return
+	}
 
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/posts/%d", post.Id))
 
-	err = app.writeJSON(w, http.StatusCreated, envelope{"post": post}, headers)
+	err = api.WriteJSON(w, http.StatusCreated, api.Envelope{"post": post}, headers)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.ServerErrorResponse(w, r, err)
 		return
 	}
 }
+
+func (app *app) createUploadHandler(w http.ResponseWriter, r *http.Request) {
+	userId := session.ContextGetUser(r).Id
+	var input struct {
+		ContentType string `json:"content_type"`
+		Size        int64  `json:"size"`
+	}
+
+	err := api.ReadJSON(w, r, &input)
+	if err != nil {
+		api.BadRequestResponse(w, r, err)
+		return
+	}
+
+	err = media.ValidateUpload(input.ContentType, input.Size)
+	if err != nil {
+		api.BadRequestResponse(w, r, err)
+		return
+	}
+
+	key, err := media.NewKey(userId, input.ContentType)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	uploadURL, err := app.uploader.PresignPut(key, input.ContentType, input.Size)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.models.Media.Reserve(key, userId, input.ContentType, input.Size)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	env := api.Envelope{
+		"upload": api.Envelope{
+			"key":        key,
+			"url":        uploadURL,
+			"method":     http.MethodPut,
+			"expires_at": time.Now().Add(UPLOAD_URL_TTL).UTC(),
+			"headers": api.Envelope{
+				"Content-Type":   input.ContentType,
+				"Content-Length": input.Size,
+			},
+		},
+	}
+
+	err = api.WriteJSON(w, http.StatusCreated, env, nil)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"events/common/media"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/lib/pq"
+)
+
+const UPLOAD_URL_TTL = 15 * time.Minute
+
+type MediaModel struct {
+	DB      *sql.DB
+	BaseURL string
+}
+
+// records an upload before its url is handed out, the row stays unattached
+// until a post is created with the key
+func (m *MediaModel) Reserve(key string, userId int64, contentType string, size int64) error {
+	query := `
+		insert into post_media (key, user_id, content_type, size)
+		values ($1, $2, $3, $4)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, key, userId, contentType, size)
+	return err
+}
+
+// attaches the authors unused uploads to the post in the order given, any key
+// that is unknown, someone elses, already attached or reserved longer than
+// media.RESERVATION_TTL ago fails the whole post. uploads processed by now
+// come back with their variants
+func (m *MediaModel) attach(ctx context.Context, tx *sql.Tx, postId, userId int64, keys []string) ([]media.Media, error) {
+	if len(keys) == 0 {
+		return []media.Media{}, nil
+	}
+
+	query := `
+		update post_media set post_id = $1, position = k.position
+		from unnest($3::text[]) with ordinality as k(key, position)
+		where post_media.key = k.key and post_media.user_id = $2
+		and post_media.post_id is null and post_media.created_at > $4
+	`
+
+	reservedAfter := time.Now().Add(-media.RESERVATION_TTL)
+	result, err := tx.ExecContext(ctx, query, postId, userId, pq.Array(keys), reservedAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if count != int64(len(keys)) {
+		return nil, media.ErrInvalidKeys
+	}
+
+	attachments, err := media.LoadForPosts(ctx, tx, m.BaseURL, []int64{postId})
+	if err != nil {
+		return nil, err
+	}
+
+	return media.OrEmpty(attachments[postId]), nil
+}
+
+// hands out presigned PUT urls for the media bucket, S3_ENDPOINT points it at
+// localstack when running locally
+type Uploader struct {
+	s3     *s3.S3
+	bucket string
+}
+
+func NewUploader() *Uploader {
+	endpoint := os.Getenv("S3_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "http://localstack:4566"
+	}
+
+	session := session.Must(session.NewSession())
+	client := s3.New(session, aws.NewConfig().
+		WithRegion("us-east-1").
+		WithEndpoint(endpoint).
+		WithS3ForcePathStyle(true),
+	)
+
+	return &Uploader{s3: client, bucket: os.Getenv("MEDIA_BUCKET")}
+}
+
+// a reserved key only has an object behind it once the client finished the
+// upload, attaching one before that would leave the post with broken media
+func (u *Uploader) CheckUploaded(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		_, err := u.s3.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(u.bucket),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			var reqErr awserr.RequestFailure
+			if errors.As(err, &reqErr) && reqErr.StatusCode() == http.StatusNotFound {
+				return media.ErrNotUploaded
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// the content type and length are part of the signature, the client has to
+// send the same headers or S3 rejects the upload
+func (u *Uploader) PresignPut(key, contentType string, size int64) (string, error) {
+	req, _ := u.s3.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:        aws.String(u.bucket),
+		Key:           aws.String(key),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(size),
+	})
+
+	return req.Presign(UPLOAD_URL_TTL)
+}
@@ -6,10 +6,13 @@ import (
 
 type Models struct {
 	Posts PostModel
+	Media MediaModel
 }
 
-func NewModels(db *sql.DB) Models {
+func NewModels(db *sql.DB, mediaBaseURL string) Models {
+	media := MediaModel{DB: db, BaseURL: mediaBaseURL}
 	return Models{
-		Posts: PostModel{DB: db},
+		Posts: PostModel{DB: db, media: media},
+		Media: media,
 	}
 }
@@ -3,33 +3,31 @@ package main
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"time"
-)
 
-var (
-	ErrRecordNotFound = errors.New("record not found")
+	"events/common/data"
+	"events/common/domain"
+	"events/common/media"
+	"events/common/outbox"
 )
 
 type PostModel struct {
-	DB *sql.DB
+	DB    *sql.DB
+	media MediaModel
 }
 
 type Post struct {
-	Id        int64     `json:"id"`
-	Body      string    `json:"body"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	User      User      `json:"user"`
-}
-
-type User struct {
-	Id             int64  `json:"id"`
-	ProfilePicture string `json:"profile_picture"`
-	Username       string `json:"username"`
+	Id        int64         `json:"id"`
+	Body      string        `json:"body"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	User      data.User     `json:"user"`
+	Media     []media.Media `json:"media"`
 }
 
-func (p PostModel) Insert(post *Post, userId int64) error {
+// mediaKeys are uploads from the upload endpoint, they are attached to the
+// post in the same transaction
+func (p PostModel) Insert(post *Post, userId int64, mediaKeys []string) error {
 	query := `
 	with insert_post as (
 		insert into posts (body, user_id)
@@ -43,9 +41,15 @@ func (p PostModel) Insert(post *Post, userId int64) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	var postUser User
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var postUser data.User
 
-	err := p.DB.QueryRowContext(ctx, query, post.Body, userId).Scan(
+	err = tx.QueryRowContext(ctx, query, post.Body, userId).Scan(
 		&post.Id,
 		&post.CreatedAt,
 		&postUser.Id,
@@ -58,5 +62,23 @@ func (p PostModel) Insert(post *Post, userId int64) error {
 	}
 
 	post.User = postUser
-	return nil
+
+	post.Media, err = p.media.attach(ctx, tx, post.Id, userId, mediaKeys)
+	if err != nil {
+		return err
+	}
+
+	event := domain.PostAdded{
+		PostId:    post.Id,
+		UserId:    post.User.Id,
+		Username:  post.User.Username,
+		CreatedAt: post.CreatedAt,
+	}
+
+	err = outbox.Enqueue(ctx, tx, outbox.AGGREGATE_POST, post.Id, event, post.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"processMedia/imaging"
+)
+
+type ImageModel struct {
+	DB *sql.DB
+}
+
+// replaces whatever was recorded for sourceKey, S3 can deliver the same
+// event more than once
+func (m *ImageModel) Save(sourceKey string, result *imaging.Result, variantKeys map[string]string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		insert into images (source_key, width, height, blurhash, error, processed_at)
+		values ($1, $2, $3, $4, null, now())
+		on conflict (source_key) do update set
+		width = excluded.width, height = excluded.height,
+		blurhash = excluded.blurhash, error = null, processed_at = now()
+	`
+
+	_, err = tx.ExecContext(ctx, query, sourceKey, result.Width, result.Height, result.Blurhash)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `delete from image_variants where source_key = $1`, sourceKey)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		insert into image_variants (source_key, name, key, content_type, width, height)
+		values ($1, $2, $3, $4, $5, $6)
+	`
+
+	for _, v := range result.Variants {
+		_, err = tx.ExecContext(ctx, query, sourceKey, v.Name, variantKeys[v.Name], v.ContentType, v.Width, v.Height)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// records why an upload has no variants so it isn't mistaken for one that is
+// still being processed
+func (m *ImageModel) SaveFailure(sourceKey, reason string) error {
+	query := `
+		insert into images (source_key, error, processed_at)
+		values ($1, $2, now())
+		on conflict (source_key) do update set error = excluded.error, processed_at = now()
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, sourceKey, reason)
+	return err
+}
@@ -0,0 +1,181 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/webp"
+)
+
+const (
+	// larger images are rejected before decoding, a 24MP image already
+	// needs ~100MB once decoded
+	MAX_PIXELS   = 24_000_000
+	JPEG_QUALITY = 82
+
+	// blurhash is computed from a copy this size, the hash can't hold more
+	// detail than that anyway
+	BLURHASH_SOURCE = 32
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("image format is not supported")
+	ErrTooManyPixels     = errors.New("image has too many pixels")
+)
+
+// the variants written for every upload, Square ones are centre cropped
+// before they are shrunk. "original" keeps the full size and only exists to
+// serve the image without the metadata the client uploaded
+const (
+	VARIANT_THUMB    = "thumb"
+	VARIANT_SMALL    = "small"
+	VARIANT_LARGE    = "large"
+	VARIANT_ORIGINAL = "original"
+)
+
+type Spec struct {
+	Name   string
+	Max    int
+	Square bool
+}
+
+var (
+	Thumb = Spec{Name: VARIANT_THUMB, Max: 200, Square: true}
+	Small = Spec{Name: VARIANT_SMALL, Max: 640}
+	Large = Spec{Name: VARIANT_LARGE, Max: 1280}
+)
+
+type Variant struct {
+	Name        string
+	Width       int
+	Height      int
+	ContentType string
+	Data        []byte
+}
+
+type Result struct {
+	Width    int
+	Height   int
+	Blurhash string
+	Variants []Variant
+}
+
+// decodes a jpeg, png, webp or gif (the first frame) and builds every variant
+// plus a blurhash placeholder. variants are re-encoded from the decoded pixels
+// so EXIF and any other metadata in the upload is never copied over, a jpeg's
+// EXIF orientation is applied first so nothing comes out sideways
+func Process(data []byte) (*Result, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	if config.Width*config.Height > MAX_PIXELS {
+		return nil, ErrTooManyPixels
+	}
+
+	var src image.Image
+	switch format {
+	case "jpeg":
+		src, err = jpeg.Decode(bytes.NewReader(data))
+	case "png":
+		src, err = png.Decode(bytes.NewReader(data))
+	case "gif":
+		src, err = gif.Decode(bytes.NewReader(data))
+	case "webp":
+		src, err = webp.Decode(bytes.NewReader(data))
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "jpeg" {
+		src = orient(src, jpegOrientation(data))
+	}
+
+	bounds := src.Bounds()
+	result := &Result{Width: bounds.Dx(), Height: bounds.Dy()}
+
+	// each size is shrunk from the one above it so the full image is only
+	// walked once
+	large := shrink(src, Large.Max)
+	small := shrink(large, Small.Max)
+	thumb := shrink(cropSquare(small), Thumb.Max)
+	result.Blurhash = blurhash(shrink(small, BLURHASH_SOURCE))
+
+	for _, v := range []struct {
+		name string
+		img  image.Image
+	}{
+		{VARIANT_THUMB, thumb},
+		{VARIANT_SMALL, small},
+		{VARIANT_LARGE, large},
+		{VARIANT_ORIGINAL, src},
+	} {
+		variant, err := encode(v.name, v.img)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Variants = append(result.Variants, *variant)
+	}
+
+	return result, nil
+}
+
+func shrink(img image.Image, max int) image.Image {
+	bounds := img.Bounds()
+	width, height := fit(bounds.Dx(), bounds.Dy(), max)
+	if width == bounds.Dx() && height == bounds.Dy() {
+		return img
+	}
+
+	return resize(img, width, height)
+}
+
+// jpeg unless the image has transparency to keep
+func encode(name string, img image.Image) (*Variant, error) {
+	bounds := img.Bounds()
+	variant := &Variant{Name: name, Width: bounds.Dx(), Height: bounds.Dy()}
+
+	var buf bytes.Buffer
+	if opaque(img) {
+		variant.ContentType = "image/jpeg"
+		err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: JPEG_QUALITY})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		variant.ContentType = "image/png"
+		err := png.Encode(&buf, img)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	variant.Data = buf.Bytes()
+	return variant, nil
+}
+
+func opaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+
+	return false
+}
+
+// file extension for a variants content type
+func Extension(contentType string) string {
+	if contentType == "image/png" {
+		return "png"
+	}
+
+	return "jpg"
+}
@@ -0,0 +1,248 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func solid(width, height int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		width, height, max int
+		wantW, wantH       int
+	}{
+		{100, 50, 200, 100, 50},
+		{2000, 1000, 1280, 1280, 640},
+		{1000, 2000, 640, 320, 640},
+		{5000, 1, 200, 200, 1},
+	}
+
+	for _, tt := range tests {
+		w, h := fit(tt.width, tt.height, tt.max)
+		if w != tt.wantW || h != tt.wantH {
+			t.Fatalf("fit(%d, %d, %d) = %d, %d, want %d, %d", tt.width, tt.height, tt.max, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestResizeAveragesPixels(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, color.NRGBA{R: 0, A: 255})
+	src.Set(1, 0, color.NRGBA{R: 200, A: 255})
+
+	dst := resize(src, 1, 1)
+	got := dst.NRGBAAt(0, 0)
+	if got.R < 99 || got.R > 100 {
+		t.Fatalf("expected the average of both pixels, got %v", got)
+	}
+}
+
+func TestBlurhash(t *testing.T) {
+	hash := blurhash(solid(32, 32, color.NRGBA{R: 255, G: 255, B: 255, A: 255}))
+	if len(hash) != 6+2*(BLURHASH_X*BLURHASH_Y-1) {
+		t.Fatalf("unexpected hash length %d: %s", len(hash), hash)
+	}
+
+	// size flag for 4x3 components, then after the ac maximum the dc which
+	// for white is 0xFFFFFF in base 83
+	if hash[:1] != "L" || hash[2:6] != "TSUA" {
+		t.Fatalf("unexpected hash %s", hash)
+	}
+}
+
+func TestProcessJPEG(t *testing.T) {
+	src := solid(2000, 1000, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+	result, err := Process(encodeJPEG(t, src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Width != 2000 || result.Height != 1000 || result.Blurhash == "" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	want := map[string][2]int{
+		VARIANT_THUMB:    {200, 200},
+		VARIANT_SMALL:    {640, 320},
+		VARIANT_LARGE:    {1280, 640},
+		VARIANT_ORIGINAL: {2000, 1000},
+	}
+
+	if len(result.Variants) != len(want) {
+		t.Fatalf("expected %d variants, got %d", len(want), len(result.Variants))
+	}
+
+	for _, v := range result.Variants {
+		size, ok := want[v.Name]
+		if !ok {
+			t.Fatalf("unexpected variant %s", v.Name)
+		}
+
+		if v.Width != size[0] || v.Height != size[1] || v.ContentType != "image/jpeg" {
+			t.Fatalf("unexpected %s variant %dx%d %s", v.Name, v.Width, v.Height, v.ContentType)
+		}
+
+		decoded, err := jpeg.Decode(bytes.NewReader(v.Data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if decoded.Bounds().Dx() != size[0] {
+			t.Fatalf("%s variant decodes to the wrong size", v.Name)
+		}
+	}
+}
+
+func TestProcessStripsEXIF(t *testing.T) {
+	data := encodeJPEG(t, solid(64, 64, color.NRGBA{R: 10, A: 255}))
+
+	// an APP1 Exif segment right after the SOI marker
+	exif := append([]byte{0xFF, 0xE1, 0x00, 0x0E}, []byte("Exif\x00\x00secret")...)
+	withExif := append(append([]byte{}, data[:2]...), append(exif, data[2:]...)...)
+
+	result, err := Process(withExif)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range result.Variants {
+		if bytes.Contains(v.Data, []byte("Exif")) || bytes.Contains(v.Data, []byte("secret")) {
+			t.Fatalf("%s variant still carries the EXIF segment", v.Name)
+		}
+	}
+}
+
+// a jpeg with an APP1 Exif segment holding only the orientation tag
+func withOrientation(data []byte, orientation byte) []byte {
+	tiff := []byte{
+		'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08,
+		0x00, 0x01,
+		0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, orientation, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+	}
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	length := len(segment) + 2
+	app1 := append([]byte{0xFF, 0xE1, byte(length >> 8), byte(length)}, segment...)
+
+	return append(append(append([]byte{}, data[:2]...), app1...), data[2:]...)
+}
+
+func TestProcessAppliesOrientation(t *testing.T) {
+	// red on the left, blue on the right, stored as if the camera was on its side
+	src := solid(40, 20, color.NRGBA{B: 255, A: 255})
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			src.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+
+	result, err := Process(withOrientation(encodeJPEG(t, src), ORIENTATION_ROTATE_90))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Width != 20 || result.Height != 40 {
+		t.Fatalf("expected the upright size 20x40, got %dx%d", result.Width, result.Height)
+	}
+
+	for _, v := range result.Variants {
+		if v.Name != VARIANT_ORIGINAL {
+			continue
+		}
+
+		decoded, err := jpeg.Decode(bytes.NewReader(v.Data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// turned clockwise the left half ends up on top
+		top := color.NRGBAModel.Convert(decoded.At(10, 5)).(color.NRGBA)
+		bottom := color.NRGBAModel.Convert(decoded.At(10, 35)).(color.NRGBA)
+		if top.R < 200 || top.B > 50 || bottom.B < 200 || bottom.R > 50 {
+			t.Fatalf("unexpected colours top %v bottom %v", top, bottom)
+		}
+	}
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	data := encodeJPEG(t, solid(4, 4, color.NRGBA{A: 255}))
+
+	if got := jpegOrientation(data); got != ORIENTATION_NORMAL {
+		t.Fatalf("expected no orientation without EXIF, got %d", got)
+	}
+
+	for _, want := range []byte{ORIENTATION_FLIP_H, ORIENTATION_ROTATE_180, ORIENTATION_ROTATE_270} {
+		if got := jpegOrientation(withOrientation(data, want)); got != int(want) {
+			t.Fatalf("expected orientation %d, got %d", want, got)
+		}
+	}
+}
+
+func TestProcessWebP(t *testing.T) {
+	// 1x1 lossless webp
+	data, err := base64.StdEncoding.DecodeString("UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA==")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Process(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Width != 1 || result.Height != 1 || len(result.Variants) != 4 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestProcessKeepsTransparency(t *testing.T) {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, solid(10, 10, color.NRGBA{R: 10, A: 100}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range result.Variants {
+		if v.ContentType != "image/png" {
+			t.Fatalf("expected %s to stay png, got %s", v.Name, v.ContentType)
+		}
+	}
+}
+
+func TestProcessRejectsUnknownData(t *testing.T) {
+	_, err := Process([]byte("not an image"))
+	if err != ErrUnsupportedFormat {
+		t.Fatalf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
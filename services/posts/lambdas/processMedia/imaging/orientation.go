@@ -0,0 +1,146 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+// EXIF orientation values, how the stored pixels have to be turned to be
+// shown upright. cameras store the sensor readout and only set the tag
+const (
+	ORIENTATION_NORMAL     = 1
+	ORIENTATION_FLIP_H     = 2
+	ORIENTATION_ROTATE_180 = 3
+	ORIENTATION_FLIP_V     = 4
+	ORIENTATION_TRANSPOSE  = 5
+	ORIENTATION_ROTATE_90  = 6
+	ORIENTATION_TRANSVERSE = 7
+	ORIENTATION_ROTATE_270 = 8
+)
+
+const exifOrientationTag = 0x0112
+
+// the orientation tag of a jpeg's EXIF segment, ORIENTATION_NORMAL when there
+// is none or it can't be read
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return ORIENTATION_NORMAL
+	}
+
+	// walk the segments up to the start of the image data
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			return ORIENTATION_NORMAL
+		}
+
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 {
+			return ORIENTATION_NORMAL
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return ORIENTATION_NORMAL
+		}
+
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+
+		pos += 2 + length
+	}
+
+	return ORIENTATION_NORMAL
+}
+
+// reads the orientation entry of IFD0 in a TIFF structure
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return ORIENTATION_NORMAL
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return ORIENTATION_NORMAL
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return ORIENTATION_NORMAL
+	}
+
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return ORIENTATION_NORMAL
+		}
+
+		if order.Uint16(tiff[entry:]) != exifOrientationTag {
+			continue
+		}
+
+		orientation := int(order.Uint16(tiff[entry+8:]))
+		if orientation < ORIENTATION_NORMAL || orientation > ORIENTATION_ROTATE_270 {
+			return ORIENTATION_NORMAL
+		}
+
+		return orientation
+	}
+
+	return ORIENTATION_NORMAL
+}
+
+// turns img upright for the given orientation, the tag itself is dropped
+// when the variants are re-encoded so the pixels have to carry it
+func orient(img image.Image, orientation int) image.Image {
+	if orientation == ORIENTATION_NORMAL {
+		return img
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	// the source pixel shown at x, y of the upright image
+	var at func(x, y int) (int, int)
+	dstW, dstH := w, h
+	switch orientation {
+	case ORIENTATION_FLIP_H:
+		at = func(x, y int) (int, int) { return w - 1 - x, y }
+	case ORIENTATION_ROTATE_180:
+		at = func(x, y int) (int, int) { return w - 1 - x, h - 1 - y }
+	case ORIENTATION_FLIP_V:
+		at = func(x, y int) (int, int) { return x, h - 1 - y }
+	case ORIENTATION_TRANSPOSE:
+		dstW, dstH = h, w
+		at = func(x, y int) (int, int) { return y, x }
+	case ORIENTATION_ROTATE_90:
+		dstW, dstH = h, w
+		at = func(x, y int) (int, int) { return y, h - 1 - x }
+	case ORIENTATION_TRANSVERSE:
+		dstW, dstH = h, w
+		at = func(x, y int) (int, int) { return w - 1 - y, h - 1 - x }
+	case ORIENTATION_ROTATE_270:
+		dstW, dstH = h, w
+		at = func(x, y int) (int, int) { return w - 1 - y, x }
+	default:
+		return img
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		for x := 0; x < dstW; x++ {
+			sx, sy := at(x, y)
+			dst.Set(x, y, img.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"events/common/data"
+	"events/common/media"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"processMedia/imaging"
+)
+
+type app struct {
+	s3     *s3.S3
+	images ImageModel
+}
+
+// S3_ENDPOINT points the client at localstack when running locally, unset it
+// talks to S3 itself
+func NewS3Client() *s3.S3 {
+	config := aws.NewConfig().WithRegion("us-east-1")
+	if endpoint := os.Getenv("S3_ENDPOINT"); endpoint != "" {
+		config = config.WithEndpoint(endpoint).WithS3ForcePathStyle(true)
+	}
+
+	session := session.Must(session.NewSession())
+	return s3.New(session, config)
+}
+
+// builds thumbnails, resized copies and a blurhash for every image uploaded
+// to the media bucket
+func (app *app) handler(ctx context.Context, event events.S3Event) error {
+	for _, record := range event.Records {
+		bucket := record.S3.Bucket.Name
+		key := record.S3.Object.URLDecodedKey
+		if key == "" {
+			key = record.S3.Object.Key
+		}
+
+		err := app.process(ctx, bucket, key)
+		if err != nil {
+			fmt.Printf("Could not process %s: %s\n", key, err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (app *app) process(ctx context.Context, bucket, key string) error {
+	if !strings.HasPrefix(key, media.KEY_PREFIX+"/") {
+		return nil
+	}
+
+	obj, err := app.s3.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return err
+	}
+	defer obj.Body.Close()
+
+	// the presigned url pins the size but read no more than allowed anyway
+	upload, err := io.ReadAll(io.LimitReader(obj.Body, media.MAX_SIZE+1))
+	if err != nil {
+		return err
+	}
+
+	if len(upload) > media.MAX_SIZE {
+		return app.images.SaveFailure(key, media.ErrInvalidSize.Error())
+	}
+
+	result, err := imaging.Process(upload)
+	if err != nil {
+		switch {
+		case errors.Is(err, imaging.ErrUnsupportedFormat), errors.Is(err, imaging.ErrTooManyPixels):
+			fmt.Printf("Skipping %s: %s\n", key, err.Error())
+			return app.images.SaveFailure(key, err.Error())
+		default:
+			return err
+		}
+	}
+
+	variantKeys := make(map[string]string, len(result.Variants))
+	for _, v := range result.Variants {
+		variantKey := VariantKey(key, v.Name, imaging.Extension(v.ContentType))
+		_, err = app.s3.PutObjectWithContext(ctx, &s3.PutObjectInput{
+			Bucket:       aws.String(bucket),
+			Key:          aws.String(variantKey),
+			Body:         bytes.NewReader(v.Data),
+			ContentType:  aws.String(v.ContentType),
+			CacheControl: aws.String("public, max-age=31536000, immutable"),
+		})
+		if err != nil {
+			return err
+		}
+
+		variantKeys[v.Name] = variantKey
+	}
+
+	err = app.images.Save(key, result, variantKeys)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Processed %s into %d variants\n", key, len(result.Variants))
+	return nil
+}
+
+// posts/1/abc.png -> variants/posts/1/abc/thumb.jpg, the bucket notification
+// only fires for uploads so writing variants never triggers this lambda again
+func VariantKey(sourceKey, name, extension string) string {
+	base := strings.TrimSuffix(sourceKey, path.Ext(sourceKey))
+	return fmt.Sprintf("%s/%s/%s.%s", media.VARIANT_PREFIX, base, name, extension)
+}
+
+func main() {
+	db, err := data.OpenDB(os.Getenv("DB_ADDRESS"))
+	if err != nil {
+		fmt.Printf("Could not open db: %s\n", err.Error())
+		return
+	}
+
+	app := &app{
+		s3:     NewS3Client(),
+		images: ImageModel{DB: db},
+	}
+
+	lambda.Start(app.handler)
+}
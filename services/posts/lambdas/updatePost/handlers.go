@@ -1,45 +1,36 @@
 package main
 
 import (
-	"database/sql"
 	"errors"
 	"net/http"
 	"strconv"
 
+	"events/common/api"
+	"events/common/data"
+	"events/session"
 	"github.com/go-chi/chi/v5"
 )
 
-func (app *app) healthcheckHandler(w http.ResponseWriter, r *http.Request) {
-	err := app.writeJSON(w, http.StatusOK, envelope{"status": "available"}, nil)
-	if err != nil {
-		app.serverErrorResponse(w, r, err)
-		return
-	}
-}
-
-func (app *app) notFoundHandler(w http.ResponseWriter, r *http.Request) {
-	app.errorResponse(w, r, http.StatusNotFound, "resource not found")
-}
-
 func (app *app) updatePostHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil || id < 1 {
-		app.notFoundHandler(w, r)
+		api.NotFoundResponse(w, r)
 		return
 	}
 
 	var input struct {
-		Body string `json:"body"`
+		Body    string `json:"body"`
+		Version *int32 `json:"version"`
 	}
 
-	err = app.readJSON(w, r, &input)
+	err = api.ReadJSON(w, r, &input)
 	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, err)
+		api.ErrorResponse(w, r, http.StatusBadRequest, err)
 		return
 	}
 
 	if input.Body == "" {
-		app.errorResponse(
+		api.ErrorResponse(
 			w,
 			r,
 			http.StatusBadRequest,
@@ -49,7 +40,7 @@ func (app *app) updatePostHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if len(input.Body) > 20_000 {
-		app.errorResponse(
+		api.ErrorResponse(
 			w,
 			r,
 			http.StatusBadRequest,
@@ -58,31 +49,59 @@ func (app *app) updatePostHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	expectedVersion, hasExpected, err := api.ReadIfMatch(r)
+	if err != nil {
+		api.BadRequestResponse(w, r, err)
+		return
+	}
+
 	post, err := app.models.Posts.Get(int64(id))
 	if err != nil {
 		switch {
-		case errors.Is(err, sql.ErrNoRows):
-			app.notFoundHandler(w, r)
+		case errors.Is(err, data.ErrRecordNotFound):
+			api.NotFoundResponse(w, r)
 		default:
-			app.serverErrorResponse(w, r, err)
+			api.ServerErrorResponse(w, r, err)
 		}
 		return
 	}
 
+	user := session.ContextGetUser(r)
+	if !user.CanModify(post.User.Id) {
+		api.NotPermittedResponse(w, r)
+		return
+	}
+
+	// the client edited the version it read, either from the body of a read
+	// or the ETag of its last edit, without one the version just read is used
+	// which still guards against a write landing between the read and the
+	// update
+	if input.Version != nil {
+		expectedVersion, hasExpected = *input.Version, true
+	}
+
+	if hasExpected && expectedVersion != post.Version {
+		api.EditConflictResponse(w, r)
+		return
+	}
+
 	post.Body = input.Body
-	err = app.models.Posts.Update(post)
+	err = app.models.Posts.Update(post, user.Id)
 	if err != nil {
 		switch {
-		case errors.Is(err, sql.ErrNoRows):
-			app.notFoundHandler(w, r)
+		case errors.Is(err, data.ErrEditConflict):
+			api.EditConflictResponse(w, r)
 		default:
-			app.serverErrorResponse(w, r, err)
+			api.ServerErrorResponse(w, r, err)
 		}
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"post": post}, nil)
+	headers := make(http.Header)
+	headers.Set("ETag", api.VersionETag(post.Version))
+
+	err = api.WriteJSON(w, http.StatusOK, api.Envelope{"post": post}, headers)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.ServerErrorResponse(w, r, err)
 	}
 }
@@ -3,67 +3,97 @@ package main
 import (
 	"context"
 	"database/sql"
-	"errors"
+	"fmt"
 	"time"
+
+	"events/common/data"
+	"events/common/domain"
+	"events/common/outbox"
+	"events/common/revisions"
 )
 
 type PostModel struct {
 	DB *sql.DB
 }
 
-var (
-	ErrRecordNotFound = errors.New("record not found")
-)
-
 type Post struct {
 	Id        int64     `json:"id"`
 	Body      string    `json:"body"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-	User      User      `json:"user"`
-}
-
-type User struct {
-	Id             int64  `json:"id"`
-	ProfilePicture string `json:"profile_picture"`
-	Username       string `json:"username"`
+	Version   int32     `json:"version"`
+	User      data.User `json:"user"`
+	data.EditInfo
 }
 
-func (p *PostModel) Update(post *Post) error {
-	query := `
+// Update writes the body being replaced to post_revisions in the same
+// statement so the history can't miss an edit
+func (p *PostModel) Update(post *Post, editorId int64) error {
+	query := fmt.Sprintf(`
+	with old as (
+		select id, body, version from posts
+		where id = $1 and version = $3
+		for update
+	), revision as (
+		%s
+	)
 	update posts set
-	body = $2
-	where id = $1
-	returning updated_at
-	`
+	body = $2, updated_at = now(), version = posts.version + 1
+	from old
+	where posts.id = old.id
+	returning posts.updated_at, posts.version
+	`, revisions.Posts.InsertFrom("old", 4))
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := p.DB.QueryRowContext(ctx, query, post.Id, post.Body).Scan(&post.UpdatedAt)
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	args := []any{post.Id, post.Body, post.Version, editorId}
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&post.UpdatedAt, &post.Version)
 	if err != nil {
 		switch {
 		case err == sql.ErrNoRows:
-			return ErrRecordNotFound
+			return data.ErrEditConflict
 		default:
 			return err
 		}
 	}
 
-	return nil
+	post.EditInfo = data.NewEditInfo(post.Version)
+
+	event := domain.PostUpdated{
+		PostId:          post.Id,
+		PostUserId:      post.User.Id,
+		EditorUserId:    editorId,
+		Version:         post.Version,
+		PostBodyPreview: domain.BodyPreview(post.Body),
+		UpdatedAt:       post.UpdatedAt,
+	}
+
+	err = outbox.Enqueue(ctx, tx, outbox.AGGREGATE_POST, post.Id, event, post.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (p *PostModel) Get(id int64) (*Post, error) {
 	query := `
 	select posts.id, posts.body, posts.created_at, 
-	posts.updated_at, users.id, users.profile_picture, users.username 
+	posts.updated_at, posts.version, users.id, users.profile_picture, users.username 
 	from posts
 	left join users on users.id = posts.user_id
 	where posts.id = $1
 	`
 
 	post := Post{}
-	postUser := User{}
+	postUser := data.User{}
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
@@ -72,6 +102,7 @@ func (p *PostModel) Get(id int64) (*Post, error) {
 		&post.Body,
 		&post.CreatedAt,
 		&post.UpdatedAt,
+		&post.Version,
 		&postUser.Id,
 		&postUser.ProfilePicture,
 		&postUser.Username,
@@ -80,12 +111,13 @@ func (p *PostModel) Get(id int64) (*Post, error) {
 	if err != nil {
 		switch {
 		case err == sql.ErrNoRows:
-			return nil, ErrRecordNotFound
+			return nil, data.ErrRecordNotFound
 		default:
 			return nil, err
 		}
 	}
 
 	post.User = postUser
+	post.EditInfo = data.NewEditInfo(post.Version)
 	return &post, nil
 }
@@ -1,8 +1,10 @@
 import { CfnOutput, Tags } from 'aws-cdk-lib';
 import { Construct } from "constructs";
 import { RestApi, LambdaIntegration } from "aws-cdk-lib/aws-apigateway";
-import { Bucket } from 'aws-cdk-lib/aws-s3';
-import * as events from 'aws-cdk-lib/aws-events';
+import { Bucket, EventType, HttpMethods } from 'aws-cdk-lib/aws-s3';
+import { LambdaDestination } from 'aws-cdk-lib/aws-s3-notifications';
+import { Distribution } from 'aws-cdk-lib/aws-cloudfront';
+import { S3Origin } from 'aws-cdk-lib/aws-cloudfront-origins';
 import { createLambda } from '../../../lib/lambda';
 import * as path from "path"
 
@@ -14,35 +16,72 @@ enum BaseUrlPaths {
   CREATE_POST = "create",
   UPDATE = "update",
   DELETE = "delete",
+  REVISIONS = "revisions",
+  MEDIA = "media",
 }
 
 
 interface PostsProps {
   db_url?: string
-  eventBus: events.EventBus
+  session_secret?: string
+  // where processed media is served from, defaults to the media cdn
+  media_base_url?: string
+  s3_endpoint?: string
 }
 
 export class Posts extends Construct {
+  // where uploads are served from, avatars are uploaded here too
+  public readonly mediaBaseUrl: string
+
   constructor(scope: Construct, id: string, props: PostsProps) {
     super(scope, id);
-    const { eventBus } = props
 
     if (!props.db_url) {
       throw new Error("DB env var is not set")
     }
 
+    if (!props.session_secret) {
+      throw new Error("SESSION_SECRET env var is not set")
+    }
+
     const hotReloadBucket = Bucket.fromBucketName(
       this,
       "HotReloadingBucket",
       "hot-reload"
     )
 
+    // post attachments, clients upload straight to the bucket with presigned
+    // urls from the create lambda
+    const mediaBucket = new Bucket(this, "PostMediaBucket", {
+      bucketName: "post-media",
+      cors: [{
+        allowedMethods: [HttpMethods.PUT, HttpMethods.GET],
+        allowedOrigins: ["*"],
+        allowedHeaders: ["*"],
+      }],
+    })
+
+    // the bucket stays private, only the processed variants/ prefix is
+    // served so raw uploads (with their exif data) never are
+    const mediaCdn = new Distribution(this, "PostMediaCdn", {
+      defaultBehavior: {
+        origin: new S3Origin(mediaBucket, { originPath: "/variants" }),
+      },
+    })
+
+    const mediaBaseUrl = props.media_base_url ?? `https://${mediaCdn.distributionDomainName}`
+    this.mediaBaseUrl = mediaBaseUrl
+
     const lambdaPosts = createLambda(
       this,
       "getPostsFunc",
       path.join(__dirname, "../lambdas/getPosts"),
       hotReloadBucket,
-      { DB_ADDRESS: props.db_url },
+      {
+        DB_ADDRESS: props.db_url,
+        SESSION_SECRET: props.session_secret,
+        MEDIA_BASE_URL: mediaBaseUrl,
+      },
     )
 
     const lambdaCreate = createLambda(
@@ -50,16 +89,43 @@ export class Posts extends Construct {
       "createPostFunc",
       path.join(__dirname, "../lambdas/postPost"),
       hotReloadBucket,
-      { DB_ADDRESS: props.db_url, BUS_NAME: eventBus.eventBusName },
+      {
+        DB_ADDRESS: props.db_url,
+        SESSION_SECRET: props.session_secret,
+        MEDIA_BUCKET: mediaBucket.bucketName,
+        MEDIA_BASE_URL: mediaBaseUrl,
+        S3_ENDPOINT: props.s3_endpoint ?? "",
+      },
+    )
+    mediaBucket.grantPut(lambdaCreate)
+    // HeadObject on a missing key is a 404 only with read access
+    mediaBucket.grantRead(lambdaCreate)
+
+    // decoding full size images needs more than the default memory
+    const lambdaProcessMedia = createLambda(
+      this,
+      "processMediaFunc",
+      path.join(__dirname, "../lambdas/processMedia"),
+      hotReloadBucket,
+      { DB_ADDRESS: props.db_url, S3_ENDPOINT: props.s3_endpoint ?? "" },
+      "Builds thumbnails and blurhash placeholders for uploaded post media",
+      1024,
+    )
+    mediaBucket.grantReadWrite(lambdaProcessMedia)
+
+    // only uploads, the variants the lambda writes live under variants/
+    mediaBucket.addEventNotification(
+      EventType.OBJECT_CREATED,
+      new LambdaDestination(lambdaProcessMedia),
+      { prefix: "posts/" },
     )
-    eventBus.grantPutEventsTo(lambdaCreate)
 
     const lambdaUpdate = createLambda(
       this,
       "updatePostFunc",
       path.join(__dirname, "../lambdas/updatePost"),
       hotReloadBucket,
-      { DB_ADDRESS: props.db_url },
+      { DB_ADDRESS: props.db_url, SESSION_SECRET: props.session_secret },
     )
 
     const lambdaDelete = createLambda(
@@ -67,7 +133,7 @@ export class Posts extends Construct {
       "deletePostFunc",
       path.join(__dirname, "../lambdas/deletePost"),
       hotReloadBucket,
-      { DB_ADDRESS: props.db_url },
+      { DB_ADDRESS: props.db_url, SESSION_SECRET: props.session_secret },
 
     )
 
@@ -88,6 +154,9 @@ export class Posts extends Construct {
     const post = posts.addResource(BaseUrlPaths.BY_ID)
     post.addMethod("GET", integration)
 
+    const postRevisions = post.addResource(BaseUrlPaths.REVISIONS)
+    postRevisions.addMethod("GET", integration)
+
     const health = posts.addResource(BaseUrlPaths.HEALTH)
     health.addMethod("GET", integration)
 
@@ -96,6 +165,9 @@ export class Posts extends Construct {
     const create = api.root.addResource(BaseUrlPaths.CREATE_POST)
     create.addMethod("POST", createIntegration)
 
+    const createMedia = create.addResource(BaseUrlPaths.MEDIA)
+    createMedia.addMethod("POST", createIntegration)
+
     const createHealth = create.addResource(BaseUrlPaths.HEALTH)
     createHealth.addMethod("GET", createIntegration)
 
@@ -0,0 +1,16 @@
+module events/search
+
+go 1.21.5
+
+require (
+	events/common v0.0.0
+	github.com/go-chi/chi/v5 v5.0.11
+)
+
+require (
+	github.com/aws/aws-lambda-go v1.43.0 // indirect
+	github.com/awslabs/aws-lambda-go-api-proxy v0.16.0 // indirect
+	github.com/lib/pq v1.10.9 // indirect
+)
+
+replace events/common => ../../../common
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+
+	"events/common/api"
+	"events/search/models"
+)
+
+func (app *app) searchHandler(w http.ResponseWriter, r *http.Request) {
+	filters, err := models.ReadFilters(r.URL.Query())
+	if err != nil {
+		api.BadRequestResponse(w, r, err)
+		return
+	}
+
+	results, metadata, err := app.models.Search.Search(filters)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = api.WriteJSON(w, http.StatusOK, api.Envelope{"results": results, "metadata": metadata}, nil)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+
+	"events/common/api"
+	"events/common/data"
+	"events/search/models"
+	"github.com/go-chi/chi/v5"
+)
+
+var router *chi.Mux
+
+type app struct {
+	models models.Models
+}
+
+func init() {
+	db, err := data.OpenDB(os.Getenv("DB_ADDRESS"))
+	if err != nil {
+		panic(err)
+	}
+
+	app := app{models: models.NewModels(db, os.Getenv("MEDIA_BASE_URL"))}
+	r := api.NewRouter()
+	r.Route("/search", func(r chi.Router) {
+		r.Get("/healthcheck", api.HealthcheckHandler)
+		r.Get("/", app.searchHandler)
+	})
+
+	router = r
+}
+
+func main() {
+	api.Start(router)
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	"events/common/api"
+	"events/common/cursor"
+)
+
+const (
+	TYPE_ALL      = "all"
+	TYPE_POSTS    = "posts"
+	TYPE_COMMENTS = "comments"
+
+	DATE_LAYOUT = "2006-01-02"
+)
+
+var (
+	ErrInvalidType      = errors.New("type must be one of all, posts or comments")
+	ErrInvalidDate      = errors.New("from and to must be dates like 2024-01-31 or RFC 3339 timestamps")
+	ErrInvalidDateRange = errors.New("from must be before to")
+)
+
+// Filters is a validated search request, Query is the tsquery ParseQuery
+// made of q
+type Filters struct {
+	Query  string
+	Type   string
+	Author string
+	From   *time.Time
+	To     *time.Time
+	Take   int
+	// scoped to Query, posts and comments share a page so the cursor carries
+	// the kind of the last match to tell apart matches with the same id
+	After *cursor.Ranked
+}
+
+func ReadFilters(qs url.Values) (Filters, error) {
+	f := Filters{Type: TYPE_ALL, Author: qs.Get("author")}
+
+	take, err := api.ReadInt(qs, "take", cursor.DEFAULT_TAKE)
+	if err == nil {
+		err = cursor.ValidateFilters(cursor.Filters{Take: take})
+	}
+
+	if err != nil {
+		return f, cursor.ErrInvalidTake
+	}
+	f.Take = take
+
+	f.Query, err = ParseQuery(qs.Get("q"))
+	if err != nil {
+		return f, err
+	}
+
+	if t := qs.Get("type"); t != "" {
+		if t != TYPE_ALL && t != TYPE_POSTS && t != TYPE_COMMENTS {
+			return f, ErrInvalidType
+		}
+		f.Type = t
+	}
+
+	f.From, err = readDate(qs.Get("from"), false)
+	if err != nil {
+		return f, err
+	}
+
+	f.To, err = readDate(qs.Get("to"), true)
+	if err != nil {
+		return f, err
+	}
+
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return f, ErrInvalidDateRange
+	}
+
+	if s := qs.Get("cursor"); s != "" {
+		c, err := cursor.DecodeRanked(s, f.Query)
+		if err != nil || (c.Kind != KIND_POST && c.Kind != KIND_COMMENT) {
+			return f, cursor.ErrInvalidCursor
+		}
+		f.After = c
+	}
+
+	return f, nil
+}
+
+// a plain date as the end of a range includes the whole day, the range is
+// searched as [from, to)
+func readDate(s string, end bool) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err == nil {
+		return &t, nil
+	}
+
+	t, err = time.Parse(DATE_LAYOUT, s)
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return &t, nil
+}
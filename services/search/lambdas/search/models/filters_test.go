@@ -0,0 +1,75 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"events/common/cursor"
+)
+
+func TestReadFilters(t *testing.T) {
+	f, err := ReadFilters(url.Values{
+		"q":      {"fox*"},
+		"type":   {"comments"},
+		"author": {"beloved-user-1"},
+		"from":   {"2024-01-01"},
+		"to":     {"2024-01-31"},
+		"take":   {"5"},
+	})
+	if err != nil {
+		t.Fatalf("reading filters: %v", err)
+	}
+
+	if f.Query != "fox:*" || f.Type != TYPE_COMMENTS || f.Author != "beloved-user-1" || f.Take != 5 {
+		t.Fatalf("unexpected filters %+v", f)
+	}
+
+	// the last day of the range is included
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	if !f.From.Equal(from) || !f.To.Equal(to) {
+		t.Fatalf("got range %v to %v, want %v to %v", f.From, f.To, from, to)
+	}
+}
+
+func TestReadFiltersDefaults(t *testing.T) {
+	f, err := ReadFilters(url.Values{"q": {"fox"}})
+	if err != nil {
+		t.Fatalf("reading filters: %v", err)
+	}
+
+	if f.Type != TYPE_ALL || f.Take != cursor.DEFAULT_TAKE || f.From != nil || f.To != nil || f.After != nil {
+		t.Fatalf("unexpected filters %+v", f)
+	}
+}
+
+func TestReadFiltersInvalid(t *testing.T) {
+	otherSearch := cursor.Ranked{Scope: "dog", Score: 0.1, Kind: KIND_POST, Id: 3}.Encode()
+	noKind := cursor.Ranked{Scope: "fox", Score: 0.1, Id: 3}.Encode()
+
+	tests := []struct {
+		name string
+		qs   url.Values
+		want error
+	}{
+		{"no query", url.Values{}, ErrInvalidQuery},
+		{"type", url.Values{"q": {"fox"}, "type": {"users"}}, ErrInvalidType},
+		{"from", url.Values{"q": {"fox"}, "from": {"yesterday"}}, ErrInvalidDate},
+		{"range", url.Values{"q": {"fox"}, "from": {"2024-02-01"}, "to": {"2024-01-01"}}, ErrInvalidDateRange},
+		{"take", url.Values{"q": {"fox"}, "take": {"500"}}, cursor.ErrInvalidTake},
+		{"cursor", url.Values{"q": {"fox"}, "cursor": {"nope"}}, cursor.ErrInvalidCursor},
+		{"cursor of another search", url.Values{"q": {"fox"}, "cursor": {otherSearch}}, cursor.ErrInvalidCursor},
+		{"cursor without a kind", url.Values{"q": {"fox"}, "cursor": {noKind}}, cursor.ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadFilters(tt.qs)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"database/sql"
+)
+
+type Models struct {
+	Search SearchModel
+}
+
+func NewModels(db *sql.DB, mediaBaseURL string) Models {
+	return Models{
+		Search: SearchModel{DB: db, MediaBaseURL: mediaBaseURL},
+	}
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	QUERY_MAX_LENGTH = 200
+	QUERY_MAX_TERMS  = 10
+)
+
+var ErrInvalidQuery = fmt.Errorf(
+	"q must have 1 to %d words, phrases or prefixes and be at most %d characters",
+	QUERY_MAX_TERMS,
+	QUERY_MAX_LENGTH,
+)
+
+// ParseQuery turns what a user typed into a tsquery where every term has to
+// match:
+//
+//	fox          the word, stemmed like the indexed text
+//	"brown fox"  the words next to each other in this order
+//	fox*         any word starting with fox
+//
+// Only letters and digits of q make it into the tsquery, everything else
+// separates words, so nothing the user types is parsed as tsquery syntax
+func ParseQuery(q string) (string, error) {
+	if len(q) > QUERY_MAX_LENGTH {
+		return "", ErrInvalidQuery
+	}
+
+	var terms []string
+	for i, part := range strings.Split(q, `"`) {
+		// odd parts were between quotes, an unclosed quote runs to the end
+		if i%2 == 1 {
+			if phrase := followedBy(words(part), false); phrase != "" {
+				terms = append(terms, phrase)
+			}
+			continue
+		}
+
+		for _, field := range strings.Fields(part) {
+			prefix := strings.HasSuffix(field, "*")
+			if term := followedBy(words(field), prefix); term != "" {
+				terms = append(terms, term)
+			}
+		}
+	}
+
+	if len(terms) == 0 || len(terms) > QUERY_MAX_TERMS {
+		return "", ErrInvalidQuery
+	}
+
+	return strings.Join(terms, " & "), nil
+}
+
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// words that have to follow each other, a single word on its own. Words a
+// bare term was split into, like e-mail, are kept together the same way
+func followedBy(ws []string, prefix bool) string {
+	if len(ws) == 0 {
+		return ""
+	}
+
+	if prefix {
+		ws[len(ws)-1] += ":*"
+	}
+
+	if len(ws) == 1 {
+		return ws[0]
+	}
+
+	return "(" + strings.Join(ws, " <-> ") + ")"
+}
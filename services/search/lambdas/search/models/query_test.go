@@ -0,0 +1,51 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		q    string
+		want string
+		err  error
+	}{
+		{"fox", "fox", nil},
+		{"Quick FOX", "quick & fox", nil},
+		{`"brown fox" jumps`, "(brown <-> fox) & jumps", nil},
+		{"jump*", "jump:*", nil},
+		{"e-mail", "(e <-> mail)", nil},
+		{"e-mai*", "(e <-> mai:*)", nil},
+		{`"unclosed phrase`, "(unclosed <-> phrase)", nil},
+		{`"" fox`, "fox", nil},
+		{"fox & !dog | cat:* <->", "fox & dog & cat:*", nil},
+		{"''; drop table posts", "drop & table & posts", nil},
+		{"", "", ErrInvalidQuery},
+		{`* " " &`, "", ErrInvalidQuery},
+		{strings.Repeat("a ", QUERY_MAX_TERMS+1), "", ErrInvalidQuery},
+		{strings.Repeat("a", QUERY_MAX_LENGTH+1), "", ErrInvalidQuery},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseQuery(tt.q)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParseQuery(%q) error = %v, want %v", tt.q, err, tt.err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("ParseQuery(%q) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	headline := "the " + HIGHLIGHT_START + "fox" + HIGHLIGHT_STOP + " said <script>alert(1)</script> & left"
+	want := "the <mark>fox</mark> said &lt;script&gt;alert(1)&lt;/script&gt; &amp; left"
+
+	if got := Highlight(headline); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,209 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"html"
+	"strings"
+	"time"
+
+	"events/common/cursor"
+	"events/common/data"
+	"events/common/media"
+)
+
+const (
+	KIND_POST    = "post"
+	KIND_COMMENT = "comment"
+
+	// postgres marks the matched words with these, the snippet is escaped
+	// before they are swapped for tags so the text itself can not add markup
+	HIGHLIGHT_START = "\x02"
+	HIGHLIGHT_STOP  = "\x03"
+
+	HEADLINE_OPTIONS = "StartSel=" + HIGHLIGHT_START + ", StopSel=" + HIGHLIGHT_STOP +
+		`, MinWords=15, MaxWords=35, MaxFragments=2, FragmentDelimiter=" … "`
+)
+
+type SearchModel struct {
+	DB           *sql.DB
+	MediaBaseURL string
+}
+
+// Result is the post a match was found in, which is the post itself or the
+// post the matching comment was left on. posts are shaped like every other
+// list of posts so clients can render results with the same components
+type Result struct {
+	data.PostData
+	Match Match `json:"match"`
+}
+
+type Match struct {
+	Kind      string    `json:"kind"`
+	Id        int64     `json:"id"`
+	User      data.User `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+	// the matching parts of the text, escaped html with the matched words in
+	// <mark> tags
+	Snippet string `json:"snippet"`
+	// what the results are ranked by, only needed for the cursor
+	Score float64 `json:"-"`
+}
+
+func (s *SearchModel) Search(f Filters) ([]Result, cursor.Metadata, error) {
+	query := `
+	WITH query AS (
+		SELECT to_tsquery('english', $1) AS q
+	), matches AS (
+		SELECT 'post' AS kind, post.id, post.id AS post_id, post.body, post.user_id,
+		post.created_at, ts_rank_cd(post.search, query.q)::float8 AS score
+		FROM posts AS post, query
+		WHERE $2::bool AND post.search @@ query.q
+		AND ($4::text = '' OR post.user_id = (
+			SELECT id FROM users WHERE lower(username) = lower($4)
+		))
+		AND ($5::timestamptz IS NULL OR post.created_at >= $5)
+		AND ($6::timestamptz IS NULL OR post.created_at < $6)
+		UNION ALL
+		SELECT 'comment', comment.id, comment.post_id, comment.body, comment.user_id,
+		comment.created_at, ts_rank_cd(comment.search, query.q)::float8
+		FROM comments AS comment, query
+		WHERE $3::bool AND comment.search @@ query.q AND comment.deleted_at IS NULL
+		AND ($4::text = '' OR comment.user_id = (
+			SELECT id FROM users WHERE lower(username) = lower($4)
+		))
+		AND ($5::timestamptz IS NULL OR comment.created_at >= $5)
+		AND ($6::timestamptz IS NULL OR comment.created_at < $6)
+	), page AS (
+		SELECT * FROM matches
+		WHERE $7::bool OR (score, kind, id) < ($8::float8, $9::text, $10::bigint)
+		ORDER BY score DESC, kind DESC, id DESC
+		LIMIT $11
+	)
+	SELECT page.kind, page.id, page.score, page.created_at,
+	ts_headline('english', page.body, query.q, $12),
+	author.id, author.username, author.profile_picture,
+	post.id, post.body, post.created_at, post.updated_at, post.version,
+	users.id, users.username, users.profile_picture,
+	stats.comments_count, stats.last_comment_at, stats.last_comment_body
+	FROM page
+	CROSS JOIN query
+	JOIN posts AS post ON post.id = page.post_id
+	JOIN users ON users.id = post.user_id
+	JOIN users AS author ON author.id = page.user_id
+	CROSS JOIN LATERAL (
+		SELECT COUNT(*) AS comments_count, MAX(comment.created_at) AS last_comment_at,
+		MAX(comment.body) AS last_comment_body
+		FROM comments AS comment
+		WHERE comment.post_id = post.id AND comment.path = '0'
+		AND comment.deleted_at IS NULL
+	) AS stats
+	ORDER BY page.score DESC, page.kind DESC, page.id DESC
+	`
+
+	metadata := cursor.Metadata{}
+	results := []Result{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	score, kind, id, first := f.After.Args()
+
+	// one extra row tells whether there is another page
+	args := []any{
+		f.Query,
+		f.Type != TYPE_COMMENTS,
+		f.Type != TYPE_POSTS,
+		f.Author,
+		f.From,
+		f.To,
+		first,
+		score,
+		kind,
+		id,
+		f.Take + 1,
+		HEADLINE_OPTIONS,
+	}
+
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, metadata, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var result Result
+		var lastCommentAt sql.NullTime
+		var lastCommentBody sql.NullString
+
+		err := rows.Scan(
+			&result.Match.Kind,
+			&result.Match.Id,
+			&result.Match.Score,
+			&result.Match.CreatedAt,
+			&result.Match.Snippet,
+			&result.Match.User.Id,
+			&result.Match.User.Username,
+			&result.Match.User.ProfilePicture,
+			&result.Post.Id,
+			&result.Post.Body,
+			&result.Post.CreatedAt,
+			&result.Post.UpdatedAt,
+			&result.Post.Version,
+			&result.Post.User.Id,
+			&result.Post.User.Username,
+			&result.Post.User.ProfilePicture,
+			&result.Metadata.CommentsCount,
+			&lastCommentAt,
+			&lastCommentBody,
+		)
+		if err != nil {
+			return nil, metadata, err
+		}
+
+		if lastCommentAt.Valid {
+			result.Metadata.LastCommentAt = lastCommentAt.Time
+		}
+
+		if lastCommentBody.Valid {
+			result.Metadata.LatestComment = lastCommentBody.String
+		}
+
+		result.Post.EditInfo = data.NewEditInfo(result.Post.Version)
+		result.Match.Snippet = Highlight(result.Match.Snippet)
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, metadata, err
+	}
+
+	results, metadata = cursor.PaginateRanked(f.Take, results, func(r Result) cursor.Ranked {
+		return cursor.Ranked{Scope: f.Query, Score: r.Match.Score, Kind: r.Match.Kind, Id: r.Match.Id}
+	})
+
+	postIds := make([]int64, len(results))
+	for i := range results {
+		postIds[i] = results[i].Post.Id
+	}
+
+	attachments, err := media.LoadForPosts(ctx, s.DB, s.MediaBaseURL, postIds)
+	if err != nil {
+		return nil, metadata, err
+	}
+
+	for i := range results {
+		results[i].Post.Media = media.OrEmpty(attachments[results[i].Post.Id])
+	}
+
+	return results, metadata, nil
+}
+
+// Highlight escapes a headline from postgres and marks the words it matched
+func Highlight(headline string) string {
+	return strings.NewReplacer(
+		HIGHLIGHT_START, "<mark>",
+		HIGHLIGHT_STOP, "</mark>",
+	).Replace(html.EscapeString(headline))
+}
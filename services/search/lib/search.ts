@@ -0,0 +1,55 @@
+import { CfnOutput, Tags } from 'aws-cdk-lib';
+import { Construct } from "constructs";
+import { RestApi, LambdaIntegration } from "aws-cdk-lib/aws-apigateway";
+import { Bucket } from 'aws-cdk-lib/aws-s3';
+import { createLambda } from '../../../lib/lambda';
+import * as path from "path"
+
+interface SearchProps {
+	db_url?: string
+	media_base_url: string
+}
+
+export class Search extends Construct {
+	constructor(scope: Construct, id: string, props: SearchProps) {
+		super(scope, id);
+
+		if (!props.db_url) {
+			throw new Error("DB env var is not set")
+		}
+
+		const hotReloadBucket = Bucket.fromBucketName(
+			this,
+			"HotReloadingBucket",
+			"hot-reload"
+		)
+
+		const search = createLambda(
+			this,
+			"search",
+			path.join(__dirname, "../lambdas/search"),
+			hotReloadBucket,
+			{ DB_ADDRESS: props.db_url, MEDIA_BASE_URL: props.media_base_url },
+			"Full text search over posts and comments",
+		)
+
+		const api = new RestApi(this, "searchapi", {
+			restApiName: "searchapi",
+			description: "API for searching posts and comments",
+		})
+		Tags.of(api).add("_custom_id_", "searchapi")
+
+		// /search
+		// /search/healthcheck
+		const base = api.root.addResource("search")
+		const healthcheck = base.addResource("healthcheck")
+
+		const searchIntegration = new LambdaIntegration(search)
+		base.addMethod("GET", searchIntegration)
+		healthcheck.addMethod("GET", searchIntegration)
+
+		new CfnOutput(this, "GatewayId", { value: api.restApiId })
+		new CfnOutput(this, "GatewayUrl", { value: api.url })
+		new CfnOutput(this, "GatewayEndPoints", { value: "\n" + api.methods.join("\n") })
+	}
+}
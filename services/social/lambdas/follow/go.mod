@@ -3,11 +3,19 @@ module follow
 go 1.21.6
 
 require (
-	github.com/aws/aws-lambda-go v1.46.0
-	github.com/aws/aws-sdk-go v1.50.20
-	github.com/awslabs/aws-lambda-go-api-proxy v0.16.1
+	events/common v0.0.0
+	events/session v0.0.0
 	github.com/go-chi/chi/v5 v5.0.12
-	github.com/lib/pq v1.10.9
 )
 
-require github.com/jmespath/go-jmespath v0.4.0 // indirect
+require (
+	github.com/aws/aws-lambda-go v1.46.0 // indirect
+	github.com/aws/aws-sdk-go v1.50.20 // indirect
+	github.com/awslabs/aws-lambda-go-api-proxy v0.16.1 // indirect
+	github.com/jmespath/go-jmespath v0.4.0 // indirect
+	github.com/lib/pq v1.10.9 // indirect
+)
+
+replace events/common => ../../../common
+
+replace events/session => ../../../auth/session
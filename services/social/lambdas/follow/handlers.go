@@ -3,62 +3,125 @@ package main
 import (
 	"errors"
 	"net/http"
-	"strconv"
 
-	"github.com/go-chi/chi/v5"
+	"events/common/api"
+	"events/common/cursor"
+	"events/common/data"
+	"events/session"
 )
 
-func (app *app) healthcheckHandler(w http.ResponseWriter, r *http.Request) {
-	err := app.writeJSON(w, http.StatusOK, envelope{"status": "available"}, nil)
+func (app *app) follow(w http.ResponseWriter, r *http.Request) {
+	userId, err := api.ReadIDParam(r, "user")
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.ErrorResponse(w, r, http.StatusBadRequest, "invalid user ID")
 		return
 	}
-}
 
-func (app *app) notFoundHandler(w http.ResponseWriter, r *http.Request) {
-	app.errorResponse(w, r, http.StatusNotFound, "resource not found")
-}
+	following, err := app.models.User.GetUser(userId)
+	if err != nil {
+		switch err {
+		case data.ErrRecordNotFound:
+			api.NotFoundResponse(w, r)
+		default:
+			api.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
 
-func (app *app) follow(w http.ResponseWriter, r *http.Request) {
-	tempUserId := int64(4)
-	toFollow := chi.URLParam(r, "user")
+	err = app.models.Social.Follow(session.ContextGetUser(r).Id, following.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrAlreadyFollowing), errors.Is(err, ErrFollowSelf):
+			api.ErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		default:
+			api.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// TODO add header to users followers endpoint
 
-	userId, err := strconv.ParseInt(toFollow, 10, 64)
+	err = api.WriteJSON(w, http.StatusOK, api.Envelope{"status": "followed"}, nil)
 	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, "invalid user ID")
+		api.ServerErrorResponse(w, r, err)
 		return
 	}
+}
 
-	following, err := app.models.User.GetUser(userId)
+func (app *app) unfollow(w http.ResponseWriter, r *http.Request) {
+	userId, err := api.ReadIDParam(r, "user")
 	if err != nil {
-		switch err {
-		case ErrRecordNotFound:
-			app.notFoundHandler(w, r)
+		api.ErrorResponse(w, r, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	err = app.models.Social.Unfollow(session.ContextGetUser(r).Id, userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			api.NotFoundResponse(w, r)
 		default:
-			app.serverErrorResponse(w, r, err)
+			api.ServerErrorResponse(w, r, err)
 		}
 		return
 	}
 
-	follower := User{Id: tempUserId}
+	err = api.WriteJSON(w, http.StatusOK, api.Envelope{"status": "unfollowed"}, nil)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (app *app) listFollowers(w http.ResponseWriter, r *http.Request) {
+	app.listConnections(w, r, "followers", app.models.Social.Followers)
+}
+
+func (app *app) listFollowing(w http.ResponseWriter, r *http.Request) {
+	app.listConnections(w, r, "following", app.models.Social.Following)
+}
 
-	err = app.models.Social.Follow(&follower, &following)
+func (app *app) listConnections(
+	w http.ResponseWriter,
+	r *http.Request,
+	key string,
+	list func(userId int64, filters cursor.Filters) ([]data.User, cursor.Metadata, error),
+) {
+	userId, err := api.ReadIDParam(r, "id")
+	if err != nil {
+		api.NotFoundResponse(w, r)
+		return
+	}
+
+	filters, err := cursor.ReadFilters(r.URL.Query())
+	if err != nil {
+		api.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user, err := app.models.User.GetUser(userId)
 	if err != nil {
 		switch {
-		case errors.Is(err, ErrAlreadyFollowing):
-			app.errorResponse(w, r, http.StatusBadRequest, err.Error())
+		case errors.Is(err, data.ErrRecordNotFound):
+			api.NotFoundResponse(w, r)
 		default:
-			app.serverErrorResponse(w, r, err)
+			api.ServerErrorResponse(w, r, err)
 		}
 		return
 	}
 
-	// TODO add header to users followers endpoint
-
-	err = app.writeJSON(w, http.StatusOK, envelope{"status": "followed"}, nil)
+	users, metadata, err := list(userId, filters)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		api.ServerErrorResponse(w, r, err)
 		return
 	}
+
+	err = api.WriteJSON(
+		w,
+		http.StatusOK,
+		api.Envelope{"user": user, key: users, "metadata": metadata},
+		nil,
+	)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+	}
 }
@@ -2,13 +2,6 @@ package main
 
 import (
 	"database/sql"
-	"errors"
-)
-
-var (
-	ErrRecordNotFound   = errors.New("record not found")
-	ErrAlreadyLiked     = errors.New("user has already liked this")
-	ErrAlreadyFollowing = errors.New("user has already followed this")
 )
 
 type Models struct {
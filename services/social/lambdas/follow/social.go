@@ -3,13 +3,21 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
+
+	"events/common/cursor"
+	"events/common/data"
+	"events/common/timeline"
 )
 
+var ErrFollowSelf = errors.New("cannot follow yourself")
+
 type FriendNode struct {
-	Id   int64 `json:"id"`
-	User User  `json:"user"`
+	Id   int64     `json:"id"`
+	User data.User `json:"user"`
 }
 
 type FiendEdge struct {
@@ -21,55 +29,218 @@ type SocialModel struct {
 	DB *sql.DB
 }
 
-func (m *SocialModel) Follow(follower, following *User) error {
+// users only get a node once they take part in a follow, so create it on
+// demand instead of assuming sign up made one
+func ensureNode(ctx context.Context, tx *sql.Tx, userId int64) (int64, error) {
 	query := `
-		select id, userid from friend_nodes
-		where userid = $1 or userid = $2
+		insert into friend_nodes (userId)
+		values ($1)
+		on conflict (userId) do nothing
+		returning id
 	`
 
+	var nodeId int64
+	err := tx.QueryRowContext(ctx, query, userId).Scan(&nodeId)
+	if err == nil {
+		return nodeId, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	query = `select id from friend_nodes where userId = $1`
+	err = tx.QueryRowContext(ctx, query, userId).Scan(&nodeId)
+	return nodeId, err
+}
+
+func (m *SocialModel) Follow(followerId, followingId int64) error {
+	if followerId == followingId {
+		return ErrFollowSelf
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, follower.Id, following.Id)
+	tx, err := m.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	defer rows.Close()
-
-	for rows.Next() {
-		var n FriendNode
-		err = rows.Scan(&n.Id, &n.User.Id)
-		if err != nil {
-			return err
-		}
-
-		if n.User.Id == follower.Id {
-			follower.Id = n.Id
-		} else {
-			following.Id = n.Id
-		}
+	followerNode, err := ensureNode(ctx, tx, followerId)
+	if err != nil {
+		return err
 	}
 
-	if err = rows.Err(); err != nil {
+	followingNode, err := ensureNode(ctx, tx, followingId)
+	if err != nil {
 		return err
 	}
 
-	query = `
+	query := `
 		insert into friend_edges
 		(previous_node, next_node)
 		values ($1, $2)
 	`
 
-	_, err = m.DB.ExecContext(ctx, query, follower.Id, following.Id)
+	_, err = tx.ExecContext(ctx, query, followerNode, followingNode)
 	if err != nil {
 		switch {
 		case strings.Contains(err.Error(), "pq: duplicate key value violates unique constraint "):
-			return ErrAlreadyFollowing
+			return data.ErrAlreadyFollowing
 		default:
 			return err
 		}
 	}
 
-	return nil
+	query = `
+		update users set
+			following_count = following_count + case when id = $1 then 1 else 0 end,
+			followers_count = followers_count + case when id = $2 then 1 else 0 end
+		where id in ($1, $2)
+	`
+
+	_, err = tx.ExecContext(ctx, query, followerId, followingId)
+	if err != nil {
+		return err
+	}
+
+	_, err = timeline.Backfill(ctx, tx, followerId, followingId, timeline.BACKFILL_LIMIT)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *SocialModel) Unfollow(followerId, followingId int64) error {
+	query := `
+		delete from friend_edges e
+		using friend_nodes p, friend_nodes n
+		where e.previous_node = p.id and e.next_node = n.id
+		and p.userId = $1 and n.userId = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, followerId, followingId)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return data.ErrRecordNotFound
+	}
+
+	query = `
+		update users set
+			following_count = greatest(following_count - case when id = $1 then 1 else 0 end, 0),
+			followers_count = greatest(followers_count - case when id = $2 then 1 else 0 end, 0)
+		where id in ($1, $2)
+	`
+
+	_, err = tx.ExecContext(ctx, query, followerId, followingId)
+	if err != nil {
+		return err
+	}
+
+	_, err = timeline.RemoveAccount(ctx, tx, followerId, followingId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// users following userId, most recent follow first
+func (m *SocialModel) Followers(userId int64, filters cursor.Filters) ([]data.User, cursor.Metadata, error) {
+	scan := filters.ScanOrder(cursor.DESC)
+	query := fmt.Sprintf(`
+		select u.id, u.username, u.profile_picture, e.created_at
+		from friend_edges e
+		join friend_nodes p on p.id = e.previous_node
+		join friend_nodes n on n.id = e.next_node
+		join users u on u.id = p.userId
+		where n.userId = $1
+		and ($4 or (e.created_at, u.id) %s ($2, $3))
+		order by e.created_at %s, u.id %s
+		limit $5
+	`, filters.Compare(cursor.DESC), scan, scan)
+
+	return m.listUsers(query, userId, filters)
+}
+
+// users that userId follows, most recent follow first
+func (m *SocialModel) Following(userId int64, filters cursor.Filters) ([]data.User, cursor.Metadata, error) {
+	scan := filters.ScanOrder(cursor.DESC)
+	query := fmt.Sprintf(`
+		select u.id, u.username, u.profile_picture, e.created_at
+		from friend_edges e
+		join friend_nodes p on p.id = e.previous_node
+		join friend_nodes n on n.id = e.next_node
+		join users u on u.id = n.userId
+		where p.userId = $1
+		and ($4 or (e.created_at, u.id) %s ($2, $3))
+		order by e.created_at %s, u.id %s
+		limit $5
+	`, filters.Compare(cursor.DESC), scan, scan)
+
+	return m.listUsers(query, userId, filters)
+}
+
+type connection struct {
+	user       data.User
+	followedAt time.Time
+}
+
+func (m *SocialModel) listUsers(query string, userId int64, filters cursor.Filters) ([]data.User, cursor.Metadata, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	createdAt, id, first := filters.Args()
+	rows, err := m.DB.QueryContext(ctx, query, userId, createdAt, id, first, filters.Limit())
+	if err != nil {
+		return nil, cursor.Metadata{}, err
+	}
+
+	defer rows.Close()
+
+	conns := []connection{}
+	for rows.Next() {
+		var c connection
+		err = rows.Scan(&c.user.Id, &c.user.Username, &c.user.ProfilePicture, &c.followedAt)
+		if err != nil {
+			return nil, cursor.Metadata{}, err
+		}
+
+		conns = append(conns, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, cursor.Metadata{}, err
+	}
+
+	conns, metadata := cursor.Paginate(filters, conns, func(c connection) (time.Time, int64) {
+		return c.followedAt, c.user.Id
+	})
+
+	users := make([]data.User, len(conns))
+	for i, c := range conns {
+		users[i] = c.user
+	}
+
+	return users, metadata, nil
 }
@@ -5,21 +5,23 @@ import (
 	"database/sql"
 	"errors"
 	"time"
-)
 
-type User struct {
-	Id             int64  `json:"id"`
-	Username       string `json:"username"`
-	ProfilePicture string `json:"profile_picture"`
-}
+	"events/common/data"
+)
 
 type UserModel struct {
 	DB *sql.DB
 }
 
-func (u *UserModel) GetUser(userId int64) (User, error) {
+type UserProfile struct {
+	data.User
+	FollowersCount int64 `json:"followers_count"`
+	FollowingCount int64 `json:"following_count"`
+}
+
+func (u *UserModel) GetUser(userId int64) (UserProfile, error) {
 	query := `
-		select id, username, profile_picture
+		select id, username, profile_picture, followers_count, following_count
 		from users
 		where id = $1
 	`
@@ -29,12 +31,18 @@ func (u *UserModel) GetUser(userId int64) (User, error) {
 
 	row := u.DB.QueryRowContext(ctx, query, userId)
 
-	var user User
-	err := row.Scan(&user.Id, &user.Username, &user.ProfilePicture)
+	var user UserProfile
+	err := row.Scan(
+		&user.Id,
+		&user.Username,
+		&user.ProfilePicture,
+		&user.FollowersCount,
+		&user.FollowingCount,
+	)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
-			return user, ErrRecordNotFound
+			return user, data.ErrRecordNotFound
 		default:
 			return user, err
 		}
@@ -10,6 +10,7 @@ import * as path from "path"
 
 interface SocialProps {
 	db_url?: string
+	session_secret?: string
 	eventBus: events.EventBus
 }
 
@@ -22,6 +23,10 @@ export class Social extends Construct {
 			throw new Error("DB env var is not set")
 		}
 
+		if (!props.session_secret) {
+			throw new Error("SESSION_SECRET env var is not set")
+		}
+
 		const hotReloadBucket = Bucket.fromBucketName(
 			this,
 			"HotReloadingBucket",
@@ -34,18 +39,34 @@ export class Social extends Construct {
 			"follow",
 			path.join(__dirname, "../lambdas/follow"),
 			hotReloadBucket,
-			{ DB_ADDRESS: props.db_url },
+			{ DB_ADDRESS: props.db_url, SESSION_SECRET: props.session_secret },
 		)
 
 		eventBus.grantPutEventsTo(follow)
 
-		const api = new RestApi(this, "likesapi", {
-			restApiName: "likesapi",
-			description: "API for likes",
+		const api = new RestApi(this, "socialapi", {
+			restApiName: "socialapi",
+			description: "API for the social graph",
 		})
-		Tags.of(api).add("_custom_id_", "likesapi")
+		Tags.of(api).add("_custom_id_", "socialapi")
 
+		// /v1/follow/{user}
+		// /v1/users/{id}/followers
+		// /v1/users/{id}/following
+		const base = api.root.addResource("v1")
+		const followBase = base.addResource("follow")
+		const followUser = followBase.addResource("{user}")
+		const healthcheck = followBase.addResource("healthcheck")
+		const user = base.addResource("users").addResource("{id}")
+		const followers = user.addResource("followers")
+		const following = user.addResource("following")
 
+		const followIntegration = new LambdaIntegration(follow)
+		healthcheck.addMethod("GET", followIntegration)
+		followUser.addMethod("POST", followIntegration)
+		followUser.addMethod("DELETE", followIntegration)
+		followers.addMethod("GET", followIntegration)
+		following.addMethod("GET", followIntegration)
 
 		new CfnOutput(this, "GatewayId", { value: api.restApiId })
 		new CfnOutput(this, "GatewayUrl", { value: api.url })
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"events/common/api"
+	"events/common/cursor"
+	"events/common/data"
+	"events/session"
+	"events/users/models"
+	"github.com/go-chi/chi/v5"
+)
+
+func (app *app) getUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 1 {
+		api.NotFoundResponse(w, r)
+		return
+	}
+
+	profile, err := app.models.Users.Get(int64(id))
+	app.writeProfile(w, r, profile, err)
+}
+
+func (app *app) getUserByUsernameHandler(w http.ResponseWriter, r *http.Request) {
+	profile, err := app.models.Users.GetByUsername(chi.URLParam(r, "username"))
+	app.writeProfile(w, r, profile, err)
+}
+
+func (app *app) getMeHandler(w http.ResponseWriter, r *http.Request) {
+	user := session.ContextGetUser(r)
+	profile, err := app.models.Users.Get(user.Id)
+	app.writeProfile(w, r, profile, err)
+}
+
+func (app *app) updateMeHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Username *string `json:"username"`
+		Name     *string `json:"name"`
+		Bio      *string `json:"bio"`
+		Avatar   *string `json:"avatar"`
+	}
+
+	err := api.ReadJSON(w, r, &input)
+	if err != nil {
+		api.BadRequestResponse(w, r, err)
+		return
+	}
+
+	update := models.ProfileUpdate{
+		Username: input.Username,
+		Name:     input.Name,
+		Bio:      input.Bio,
+		Avatar:   input.Avatar,
+	}
+
+	err = update.Validate()
+	if err != nil {
+		api.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := session.ContextGetUser(r)
+	profile, err := app.models.Users.Update(user.Id, update)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrDuplicateUsername):
+			api.ErrorResponse(w, r, http.StatusConflict, err.Error())
+		case errors.Is(err, models.ErrInvalidAvatar):
+			api.BadRequestResponse(w, r, err)
+		default:
+			app.writeProfile(w, r, profile, err)
+		}
+		return
+	}
+
+	app.writeProfile(w, r, profile, nil)
+}
+
+func (app *app) writeProfile(w http.ResponseWriter, r *http.Request, profile models.Profile, err error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			api.NotFoundResponse(w, r)
+		default:
+			api.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = api.WriteJSON(w, http.StatusOK, api.Envelope{"user": profile}, nil)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (app *app) searchUsersHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	take, err := api.ReadInt(qs, "take", cursor.DEFAULT_TAKE)
+	if err == nil {
+		err = cursor.ValidateFilters(cursor.Filters{Take: take})
+	}
+
+	if err != nil {
+		api.BadRequestResponse(w, r, cursor.ErrInvalidTake)
+		return
+	}
+
+	tsquery, err := models.SearchQuery(qs.Get("q"))
+	if err != nil {
+		api.BadRequestResponse(w, r, err)
+		return
+	}
+
+	var after *cursor.Ranked
+	if s := qs.Get("cursor"); s != "" {
+		after, err = cursor.DecodeRanked(s, tsquery)
+		if err != nil {
+			api.BadRequestResponse(w, r, err)
+			return
+		}
+	}
+
+	// anonymous searches work too, they just follow nobody
+	user := session.ContextGetUser(r)
+	users, metadata, err := app.models.Users.Search(qs.Get("q"), user.Id, after, take)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidQuery):
+			api.BadRequestResponse(w, r, err)
+		default:
+			api.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = api.WriteJSON(w, http.StatusOK, api.Envelope{"users": users, "metadata": metadata}, nil)
+	if err != nil {
+		api.ServerErrorResponse(w, r, err)
+	}
+}
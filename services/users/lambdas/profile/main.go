@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+
+	"events/common/api"
+	"events/common/data"
+	"events/session"
+	"events/users/models"
+	"github.com/go-chi/chi/v5"
+)
+
+var router *chi.Mux
+
+type app struct {
+	models models.Models
+}
+
+func init() {
+	db, err := data.OpenDB(os.Getenv("DB_ADDRESS"))
+	if err != nil {
+		panic(err)
+	}
+
+	app := app{models: models.NewModels(db, os.Getenv("MEDIA_BASE_URL"))}
+	r := api.NewRouter()
+	sessionModel := session.SessionModel{DB: db}
+	r.Use(sessionModel.Authenticate)
+	r.Route("/users", func(r chi.Router) {
+		r.Get("/healthcheck", api.HealthcheckHandler)
+		r.With(session.RequireAuthenticatedUser).Get("/me", app.getMeHandler)
+		r.With(session.RequireAuthenticatedUser).Patch("/me", app.updateMeHandler)
+		r.Get("/search", app.searchUsersHandler)
+		r.Get("/by-username/{username}", app.getUserByUsernameHandler)
+		r.Get("/{id}", app.getUserHandler)
+	})
+
+	router = r
+}
+
+func main() {
+	api.Start(router)
+}
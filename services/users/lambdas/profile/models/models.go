@@ -0,0 +1,15 @@
+package models
+
+import (
+	"database/sql"
+)
+
+type Models struct {
+	Users UserModel
+}
+
+func NewModels(db *sql.DB, mediaBaseURL string) Models {
+	return Models{
+		Users: UserModel{DB: db, MediaBaseURL: mediaBaseURL},
+	}
+}
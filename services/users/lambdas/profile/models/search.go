@@ -0,0 +1,143 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"events/common/cursor"
+)
+
+const (
+	SEARCH_MAX_TERMS  = 5
+	SEARCH_MAX_LENGTH = 100
+
+	// ts_rank of a username stays well below 1 so an exact match always
+	// comes first and followed accounts come before everyone else
+	EXACT_MATCH_BOOST = 2.0
+	FOLLOWING_BOOST   = 1.0
+)
+
+var ErrInvalidQuery = fmt.Errorf(
+	"q must have 1 to %d words of letters or digits and be at most %d characters",
+	SEARCH_MAX_TERMS,
+	SEARCH_MAX_LENGTH,
+)
+
+type SearchResult struct {
+	Id             int64  `json:"id"`
+	Username       string `json:"username"`
+	Name           string `json:"name"`
+	ProfilePicture string `json:"profile_picture"`
+	FollowersCount int64  `json:"followers_count"`
+	Following      bool   `json:"following"`
+	// what the results are ranked by, only needed for the cursor
+	Score float64 `json:"-"`
+}
+
+// SearchQuery turns what a user typed into a tsquery where every word has to
+// prefix one of the parts of the username, "bel us" finds beloved-user-1.
+// The words are letters and digits only so nothing in q reaches the tsquery
+// parser as syntax
+func SearchQuery(q string) (string, error) {
+	if len(q) > SEARCH_MAX_LENGTH {
+		return "", ErrInvalidQuery
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	if len(words) == 0 || len(words) > SEARCH_MAX_TERMS {
+		return "", ErrInvalidQuery
+	}
+
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+
+	return strings.Join(words, " & "), nil
+}
+
+// users whose username matches q, accounts viewerId follows are ranked above
+// the rest. viewerId is 0 for anonymous searches which follow nobody, after
+// is a cursor scoped to SearchQuery(q)
+func (u *UserModel) Search(q string, viewerId int64, after *cursor.Ranked, take int) ([]SearchResult, cursor.Metadata, error) {
+	metadata := cursor.Metadata{}
+
+	tsquery, err := SearchQuery(q)
+	if err != nil {
+		return nil, metadata, err
+	}
+
+	// the match has to be written exactly like users_username_idx for the
+	// planner to use it
+	query := fmt.Sprintf(`
+	WITH matches AS (
+		SELECT users.id, users.username, coalesce(users.name, '') AS name,
+		users.profile_picture, users.followers_count, EXISTS (
+			SELECT 1 FROM friend_edges AS edge
+			JOIN friend_nodes AS follower ON follower.id = edge.previous_node
+			JOIN friend_nodes AS followed ON followed.id = edge.next_node
+			WHERE follower.userId = $3 AND followed.userId = users.id
+		) AS following,
+		ts_rank(to_tsvector('simple', users.username), to_tsquery('simple', $1))
+		+ CASE WHEN lower(users.username) = lower($2) THEN %g ELSE 0 END AS text_rank
+		FROM users
+		WHERE to_tsvector('simple', users.username) @@ to_tsquery('simple', $1)
+	), scored AS (
+		SELECT *, (text_rank + CASE WHEN following THEN %g ELSE 0 END)::float8 AS score
+		FROM matches
+	)
+	SELECT id, username, name, profile_picture, followers_count, following, score
+	FROM scored
+	WHERE $4::bool OR (score, id) < ($5::float8, $6::bigint)
+	ORDER BY score DESC, id DESC
+	LIMIT $7
+	`, EXACT_MATCH_BOOST, FOLLOWING_BOOST)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	score, _, id, first := after.Args()
+
+	// one extra row tells whether there is another page
+	args := []any{tsquery, strings.TrimSpace(q), viewerId, first, score, id, take + 1}
+	rows, err := u.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, metadata, err
+	}
+
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var result SearchResult
+		err := rows.Scan(
+			&result.Id,
+			&result.Username,
+			&result.Name,
+			&result.ProfilePicture,
+			&result.FollowersCount,
+			&result.Following,
+			&result.Score,
+		)
+		if err != nil {
+			return nil, metadata, err
+		}
+
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, metadata, err
+	}
+
+	results, metadata = cursor.PaginateRanked(take, results, func(r SearchResult) cursor.Ranked {
+		return cursor.Ranked{Scope: tsquery, Score: r.Score, Id: r.Id}
+	})
+
+	return results, metadata, nil
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSearchQuery(t *testing.T) {
+	tests := []struct {
+		q    string
+		want string
+		err  error
+	}{
+		{"beloved", "beloved:*", nil},
+		{"  Beloved-User ", "beloved:* & user:*", nil},
+		{"jane.doe_1", "jane:* & doe:* & 1:*", nil},
+		{"o'brien & !x | y:*", "o:* & brien:* & x:* & y:*", nil},
+		{"zoë", "zoë:*", nil},
+		{"", "", ErrInvalidQuery},
+		{"-_.", "", ErrInvalidQuery},
+		{"a b c d e f", "", ErrInvalidQuery},
+		{strings.Repeat("a", SEARCH_MAX_LENGTH+1), "", ErrInvalidQuery},
+	}
+
+	for _, tt := range tests {
+		got, err := SearchQuery(tt.q)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("SearchQuery(%q) error = %v, want %v", tt.q, err, tt.err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("SearchQuery(%q) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
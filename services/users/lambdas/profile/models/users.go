@@ -0,0 +1,228 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"events/common/data"
+	"events/common/media"
+)
+
+// the square variant written by the image processing lambda, small enough to
+// be shown next to every post and comment
+const AVATAR_VARIANT = "thumb"
+
+var (
+	ErrDuplicateUsername = errors.New("username is already taken")
+	ErrInvalidAvatar     = errors.New("avatar must be one of your uploads that has finished processing")
+)
+
+type UserModel struct {
+	DB           *sql.DB
+	MediaBaseURL string
+}
+
+type Profile struct {
+	Id             int64     `json:"id"`
+	Username       string    `json:"username"`
+	Name           string    `json:"name"`
+	Bio            string    `json:"bio"`
+	ProfilePicture string    `json:"profile_picture"`
+	Avatar         *Avatar   `json:"avatar"`
+	CreatedAt      time.Time `json:"created_at"`
+	PostsCount     int64     `json:"posts_count"`
+	FollowersCount int64     `json:"followers_count"`
+	FollowingCount int64     `json:"following_count"`
+}
+
+// the upload the profile picture was made from, nil while the profile
+// picture is still the one from the oauth provider
+type Avatar struct {
+	Key      string             `json:"key"`
+	Width    int                `json:"width"`
+	Height   int                `json:"height"`
+	Blurhash string             `json:"blurhash"`
+	Variants map[string]Variant `json:"variants"`
+}
+
+type Variant struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
+
+func (u *UserModel) Get(id int64) (Profile, error) {
+	return u.get("users.id = $1", id)
+}
+
+// usernames are unique regardless of case so any casing finds the user
+func (u *UserModel) GetByUsername(username string) (Profile, error) {
+	return u.get("lower(users.username) = lower($1)", username)
+}
+
+func (u *UserModel) get(where string, arg any) (Profile, error) {
+	query := fmt.Sprintf(`
+	SELECT users.id, users.username, coalesce(users.name, ''), users.bio,
+	users.profile_picture, users.created_at, users.followers_count,
+	users.following_count, (
+		SELECT count(*) FROM posts WHERE posts.user_id = users.id
+	) AS posts_count, users.avatar_key, coalesce(images.width, 0),
+	coalesce(images.height, 0), coalesce(images.blurhash, '')
+	FROM users
+	LEFT JOIN images ON images.source_key = users.avatar_key
+	WHERE %s
+	`, where)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var profile Profile
+	var avatarKey sql.NullString
+	avatar := Avatar{Variants: map[string]Variant{}}
+	err := u.DB.QueryRowContext(ctx, query, arg).Scan(
+		&profile.Id,
+		&profile.Username,
+		&profile.Name,
+		&profile.Bio,
+		&profile.ProfilePicture,
+		&profile.CreatedAt,
+		&profile.FollowersCount,
+		&profile.FollowingCount,
+		&profile.PostsCount,
+		&avatarKey,
+		&avatar.Width,
+		&avatar.Height,
+		&avatar.Blurhash,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return profile, data.ErrRecordNotFound
+		default:
+			return profile, err
+		}
+	}
+
+	if !avatarKey.Valid {
+		return profile, nil
+	}
+
+	avatar.Key = avatarKey.String
+	err = u.loadVariants(ctx, &avatar)
+	if err != nil {
+		return profile, err
+	}
+
+	profile.Avatar = &avatar
+	return profile, nil
+}
+
+func (u *UserModel) loadVariants(ctx context.Context, avatar *Avatar) error {
+	query := `
+	SELECT name, key, content_type, width, height
+	FROM image_variants
+	WHERE source_key = $1
+	`
+
+	rows, err := u.DB.QueryContext(ctx, query, avatar.Key)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, key string
+		var variant Variant
+		err = rows.Scan(&name, &key, &variant.ContentType, &variant.Width, &variant.Height)
+		if err != nil {
+			return err
+		}
+
+		variant.URL = media.URL(u.MediaBaseURL, key)
+		avatar.Variants[name] = variant
+	}
+
+	return rows.Err()
+}
+
+// applies a validated update to the users own profile and returns the
+// profile as it is after the update
+func (u *UserModel) Update(userId int64, update ProfileUpdate) (Profile, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var profilePicture *string
+	if update.Avatar != nil {
+		url, err := u.avatarURL(ctx, userId, *update.Avatar)
+		if err != nil {
+			return Profile{}, err
+		}
+		profilePicture = &url
+	}
+
+	query := `
+	UPDATE users
+	SET username = coalesce($2, username),
+	name = coalesce($3, name),
+	bio = coalesce($4, bio),
+	avatar_key = coalesce($5, avatar_key),
+	profile_picture = coalesce($6, profile_picture)
+	WHERE id = $1
+	`
+
+	args := []any{userId, update.Username, update.Name, update.Bio, update.Avatar, profilePicture}
+	result, err := u.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "users_username_lower_key"`:
+			return Profile{}, ErrDuplicateUsername
+		default:
+			return Profile{}, err
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return Profile{}, err
+	}
+
+	if rowsAffected == 0 {
+		return Profile{}, data.ErrRecordNotFound
+	}
+
+	return u.Get(userId)
+}
+
+// url of the avatar sized variant of key, which has to be one of the users
+// uploads the image processing lambda managed to process
+func (u *UserModel) avatarURL(ctx context.Context, userId int64, key string) (string, error) {
+	if !strings.HasPrefix(key, fmt.Sprintf("%s/%d/", media.KEY_PREFIX, userId)) {
+		return "", ErrInvalidAvatar
+	}
+
+	query := `
+	SELECT image_variants.key
+	FROM images
+	JOIN image_variants
+		ON image_variants.source_key = images.source_key AND image_variants.name = $2
+	WHERE images.source_key = $1 AND images.error IS NULL
+	`
+
+	var variantKey string
+	err := u.DB.QueryRowContext(ctx, query, key, AVATAR_VARIANT).Scan(&variantKey)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return "", ErrInvalidAvatar
+		default:
+			return "", err
+		}
+	}
+
+	return media.URL(u.MediaBaseURL, variantKey), nil
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+const (
+	USERNAME_MIN = 3
+	USERNAME_MAX = 30
+	NAME_MAX     = 50
+	BIO_MAX      = 280
+)
+
+var usernameRX = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+var (
+	ErrInvalidUsername = fmt.Errorf(
+		"username must be %d to %d letters, digits, '_', '.' or '-'",
+		USERNAME_MIN,
+		USERNAME_MAX,
+	)
+	ErrInvalidName = fmt.Errorf("name must be 1 to %d characters", NAME_MAX)
+	ErrBioTooLong  = fmt.Errorf("bio too long, max %d characters", BIO_MAX)
+	ErrNoChanges   = errors.New("nothing to update, send a username, name, bio or avatar")
+)
+
+// the fields a user sent to change on their profile, nil ones are left as
+// they are
+type ProfileUpdate struct {
+	Username *string
+	Name     *string
+	Bio      *string
+	// object key of one of the users own uploads
+	Avatar *string
+}
+
+func (u ProfileUpdate) Validate() error {
+	if u.Username == nil && u.Name == nil && u.Bio == nil && u.Avatar == nil {
+		return ErrNoChanges
+	}
+
+	if u.Username != nil {
+		n := len(*u.Username)
+		if n < USERNAME_MIN || n > USERNAME_MAX || !usernameRX.MatchString(*u.Username) {
+			return ErrInvalidUsername
+		}
+	}
+
+	if u.Name != nil {
+		n := utf8.RuneCountInString(*u.Name)
+		if n < 1 || n > NAME_MAX {
+			return ErrInvalidName
+		}
+	}
+
+	if u.Bio != nil && utf8.RuneCountInString(*u.Bio) > BIO_MAX {
+		return ErrBioTooLong
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func ptr(s string) *string {
+	return &s
+}
+
+func TestProfileUpdateValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		update ProfileUpdate
+		want   error
+	}{
+		{"empty", ProfileUpdate{}, ErrNoChanges},
+		{"generated username", ProfileUpdate{Username: ptr("beloved-user-48213")}, nil},
+		{"dots and underscores", ProfileUpdate{Username: ptr("jane.doe_1")}, nil},
+		{"short username", ProfileUpdate{Username: ptr("ab")}, ErrInvalidUsername},
+		{"long username", ProfileUpdate{Username: ptr(strings.Repeat("a", USERNAME_MAX+1))}, ErrInvalidUsername},
+		{"username with spaces", ProfileUpdate{Username: ptr("jane doe")}, ErrInvalidUsername},
+		{"username with slash", ProfileUpdate{Username: ptr("jane/doe")}, ErrInvalidUsername},
+		{"empty name", ProfileUpdate{Name: ptr("")}, ErrInvalidName},
+		{"long name", ProfileUpdate{Name: ptr(strings.Repeat("a", NAME_MAX+1))}, ErrInvalidName},
+		{"empty bio", ProfileUpdate{Bio: ptr("")}, nil},
+		{"bio at max runes", ProfileUpdate{Bio: ptr(strings.Repeat("é", BIO_MAX))}, nil},
+		{"long bio", ProfileUpdate{Bio: ptr(strings.Repeat("a", BIO_MAX+1))}, ErrBioTooLong},
+		{"only avatar", ProfileUpdate{Avatar: ptr("posts/1/abc.jpg")}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.update.Validate()
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
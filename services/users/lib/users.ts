@@ -0,0 +1,75 @@
+import { CfnOutput, Tags } from 'aws-cdk-lib';
+import { Construct } from "constructs";
+import { RestApi, LambdaIntegration } from "aws-cdk-lib/aws-apigateway";
+import { Bucket } from 'aws-cdk-lib/aws-s3';
+import { createLambda } from '../../../lib/lambda';
+import * as path from "path"
+
+interface UsersProps {
+	db_url?: string
+	session_secret?: string
+	media_base_url: string
+}
+
+export class Users extends Construct {
+	constructor(scope: Construct, id: string, props: UsersProps) {
+		super(scope, id);
+
+		if (!props.db_url) {
+			throw new Error("DB env var is not set")
+		}
+
+		if (!props.session_secret) {
+			throw new Error("SESSION_SECRET env var is not set")
+		}
+
+		const hotReloadBucket = Bucket.fromBucketName(
+			this,
+			"HotReloadingBucket",
+			"hot-reload"
+		)
+
+		const profile = createLambda(
+			this,
+			"profile",
+			path.join(__dirname, "../lambdas/profile"),
+			hotReloadBucket,
+			{
+				DB_ADDRESS: props.db_url,
+				SESSION_SECRET: props.session_secret,
+				MEDIA_BASE_URL: props.media_base_url,
+			},
+			"Public user profiles, user search and editing your own profile",
+		)
+
+		const api = new RestApi(this, "usersapi", {
+			restApiName: "usersapi",
+			description: "API for user profiles",
+		})
+		Tags.of(api).add("_custom_id_", "usersapi")
+
+		// /users/healthcheck
+		// /users/me
+		// /users/search
+		// /users/by-username/{username}
+		// /users/{id}
+		const users = api.root.addResource("users")
+		const healthcheck = users.addResource("healthcheck")
+		const me = users.addResource("me")
+		const search = users.addResource("search")
+		const byUsername = users.addResource("by-username").addResource("{username}")
+		const user = users.addResource("{id}")
+
+		const profileIntegration = new LambdaIntegration(profile)
+		healthcheck.addMethod("GET", profileIntegration)
+		me.addMethod("GET", profileIntegration)
+		me.addMethod("PATCH", profileIntegration)
+		search.addMethod("GET", profileIntegration)
+		byUsername.addMethod("GET", profileIntegration)
+		user.addMethod("GET", profileIntegration)
+
+		new CfnOutput(this, "GatewayId", { value: api.restApiId })
+		new CfnOutput(this, "GatewayUrl", { value: api.url })
+		new CfnOutput(this, "GatewayEndPoints", { value: "\n" + api.methods.join("\n") })
+	}
+}